@@ -3,6 +3,9 @@ package container
 import (
 	"context"
 	"errors"
+	"fmt"
+	"io"
+	"net"
 	"os"
 	"strings"
 
@@ -21,6 +24,7 @@ type Container struct {
 	client      *client.Client
 	ContainerID string
 	ImageName   string
+	Labels      map[string]string
 }
 
 // NewContainer creates a new Container manager instance.
@@ -77,8 +81,9 @@ func (c *Container) Run(ctx context.Context, cmd []string, paths []string) error
 	}
 
 	resp, err := c.client.ContainerCreate(ctx, &container.Config{
-		Image: c.ImageName,
-		Cmd:   cmd,
+		Image:  c.ImageName,
+		Cmd:    cmd,
+		Labels: c.Labels,
 	}, hostConfig, nil, nil, "")
 	if err != nil {
 		return err
@@ -121,10 +126,73 @@ func (c *Container) Execute(ctx context.Context, cmd []string) (string, error) {
 		return "", err
 	}
 
-	if stderr.Len() > 0 {
-		return stderr.String(), nil
+	return combineOutput(stdout.String(), stderr.String()), nil
+}
+
+// Snapshot commits the container's filesystem to a new image tagged with the given reference.
+func (c *Container) Snapshot(ctx context.Context, tag string) (string, error) {
+	if c.ContainerID == "" {
+		return "", errors.New("container is not running")
+	}
+
+	_, err := c.client.ContainerCommit(ctx, c.ContainerID, container.CommitOptions{
+		Reference: tag,
+		Comment:   "agent snapshot",
+		Pause:     true,
+	})
+	if err != nil {
+		return "", err
 	}
-	return stdout.String(), nil
+
+	return tag, nil
+}
+
+// DialLoopback connects to a port on the container's loopback interface. The connection is
+// relayed by a bash process exec'd in the container, so the port never has to be reachable
+// from the container network.
+func (c *Container) DialLoopback(ctx context.Context, port int) (net.Conn, error) {
+	if c.ContainerID == "" {
+		return nil, errors.New("container is not running")
+	}
+
+	relay := fmt.Sprintf("exec 3<>/dev/tcp/127.0.0.1/%d || exit 1; cat <&3 & cat >&3", port)
+	execIDResp, err := c.client.ContainerExecCreate(ctx, c.ContainerID, container.ExecOptions{
+		Cmd:          []string{"bash", "-c", relay},
+		AttachStdin:  true,
+		AttachStdout: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.client.ContainerExecAttach(ctx, execIDResp.ID, container.ExecAttachOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	// Without a TTY the output is multiplexed with stream headers, which are stripped here.
+	reader, writer := io.Pipe()
+	go func() {
+		_, err := stdcopy.StdCopy(writer, io.Discard, resp.Reader)
+		writer.CloseWithError(err)
+	}()
+	return &execConn{Conn: resp.Conn, reader: reader}, nil
+}
+
+// execConn is a connection relayed through an exec session: writes go to the session's stdin
+// and reads come from its demultiplexed stdout.
+type execConn struct {
+	net.Conn
+	reader *io.PipeReader
+}
+
+func (c *execConn) Read(p []byte) (int, error) {
+	return c.reader.Read(p)
+}
+
+func (c *execConn) Close() error {
+	_ = c.reader.Close()
+	return c.Conn.Close()
 }
 
 // IsRunning checks if the container is currently running.
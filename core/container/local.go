@@ -0,0 +1,188 @@
+package container
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// labelFileSuffix is appended to a local sandbox directory to name the file holding its labels.
+const labelFileSuffix = ".labels.json"
+
+// LocalSandbox runs agent commands as plain processes on the host, confined to a
+// temporary working directory. It provides no isolation beyond the directory and is
+// meant for environments where no container runtime is available, such as locked-down CI runners.
+type LocalSandbox struct {
+	Dir    string
+	Labels map[string]string
+	// Template is an optional snapshot directory whose contents are copied into Dir on Run.
+	Template string
+}
+
+// NewLocalSandbox creates a new local-process sandbox. The working directory is created by Run.
+func NewLocalSandbox() *LocalSandbox {
+	return &LocalSandbox{}
+}
+
+// Run creates the temporary working directory. The command is ignored, since there is
+// no long-running container process to keep alive. Paths cannot be mounted into a plain
+// directory, so they are rejected rather than silently left out.
+func (s *LocalSandbox) Run(ctx context.Context, cmd []string, paths []string) error {
+	if len(paths) > 0 {
+		return fmt.Errorf("the local sandbox cannot mount paths: %s", strings.Join(paths, ", "))
+	}
+
+	dir, err := os.MkdirTemp("", "agent-sandbox-")
+	if err != nil {
+		return err
+	}
+	s.Dir = dir
+
+	// Labels are kept next to the directory, out of the agent's reach, so the reaper can find it.
+	if len(s.Labels) > 0 {
+		data, err := json.Marshal(s.Labels)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(dir+labelFileSuffix, data, 0o600); err != nil {
+			return err
+		}
+	}
+
+	if s.Template != "" {
+		if err := copyDir(s.Template, s.Dir); err != nil {
+			_ = s.StopAndRemove(ctx)
+			return err
+		}
+	}
+	return nil
+}
+
+// Execute runs a command in the sandbox directory and returns the output.
+func (s *LocalSandbox) Execute(ctx context.Context, cmd []string) (string, error) {
+	if s.Dir == "" {
+		return "", errors.New("sandbox is not running")
+	}
+	if len(cmd) == 0 {
+		return "", errors.New("no command given")
+	}
+
+	command := exec.CommandContext(ctx, cmd[0], cmd[1:]...)
+	command.Dir = s.Dir
+	command.Env = append(os.Environ(), "HOME="+s.Dir, "TMPDIR="+s.Dir)
+
+	var stdout, stderr bytes.Buffer
+	command.Stdout = &stdout
+	command.Stderr = &stderr
+
+	// A non-zero exit status is reported through the output, matching the container backends.
+	if err := command.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			return "", err
+		}
+	}
+
+	return combineOutput(stdout.String(), stderr.String()), nil
+}
+
+// Snapshot copies the sandbox directory into a new snapshot directory and returns its path.
+func (s *LocalSandbox) Snapshot(ctx context.Context, tag string) (string, error) {
+	if s.Dir == "" {
+		return "", errors.New("sandbox is not running")
+	}
+
+	dir, err := os.MkdirTemp("", "agent-snapshot-"+strings.NewReplacer("/", "-", ":", "-").Replace(tag)+"-")
+	if err != nil {
+		return "", err
+	}
+
+	if err := copyDir(s.Dir, dir); err != nil {
+		_ = os.RemoveAll(dir)
+		return "", err
+	}
+	return dir, nil
+}
+
+// IsRunning checks if the sandbox directory still exists.
+func (s *LocalSandbox) IsRunning(ctx context.Context) bool {
+	if s.Dir == "" {
+		return false
+	}
+	_, err := os.Stat(s.Dir)
+	return err == nil
+}
+
+// StopAndRemove deletes the sandbox directory and everything in it.
+func (s *LocalSandbox) StopAndRemove(ctx context.Context) error {
+	if s.Dir == "" {
+		return nil // Nothing to do
+	}
+	err := os.RemoveAll(s.Dir)
+	_ = os.Remove(s.Dir + labelFileSuffix)
+	s.Dir = ""
+	return err
+}
+
+// copyDir recursively copies the contents of src into dst, preserving file modes and symlinks.
+func copyDir(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case entry.IsDir():
+			return os.MkdirAll(target, info.Mode().Perm())
+		case info.Mode()&os.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		case info.Mode().IsRegular():
+			return copyFile(path, target, info.Mode().Perm())
+		default:
+			return nil // Skip sockets, devices and other special files
+		}
+	})
+}
+
+// copyFile copies a single regular file.
+func copyFile(src, dst string, perm os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
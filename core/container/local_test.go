@@ -0,0 +1,35 @@
+package container
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestLocalSandbox(t *testing.T) {
+	Convey("Given a running local sandbox", t, func() {
+		ctx := context.Background()
+		sandbox := NewLocalSandbox()
+		So(sandbox.Run(ctx, nil, nil), ShouldBeNil)
+		defer sandbox.StopAndRemove(ctx)
+
+		Convey("Both output streams are returned", func() {
+			output, err := sandbox.Execute(ctx, []string{"sh", "-c", "echo out; echo err >&2"})
+			So(err, ShouldBeNil)
+			So(output, ShouldEqual, "out\nerr\n")
+		})
+
+		Convey("Output without stderr is returned as is", func() {
+			output, err := sandbox.Execute(ctx, []string{"sh", "-c", "printf out"})
+			So(err, ShouldBeNil)
+			So(output, ShouldEqual, "out")
+		})
+	})
+
+	Convey("Paths cannot be mounted into a local sandbox", t, func() {
+		sandbox := NewLocalSandbox()
+		So(sandbox.Run(context.Background(), nil, []string{"/data"}), ShouldNotBeNil)
+		So(sandbox.Dir, ShouldBeEmpty)
+	})
+}
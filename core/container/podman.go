@@ -0,0 +1,84 @@
+package container
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/docker/docker/client"
+)
+
+// PodmanContainer runs the agent environment in a rootless Podman container.
+// Podman exposes a Docker-compatible API on its user socket, so the container
+// lifecycle is shared with the Docker implementation and only the connection differs.
+type PodmanContainer struct {
+	*Container
+}
+
+// NewPodmanContainer creates a new Podman container manager instance.
+// The socket is taken from CONTAINER_HOST or PODMAN_HOST when set, otherwise the
+// rootless user socket under XDG_RUNTIME_DIR is used.
+func NewPodmanContainer(imageName string) (*PodmanContainer, error) {
+	host, err := podmanHost()
+	if err != nil {
+		return nil, err
+	}
+
+	cli, err := client.NewClientWithOpts(client.WithHost(host), client.WithAPIVersionNegotiation())
+	if err != nil {
+		return nil, err
+	}
+
+	return &PodmanContainer{
+		Container: &Container{client: cli, ImageName: qualifyImageName(imageName)},
+	}, nil
+}
+
+// Snapshot commits the container to a local image. Podman stores committed images
+// under the localhost registry, so the returned reference is qualified accordingly.
+func (c *PodmanContainer) Snapshot(ctx context.Context, tag string) (string, error) {
+	ref, err := c.Container.Snapshot(ctx, tag)
+	if err != nil {
+		return "", err
+	}
+	if !strings.Contains(ref, "/") {
+		ref = "localhost/" + ref
+	}
+	return ref, nil
+}
+
+// podmanHost resolves the address of the Podman API socket.
+func podmanHost() (string, error) {
+	for _, key := range []string{"CONTAINER_HOST", "PODMAN_HOST"} {
+		if host := os.Getenv(key); host != "" {
+			return host, nil
+		}
+	}
+
+	runtimeDir := os.Getenv("XDG_RUNTIME_DIR")
+	if runtimeDir == "" {
+		runtimeDir = fmt.Sprintf("/run/user/%d", os.Getuid())
+	}
+
+	socket := filepath.Join(runtimeDir, "podman", "podman.sock")
+	if _, err := os.Stat(socket); err != nil {
+		return "", fmt.Errorf("podman socket not found at %s (start it with 'systemctl --user start podman.socket'): %w", socket, err)
+	}
+
+	return "unix://" + socket, nil
+}
+
+// qualifyImageName prefixes short image names with docker.io, since rootless
+// Podman does not resolve unqualified names without a registries configuration.
+func qualifyImageName(imageName string) string {
+	first, _, found := strings.Cut(imageName, "/")
+	if found && (strings.ContainsAny(first, ".:") || first == "localhost") {
+		return imageName
+	}
+	if !found {
+		return "docker.io/library/" + imageName
+	}
+	return "docker.io/" + imageName
+}
@@ -0,0 +1,177 @@
+package container
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/client"
+)
+
+// Labels attached to every sandbox so that it can be traced back to the agent and
+// the server process that created it.
+const (
+	LabelAgentID  = "mcp-devops-bridge.agent-id"
+	LabelInstance = "mcp-devops-bridge.instance"
+	LabelHost     = "mcp-devops-bridge.host"
+	LabelPID      = "mcp-devops-bridge.pid"
+)
+
+var (
+	hostname, _ = os.Hostname()
+	// InstanceID uniquely identifies this server process.
+	InstanceID = fmt.Sprintf("%s-%d-%d", hostname, os.Getpid(), time.Now().Unix())
+)
+
+// AgentLabels returns the labels identifying a sandbox created for the given agent by this server instance.
+func AgentLabels(agentID string) map[string]string {
+	return map[string]string{
+		LabelAgentID:  agentID,
+		LabelInstance: InstanceID,
+		LabelHost:     hostname,
+		LabelPID:      strconv.Itoa(os.Getpid()),
+	}
+}
+
+// Reaper removes labeled sandboxes that no longer belong to a live agent. It connects to the
+// runtime once and reuses the connection for every pass.
+type Reaper struct {
+	runtime Runtime
+	cli     *client.Client // nil for the local runtime
+}
+
+// NewReaper creates a Reaper for the given runtime.
+func NewReaper(runtime Runtime) (*Reaper, error) {
+	reaper := &Reaper{runtime: runtime}
+	if runtime == RuntimeLocal {
+		return reaper, nil
+	}
+
+	cli, err := runtimeClient(runtime)
+	if err != nil {
+		return nil, err
+	}
+	reaper.cli = cli
+	return reaper, nil
+}
+
+// Reap removes the orphaned sandboxes. A sandbox is an orphan when it was created by this
+// instance for an agent that isLive does not know about, or when it was created by another
+// server process on this host that is no longer running. Sandboxes owned by live server
+// processes, or by processes on other hosts, are left alone.
+// It returns the agent IDs whose sandboxes were removed.
+func (r *Reaper) Reap(ctx context.Context, isLive func(agentID string) bool) ([]string, error) {
+	if r.cli == nil {
+		return reapLocalSandboxes(isLive)
+	}
+	return reapContainers(ctx, r.cli, isLive)
+}
+
+// Close releases the connection to the runtime.
+func (r *Reaper) Close() error {
+	if r.cli == nil {
+		return nil
+	}
+	return r.cli.Close()
+}
+
+// runtimeClient connects to the Docker-compatible API of a container runtime.
+func runtimeClient(runtime Runtime) (*client.Client, error) {
+	switch runtime {
+	case RuntimeDocker, "":
+		return client.NewClientWithOpts(client.FromEnv)
+	case RuntimePodman:
+		host, err := podmanHost()
+		if err != nil {
+			return nil, err
+		}
+		return client.NewClientWithOpts(client.WithHost(host), client.WithAPIVersionNegotiation())
+	default:
+		return nil, fmt.Errorf("unsupported sandbox runtime: %s", runtime)
+	}
+}
+
+// reapContainers removes orphaned containers through a Docker-compatible API.
+func reapContainers(ctx context.Context, cli *client.Client, isLive func(agentID string) bool) ([]string, error) {
+	containers, err := cli.ContainerList(ctx, container.ListOptions{
+		All:     true,
+		Filters: filters.NewArgs(filters.Arg("label", LabelAgentID)),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var reaped []string
+	for _, c := range containers {
+		if !isOrphan(c.Labels, isLive) {
+			continue
+		}
+		if err := cli.ContainerRemove(ctx, c.ID, container.RemoveOptions{Force: true}); err != nil {
+			log.Warnf("Failed to remove orphaned container %s: %v", c.ID, err)
+			continue
+		}
+		reaped = append(reaped, c.Labels[LabelAgentID])
+	}
+	return reaped, nil
+}
+
+// reapLocalSandboxes removes orphaned local sandbox directories, identified by their label files.
+func reapLocalSandboxes(isLive func(agentID string) bool) ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(os.TempDir(), "agent-sandbox-*"+labelFileSuffix))
+	if err != nil {
+		return nil, err
+	}
+
+	var reaped []string
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		var labels map[string]string
+		if err := json.Unmarshal(data, &labels); err != nil || !isOrphan(labels, isLive) {
+			continue
+		}
+		if err := os.RemoveAll(strings.TrimSuffix(file, labelFileSuffix)); err != nil {
+			log.Warnf("Failed to remove orphaned sandbox %s: %v", file, err)
+			continue
+		}
+		_ = os.Remove(file)
+		reaped = append(reaped, labels[LabelAgentID])
+	}
+	return reaped, nil
+}
+
+// isOrphan decides whether a sandbox with the given labels has lost its owner.
+func isOrphan(labels map[string]string, isLive func(agentID string) bool) bool {
+	if labels[LabelInstance] == InstanceID {
+		return !isLive(labels[LabelAgentID])
+	}
+	if labels[LabelHost] != hostname {
+		return false
+	}
+	pid, err := strconv.Atoi(labels[LabelPID])
+	if err != nil {
+		return false
+	}
+	return !processAlive(pid)
+}
+
+// processAlive reports whether a process with the given PID is running on this host.
+func processAlive(pid int) bool {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	return !errors.Is(process.Signal(syscall.Signal(0)), os.ErrProcessDone)
+}
@@ -0,0 +1,142 @@
+package container
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/docker/docker/api/types/image"
+)
+
+// Runtime identifies which backend is used to isolate an agent's environment.
+type Runtime string
+
+const (
+	// RuntimeDocker runs agents in containers managed by the Docker Engine API.
+	RuntimeDocker Runtime = "docker"
+	// RuntimePodman runs agents in rootless Podman containers through Podman's Docker-compatible API.
+	RuntimePodman Runtime = "podman"
+	// RuntimeLocal runs agent commands as plain local processes inside a temporary directory.
+	RuntimeLocal Runtime = "local"
+)
+
+// Sandbox is an isolated environment in which an agent can execute shell commands.
+// It abstracts over the available runtimes so the agent system can run on hosts
+// with Docker, with rootless Podman, or without any container runtime at all.
+type Sandbox interface {
+	// Run prepares the environment and starts its long-running process, if any.
+	Run(ctx context.Context, cmd []string, paths []string) error
+	// Execute runs a command inside the environment and returns its output.
+	Execute(ctx context.Context, cmd []string) (string, error)
+	// IsRunning reports whether the environment is still available.
+	IsRunning(ctx context.Context) bool
+	// StopAndRemove tears the environment down and releases its resources.
+	StopAndRemove(ctx context.Context) error
+}
+
+// Snapshotter is implemented by sandboxes whose current state can be captured and
+// later used to start a new sandbox from the same point.
+type Snapshotter interface {
+	// Snapshot captures the sandbox state under the given tag and returns a reference
+	// (an image name or a directory) that can be used as the image of a new sandbox.
+	Snapshot(ctx context.Context, tag string) (string, error)
+}
+
+// LoopbackDialer is implemented by sandboxes that can open a connection to a port listening
+// on the sandbox's own loopback interface, so services inside it need not be exposed on the
+// network to be used by the server.
+type LoopbackDialer interface {
+	// DialLoopback connects to the port on the loopback interface inside the sandbox.
+	DialLoopback(ctx context.Context, port int) (net.Conn, error)
+}
+
+var (
+	_ LoopbackDialer = (*Container)(nil)
+	_ LoopbackDialer = (*PodmanContainer)(nil)
+	_ Sandbox        = (*Container)(nil)
+	_ Sandbox        = (*PodmanContainer)(nil)
+	_ Sandbox        = (*LocalSandbox)(nil)
+	_ Snapshotter    = (*Container)(nil)
+	_ Snapshotter    = (*PodmanContainer)(nil)
+	_ Snapshotter    = (*LocalSandbox)(nil)
+)
+
+// ParseRuntime converts a configuration value into a Runtime, defaulting to Docker when empty.
+func ParseRuntime(value string) (Runtime, error) {
+	switch Runtime(strings.ToLower(strings.TrimSpace(value))) {
+	case "", RuntimeDocker:
+		return RuntimeDocker, nil
+	case RuntimePodman:
+		return RuntimePodman, nil
+	case RuntimeLocal:
+		return RuntimeLocal, nil
+	default:
+		return "", fmt.Errorf("unknown sandbox runtime '%s', expected one of: docker, podman, local", value)
+	}
+}
+
+// NewSandbox creates a Sandbox for the given runtime, attaching the labels to it. For the local
+// backend the image name is only used when it refers to a snapshot directory, whose contents then
+// seed the working directory.
+func NewSandbox(runtime Runtime, imageName string, labels map[string]string) (Sandbox, error) {
+	switch runtime {
+	case RuntimeDocker, "":
+		c, err := NewContainer(imageName)
+		if err != nil {
+			return nil, err
+		}
+		c.Labels = labels
+		return c, nil
+	case RuntimePodman:
+		c, err := NewPodmanContainer(imageName)
+		if err != nil {
+			return nil, err
+		}
+		c.Labels = labels
+		return c, nil
+	case RuntimeLocal:
+		sandbox := NewLocalSandbox()
+		sandbox.Labels = labels
+		if info, err := os.Stat(imageName); err == nil && info.IsDir() {
+			sandbox.Template = imageName
+		}
+		return sandbox, nil
+	default:
+		return nil, fmt.Errorf("unsupported sandbox runtime: %s", runtime)
+	}
+}
+
+// combineOutput joins the output streams of a command, stdout first, so that neither is lost
+// when a command writes warnings to stderr.
+func combineOutput(stdout, stderr string) string {
+	if stdout == "" || stderr == "" {
+		return stdout + stderr
+	}
+	if !strings.HasSuffix(stdout, "\n") {
+		stdout += "\n"
+	}
+	return stdout + stderr
+}
+
+// RemoveSnapshot deletes a snapshot taken with Snapshotter.Snapshot: the committed image for the
+// container runtimes, or the snapshot directory for the local runtime.
+func RemoveSnapshot(ctx context.Context, runtime Runtime, ref string) error {
+	if runtime == RuntimeLocal {
+		if !strings.HasPrefix(filepath.Base(ref), "agent-snapshot-") {
+			return fmt.Errorf("%s is not a snapshot directory", ref)
+		}
+		return os.RemoveAll(ref)
+	}
+
+	cli, err := runtimeClient(runtime)
+	if err != nil {
+		return err
+	}
+	defer cli.Close()
+
+	_, err = cli.ImageRemove(ctx, ref, image.RemoveOptions{PruneChildren: true})
+	return err
+}
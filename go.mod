@@ -15,7 +15,9 @@ require (
 	github.com/slack-go/slack v0.13.0
 	github.com/smartystreets/goconvey v1.8.1
 	github.com/spf13/viper v1.19.0
+	golang.org/x/net v0.35.0
 	golang.org/x/oauth2 v0.30.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -94,6 +96,5 @@ require (
 	golang.org/x/text v0.22.0 // indirect
 	golang.org/x/time v0.6.0 // indirect
 	gopkg.in/ini.v1 v1.67.0 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
 	gotest.tools/v3 v3.5.1 // indirect
 )
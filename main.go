@@ -110,8 +110,10 @@ func main() {
 		multiTool.addTool(slackTool.Handle().Name, slackTool)
 	}
 
-	// // Start agent cleanup goroutine
-	// ai.StartAgentCleanup()
+	// Start agent cleanup goroutine
+	if agentProvider != nil {
+		agents.StartAgentCleanup()
+	}
 
 	if err := server.ServeStdio(mcpServer); err != nil {
 		panic(err)
@@ -4,8 +4,11 @@ package config
 import (
 	"fmt"
 	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/spf13/viper"
 )
@@ -38,6 +41,56 @@ type Config struct {
 		Model  string
 	}
 
+	// Agent system configuration
+	Agents struct {
+		// Runtime selects the sandbox backend: docker, podman or local.
+		Runtime string
+		Image   string
+		// IdleTimeout is how long an agent may wait for input before it is shut down. Zero disables it.
+		IdleTimeout time.Duration
+		// ReapInterval is how often idle agents and orphaned sandboxes are cleaned up.
+		ReapInterval time.Duration
+		// MaxConcurrent caps how many agents hold a live sandbox at once; further launches are queued
+		// until an agent completes, fails or is shut down and its sandbox has been removed. Zero means unlimited.
+		MaxConcurrent int
+		// MaxLLMCalls caps how many LLM requests agents may have in flight at once. Zero means unlimited.
+		MaxLLMCalls int
+		// MaxParallelTools caps how many independent tool calls of one model response an agent runs at once. 1 runs them one by one.
+		MaxParallelTools int
+		// LLMMaxRetries is how often a rate-limited or failed LLM request is retried before the agent fails.
+		LLMMaxRetries int
+		// LLMRetryBaseDelay and LLMRetryMaxDelay bound the exponential backoff between LLM retries.
+		// A request whose Retry-After exceeds LLMRetryMaxDelay fails instead of waiting.
+		LLMRetryBaseDelay time.Duration
+		LLMRetryMaxDelay  time.Duration
+		// BrowserMode is either "shared" (one host Chromium with a context per agent) or "container" (Chromium in each agent's container).
+		BrowserMode string
+		// BrowserMaxPages caps how many browser page operations may run at once across all agents.
+		BrowserMaxPages int
+		// ArtifactDir is where files agents produce, such as browser screenshots, are saved.
+		ArtifactDir string
+		// TemplateDir is the directory agent templates are loaded from.
+		TemplateDir string
+		// URLAllowlist, when set, restricts agents to these domain patterns.
+		URLAllowlist []string
+		// URLDenylist are domain patterns agents may never access.
+		URLDenylist []string
+		// URLSchemes are the URL schemes agents may access.
+		URLSchemes []string
+		// URLAllowPrivate permits access to loopback, private and link-local addresses.
+		URLAllowPrivate bool
+		// URLRespectRobots makes agents honor robots.txt.
+		URLRespectRobots bool
+		// ApprovalCommandPattern is a regular expression; matching shell commands wait for human approval.
+		ApprovalCommandPattern string
+		// ApprovalWrites makes browser clicks, form submits and mutating HTTP requests wait for approval.
+		ApprovalWrites bool
+		// ApprovalTools are tool name patterns that always wait for approval.
+		ApprovalTools []string
+		// ApprovalTimeout rejects pending approvals nobody decided on in time. Zero waits indefinitely.
+		ApprovalTimeout time.Duration
+	}
+
 	// Sentry configuration
 	Sentry struct {
 		DSN                string
@@ -61,6 +114,22 @@ func Load() *Config {
 
 		// Set default values
 		v.SetDefault("openai.model", "gpt-4o-mini")
+		v.SetDefault("agents.runtime", "docker")
+		v.SetDefault("agents.image", "debian:stable-slim")
+		v.SetDefault("agents.idle_timeout", "30m")
+		v.SetDefault("agents.reap_interval", "1m")
+		v.SetDefault("agents.max_concurrent", 0)
+		v.SetDefault("agents.max_llm_calls", 0)
+		v.SetDefault("agents.max_parallel_tools", 4)
+		v.SetDefault("agents.llm_max_retries", 5)
+		v.SetDefault("agents.llm_retry_base_delay", "1s")
+		v.SetDefault("agents.llm_retry_max_delay", "1m")
+		v.SetDefault("agents.browser_mode", "shared")
+		v.SetDefault("agents.browser_max_pages", 4)
+		v.SetDefault("agents.artifact_dir", filepath.Join(os.TempDir(), "mcp-agent-artifacts"))
+		v.SetDefault("agents.url_schemes", "http,https")
+		v.SetDefault("agents.template_dir", "agent-templates")
+		v.SetDefault("agents.approval_timeout", "1h")
 
 		// Load from environment variables
 		v.AutomaticEnv()
@@ -89,6 +158,46 @@ func Load() *Config {
 			config.OpenAI.Model = v.GetString("openai.model")
 		}
 
+		// Agents
+		config.Agents.Runtime = os.Getenv("AGENT_SANDBOX_RUNTIME")
+		if config.Agents.Runtime == "" {
+			config.Agents.Runtime = v.GetString("agents.runtime")
+		}
+		config.Agents.Image = os.Getenv("AGENT_SANDBOX_IMAGE")
+		if config.Agents.Image == "" {
+			config.Agents.Image = v.GetString("agents.image")
+		}
+		config.Agents.IdleTimeout = durationFromEnv("AGENT_IDLE_TIMEOUT", v.GetDuration("agents.idle_timeout"))
+		config.Agents.ReapInterval = durationFromEnv("AGENT_REAP_INTERVAL", v.GetDuration("agents.reap_interval"))
+		config.Agents.MaxConcurrent = intFromEnv("AGENT_MAX_CONCURRENT", v.GetInt("agents.max_concurrent"))
+		config.Agents.MaxLLMCalls = intFromEnv("AGENT_MAX_LLM_CALLS", v.GetInt("agents.max_llm_calls"))
+		config.Agents.MaxParallelTools = intFromEnv("AGENT_MAX_PARALLEL_TOOLS", v.GetInt("agents.max_parallel_tools"))
+		config.Agents.LLMMaxRetries = intFromEnv("AGENT_LLM_MAX_RETRIES", v.GetInt("agents.llm_max_retries"))
+		config.Agents.LLMRetryBaseDelay = durationFromEnv("AGENT_LLM_RETRY_BASE_DELAY", v.GetDuration("agents.llm_retry_base_delay"))
+		config.Agents.LLMRetryMaxDelay = durationFromEnv("AGENT_LLM_RETRY_MAX_DELAY", v.GetDuration("agents.llm_retry_max_delay"))
+		config.Agents.BrowserMode = os.Getenv("AGENT_BROWSER_MODE")
+		if config.Agents.BrowserMode == "" {
+			config.Agents.BrowserMode = v.GetString("agents.browser_mode")
+		}
+		config.Agents.BrowserMaxPages = intFromEnv("AGENT_BROWSER_MAX_PAGES", v.GetInt("agents.browser_max_pages"))
+		config.Agents.ArtifactDir = os.Getenv("AGENT_ARTIFACT_DIR")
+		if config.Agents.ArtifactDir == "" {
+			config.Agents.ArtifactDir = v.GetString("agents.artifact_dir")
+		}
+		config.Agents.TemplateDir = os.Getenv("AGENT_TEMPLATE_DIR")
+		if config.Agents.TemplateDir == "" {
+			config.Agents.TemplateDir = v.GetString("agents.template_dir")
+		}
+		config.Agents.URLAllowlist = listFromEnv("AGENT_URL_ALLOWLIST", "")
+		config.Agents.URLDenylist = listFromEnv("AGENT_URL_DENYLIST", "")
+		config.Agents.URLSchemes = listFromEnv("AGENT_URL_SCHEMES", v.GetString("agents.url_schemes"))
+		config.Agents.URLAllowPrivate = boolFromEnv("AGENT_URL_ALLOW_PRIVATE", false)
+		config.Agents.URLRespectRobots = boolFromEnv("AGENT_URL_RESPECT_ROBOTS", false)
+		config.Agents.ApprovalCommandPattern = os.Getenv("AGENT_APPROVAL_COMMAND_PATTERN")
+		config.Agents.ApprovalWrites = boolFromEnv("AGENT_APPROVAL_WRITES", false)
+		config.Agents.ApprovalTools = listFromEnv("AGENT_APPROVAL_TOOLS", "")
+		config.Agents.ApprovalTimeout = durationFromEnv("AGENT_APPROVAL_TIMEOUT", v.GetDuration("agents.approval_timeout"))
+
 		// Sentry
 		config.Sentry.DSN = os.Getenv("SENTRY_DSN")
 		config.Sentry.AuthToken = os.Getenv("SENTRY_AUTH_TOKEN")
@@ -104,6 +213,64 @@ func Load() *Config {
 	return config
 }
 
+// durationFromEnv parses a duration such as "30m" from an environment variable,
+// falling back to the default when it is unset or invalid.
+func durationFromEnv(key string, fallback time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return fallback
+	}
+	return d
+}
+
+// intFromEnv parses an integer from an environment variable, falling back to the
+// default when it is unset or invalid.
+func intFromEnv(key string, fallback int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return fallback
+	}
+	return n
+}
+
+// listFromEnv splits a comma-separated environment variable into its trimmed, non-empty
+// values, falling back to the default when it is unset.
+func listFromEnv(key string, fallback string) []string {
+	value, ok := os.LookupEnv(key)
+	if !ok {
+		value = fallback
+	}
+	var values []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			values = append(values, item)
+		}
+	}
+	return values
+}
+
+// boolFromEnv parses a boolean from an environment variable, falling back to the
+// default when it is unset or invalid.
+func boolFromEnv(key string, fallback bool) bool {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return fallback
+	}
+	return b
+}
+
 // Validate checks if all required configuration values are set
 func (c *Config) Validate() error {
 	// List of validation errors
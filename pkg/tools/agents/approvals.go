@@ -0,0 +1,209 @@
+package agents
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/theapemachine/mcp-server-devops-bridge/pkg/config"
+)
+
+// ApprovalDecision is the outcome of a pending approval.
+type ApprovalDecision string
+
+const (
+	ApprovalApproved ApprovalDecision = "approved"
+	ApprovalRejected ApprovalDecision = "rejected"
+	ApprovalExpired  ApprovalDecision = "expired"
+)
+
+// writeToolPatterns match tools that change state outside the agent's sandbox. Clicks are
+// gated as well, since any click may submit a form, through a submit button or a script.
+var writeToolPatterns = []string{"browser_submit"}
+
+// mutatingMethods are the HTTP methods that make http_request a write action.
+var mutatingMethods = map[string]bool{"POST": true, "PUT": true, "PATCH": true, "DELETE": true}
+
+// ApprovalPolicy decides which agent actions must be approved by a human before they run.
+type ApprovalPolicy struct {
+	// CommandPattern matches execute_command commands that need approval.
+	CommandPattern *regexp.Regexp
+	// Writes gates actions that change state outside the sandbox: clicks and form submits in
+	// the browser and mutating HTTP requests.
+	Writes bool
+	// Tools are additional tool name patterns that always need approval.
+	Tools []string
+	// Timeout rejects approvals nobody decided on in time. Zero waits indefinitely.
+	Timeout time.Duration
+}
+
+// NewApprovalPolicy builds the approval policy from the configuration.
+func NewApprovalPolicy() (*ApprovalPolicy, error) {
+	cfg := config.Load()
+
+	policy := &ApprovalPolicy{
+		Writes:  cfg.Agents.ApprovalWrites,
+		Tools:   cfg.Agents.ApprovalTools,
+		Timeout: cfg.Agents.ApprovalTimeout,
+	}
+
+	if cfg.Agents.ApprovalCommandPattern != "" {
+		pattern, err := regexp.Compile(cfg.Agents.ApprovalCommandPattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid AGENT_APPROVAL_COMMAND_PATTERN: %w", err)
+		}
+		policy.CommandPattern = pattern
+	}
+	return policy, nil
+}
+
+// Reason returns why the tool call needs approval, or an empty string if it may run right away.
+func (p *ApprovalPolicy) Reason(tool, arguments string) string {
+	if matchesToolPattern(p.Tools, tool) {
+		return fmt.Sprintf("the tool %s always requires approval", tool)
+	}
+
+	var args struct {
+		Command string `json:"command"`
+		Method  string `json:"method"`
+	}
+	_ = json.Unmarshal([]byte(arguments), &args)
+
+	if p.CommandPattern != nil && tool == "execute_command" && p.CommandPattern.MatchString(args.Command) {
+		return fmt.Sprintf("the command matches the approval pattern %q", p.CommandPattern.String())
+	}
+	if p.Writes {
+		if tool == "http_request" && mutatingMethods[strings.ToUpper(args.Method)] {
+			return fmt.Sprintf("%s requests change state on the remote server", strings.ToUpper(args.Method))
+		}
+		if tool == "browser_click" {
+			return "a click may submit a form or otherwise change state on the site"
+		}
+		if matchesToolPattern(writeToolPatterns, tool) {
+			return fmt.Sprintf("%s is a write action", tool)
+		}
+	}
+	return ""
+}
+
+func matchesToolPattern(patterns []string, tool string) bool {
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(pattern, tool); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// PendingApproval is an agent action waiting for a human decision.
+type PendingApproval struct {
+	ID          string          `json:"id"`
+	AgentID     string          `json:"agent_id"`
+	Tool        string          `json:"tool"`
+	Arguments   json.RawMessage `json:"arguments"`
+	Reason      string          `json:"reason"`
+	RequestedAt time.Time       `json:"requested_at"`
+	decision    chan approvalDecision
+}
+
+type approvalDecision struct {
+	decision ApprovalDecision
+	note     string
+}
+
+// approvalQueue holds the pending approvals of all agents.
+type approvalQueue struct {
+	pending map[string]*PendingApproval
+	mu      sync.Mutex
+}
+
+func newApprovalQueue() *approvalQueue {
+	return &approvalQueue{pending: make(map[string]*PendingApproval)}
+}
+
+// awaitApproval pauses the agent in StatusAwaitingApproval until a human approves or rejects
+// the action, the approval times out, or the agent is shut down. It returns the decision
+// and the reviewer's note.
+func (m *AgentManager) awaitApproval(agent *Agent, tool, arguments, reason string) (ApprovalDecision, string) {
+	args := json.RawMessage(arguments)
+	if !json.Valid(args) {
+		args, _ = json.Marshal(arguments)
+	}
+
+	approval := &PendingApproval{
+		ID:          uuid.New().String(),
+		AgentID:     agent.ID,
+		Tool:        tool,
+		Arguments:   args,
+		Reason:      reason,
+		RequestedAt: time.Now(),
+		decision:    make(chan approvalDecision, 1),
+	}
+
+	m.approvals.mu.Lock()
+	m.approvals.pending[approval.ID] = approval
+	m.approvals.mu.Unlock()
+
+	defer func() {
+		m.approvals.mu.Lock()
+		delete(m.approvals.pending, approval.ID)
+		m.approvals.mu.Unlock()
+	}()
+
+	agent.setStatus(StatusAwaitingApproval, "")
+
+	var timeout <-chan time.Time
+	if m.approvalPolicy.Timeout > 0 {
+		timeout = time.After(m.approvalPolicy.Timeout)
+	}
+
+	var result approvalDecision
+	select {
+	case result = <-approval.decision:
+	case <-timeout:
+		result = approvalDecision{decision: ApprovalExpired, note: fmt.Sprintf("nobody decided within %s", m.approvalPolicy.Timeout)}
+	case <-agent.shutdownChan:
+		result = approvalDecision{decision: ApprovalRejected, note: "the agent was shut down"}
+	}
+
+	agent.setStatus(StatusRunning, "")
+	return result.decision, result.note
+}
+
+// PendingApprovals returns the actions waiting for a decision, oldest first. An empty agentID lists all agents.
+func (m *AgentManager) PendingApprovals(agentID string) []*PendingApproval {
+	m.approvals.mu.Lock()
+	defer m.approvals.mu.Unlock()
+
+	approvals := make([]*PendingApproval, 0, len(m.approvals.pending))
+	for _, approval := range m.approvals.pending {
+		if agentID == "" || approval.AgentID == agentID {
+			approvals = append(approvals, approval)
+		}
+	}
+	sort.Slice(approvals, func(i, j int) bool { return approvals[i].RequestedAt.Before(approvals[j].RequestedAt) })
+	return approvals
+}
+
+// DecideApproval approves or rejects a pending action, resuming its agent.
+func (m *AgentManager) DecideApproval(approvalID string, decision ApprovalDecision, note string) (*PendingApproval, error) {
+	m.approvals.mu.Lock()
+	approval, exists := m.approvals.pending[approvalID]
+	if exists {
+		delete(m.approvals.pending, approvalID)
+	}
+	m.approvals.mu.Unlock()
+
+	if !exists {
+		return nil, fmt.Errorf("no pending approval with ID %s; it may already have been decided or expired", approvalID)
+	}
+
+	approval.decision <- approvalDecision{decision: decision, note: note}
+	return approval, nil
+}
@@ -0,0 +1,101 @@
+package agents
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestApprovalPolicyReason(t *testing.T) {
+	Convey("Given an approval policy gating writes", t, func() {
+		policy := &ApprovalPolicy{Writes: true}
+
+		Convey("It should gate browser clicks and form submits", func() {
+			So(policy.Reason("browser_click", `{"selector":"button[type=submit]"}`), ShouldContainSubstring, "may submit a form")
+			So(policy.Reason("browser_click", `{"selector":"a.next"}`), ShouldNotBeEmpty)
+			So(policy.Reason("browser_submit", `{"selector":"form"}`), ShouldEqual, "browser_submit is a write action")
+		})
+
+		Convey("It should gate mutating HTTP requests only", func() {
+			So(policy.Reason("http_request", `{"method":"post","url":"https://example.com"}`), ShouldEqual, "POST requests change state on the remote server")
+			So(policy.Reason("http_request", `{"url":"https://example.com"}`), ShouldBeEmpty)
+		})
+
+		Convey("It should let read actions run right away", func() {
+			for _, tool := range []string{"browse_web", "browser_fill", "browser_scroll", "browser_extract_links", "execute_command"} {
+				So(policy.Reason(tool, `{}`), ShouldBeEmpty)
+			}
+		})
+	})
+
+	Convey("Given an approval policy without write gating", t, func() {
+		policy := &ApprovalPolicy{}
+
+		Convey("It should let clicks run right away", func() {
+			So(policy.Reason("browser_click", `{"selector":"button"}`), ShouldBeEmpty)
+		})
+	})
+
+	Convey("Given an approval policy with a command pattern and tool patterns", t, func() {
+		policy := &ApprovalPolicy{
+			CommandPattern: regexp.MustCompile(`\bgit push\b`),
+			Tools:          []string{"blackboard_*"},
+			Timeout:        time.Minute,
+		}
+
+		Convey("It should gate matching commands only", func() {
+			So(policy.Reason("execute_command", `{"command":"git push origin main"}`), ShouldContainSubstring, "approval pattern")
+			So(policy.Reason("execute_command", `{"command":"git status"}`), ShouldBeEmpty)
+		})
+
+		Convey("It should gate the configured tools", func() {
+			So(policy.Reason("blackboard_set", `{}`), ShouldEqual, "the tool blackboard_set always requires approval")
+		})
+	})
+}
+
+func TestDecideApproval(t *testing.T) {
+	Convey("Given an agent awaiting approval", t, func() {
+		m := &AgentManager{approvals: newApprovalQueue(), approvalPolicy: &ApprovalPolicy{}}
+		agent := &Agent{ID: "agent", shutdownChan: make(chan struct{})}
+
+		type result struct {
+			decision ApprovalDecision
+			note     string
+		}
+		done := make(chan result, 1)
+		go func() {
+			decision, note := m.awaitApproval(agent, "browser_submit", `{"selector":"form"}`, "browser_submit is a write action")
+			done <- result{decision, note}
+		}()
+
+		var pending []*PendingApproval
+		for deadline := time.Now().Add(5 * time.Second); len(pending) == 0 && time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+			pending = m.PendingApprovals("agent")
+		}
+		So(pending, ShouldHaveLength, 1)
+		So(agent.status(), ShouldEqual, StatusAwaitingApproval)
+
+		Convey("It should resume the agent with the decision", func() {
+			_, err := m.DecideApproval(pending[0].ID, ApprovalRejected, "not today")
+			So(err, ShouldBeNil)
+
+			res := <-done
+			So(res.decision, ShouldEqual, ApprovalRejected)
+			So(res.note, ShouldEqual, "not today")
+			So(agent.status(), ShouldEqual, StatusRunning)
+			So(m.PendingApprovals(""), ShouldBeEmpty)
+
+			_, err = m.DecideApproval(pending[0].ID, ApprovalApproved, "")
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("It should reject the action when the agent is shut down", func() {
+			close(agent.shutdownChan)
+
+			So((<-done).decision, ShouldEqual, ApprovalRejected)
+		})
+	})
+}
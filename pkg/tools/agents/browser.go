@@ -0,0 +1,482 @@
+package agents
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/cdp"
+	"github.com/go-rod/rod/lib/launcher"
+	"github.com/go-rod/rod/lib/proto"
+	"github.com/theapemachine/mcp-server-devops-bridge/core/container"
+	"github.com/theapemachine/mcp-server-devops-bridge/pkg/config"
+)
+
+// BrowserMode selects where the Chromium process serving an agent runs.
+type BrowserMode string
+
+const (
+	// BrowserModeShared runs one Chromium process on the host, giving every agent its own incognito context.
+	BrowserModeShared BrowserMode = "shared"
+	// BrowserModeContainer runs a Chromium process inside each agent's own container.
+	BrowserModeContainer BrowserMode = "container"
+)
+
+// containerDebuggingPort is the remote debugging port Chromium listens on inside agent containers.
+const containerDebuggingPort = 9222
+
+// BrowserManager is a pool of headless browser contexts for agents. In shared mode a single
+// Chromium process is launched lazily and each agent gets an isolated incognito context in it,
+// so cookies and storage never leak between agents. Each agent works on one persistent page,
+// and the number of page operations running at once across all agents is bounded.
+//
+// Every page load, including those caused by clicks, form submits and redirects, is checked
+// against the global URL policy and the agent's own policy, if it has one.
+type BrowserManager struct {
+	mode     BrowserMode
+	launcher *launcher.Launcher
+	browser  *rod.Browser            // The shared Chromium process
+	contexts map[string]*rod.Browser // Per-agent incognito contexts or in-container browsers
+	pages    map[string]*rod.Page    // Per-agent persistent pages
+	guards   map[string]func()       // Stop the request interception of the persistent pages
+	slots    chan struct{}           // Semaphore bounding concurrent page operations
+	policy   *URLPolicy              // Applies to every agent
+	policies map[string]*URLPolicy
+	denials  map[string]error // The last page load the URL policy blocked, per agent
+	mu       sync.Mutex
+}
+
+var (
+	browserManager *BrowserManager
+	browserOnce    sync.Once
+)
+
+// NewBrowserManager creates and returns the BrowserManager. Chromium is only started once an agent needs it.
+func NewBrowserManager() *BrowserManager {
+	browserOnce.Do(func() {
+		cfg := config.Load()
+
+		mode := BrowserMode(cfg.Agents.BrowserMode)
+		if mode != BrowserModeContainer {
+			mode = BrowserModeShared
+		}
+
+		maxPages := cfg.Agents.BrowserMaxPages
+		if maxPages <= 0 {
+			maxPages = 4
+		}
+
+		browserManager = &BrowserManager{
+			mode:     mode,
+			contexts: make(map[string]*rod.Browser),
+			pages:    make(map[string]*rod.Page),
+			guards:   make(map[string]func()),
+			slots:    make(chan struct{}, maxPages),
+			policy:   GlobalURLPolicy(),
+			policies: make(map[string]*URLPolicy),
+			denials:  make(map[string]error),
+		}
+	})
+	return browserManager
+}
+
+// GetBrowserForAgent returns the browser context for a given agent, creating it on first use.
+// The sandbox is only used in container mode, where Chromium is started inside it.
+func (m *BrowserManager) GetBrowserForAgent(ctx context.Context, agentID string, sandbox container.Sandbox) (*rod.Browser, error) {
+	m.mu.Lock()
+	if browser, exists := m.contexts[agentID]; exists {
+		m.mu.Unlock()
+		return browser, nil
+	}
+
+	if m.mode == BrowserModeShared {
+		m.mu.Unlock()
+		return m.sharedContext(agentID)
+	}
+	m.mu.Unlock()
+
+	// Starting Chromium in a container can take a while, so it is done without holding the lock.
+	browser, err := m.connectToSandbox(ctx, sandbox)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if existing, exists := m.contexts[agentID]; exists {
+		_ = browser.Close()
+		return existing, nil
+	}
+	m.contexts[agentID] = browser
+	return browser, nil
+}
+
+// WithPage runs fn against the agent's persistent page, creating it on first use, so that
+// navigation, form state and scroll position carry over between browser actions. It waits
+// for a free page slot first, bounding how many page operations run at once across agents.
+func (m *BrowserManager) WithPage(ctx context.Context, agentID string, sandbox container.Sandbox, fn func(page *rod.Page) error) error {
+	browser, err := m.GetBrowserForAgent(ctx, agentID, sandbox)
+	if err != nil {
+		return fmt.Errorf("could not get browser: %w", err)
+	}
+
+	select {
+	case m.slots <- struct{}{}:
+		defer func() { <-m.slots }()
+	case <-ctx.Done():
+		return fmt.Errorf("timed out waiting for a free browser page: %w", ctx.Err())
+	}
+
+	m.mu.Lock()
+	page, exists := m.pages[agentID]
+	delete(m.denials, agentID)
+	m.mu.Unlock()
+
+	// Opening a page is a round trip to Chromium, so it is done without holding the lock.
+	if !exists {
+		created, err := browser.Page(proto.TargetCreateTarget{})
+		if err != nil {
+			return fmt.Errorf("could not open page: %w", err)
+		}
+		stop := m.enforcePolicy(agentID, created, func(err error) {
+			m.mu.Lock()
+			m.denials[agentID] = err
+			m.mu.Unlock()
+		})
+
+		m.mu.Lock()
+		if page, exists = m.pages[agentID]; !exists {
+			page = created
+			m.pages[agentID] = created
+			m.guards[agentID] = stop
+		}
+		m.mu.Unlock()
+
+		if page != created {
+			// Another action of the agent opened its page meanwhile.
+			stop()
+			_ = created.Close()
+		}
+	}
+
+	err = fn(page.Context(ctx))
+
+	// A blocked page load surfaces as a generic navigation error, so report the policy denial instead.
+	m.mu.Lock()
+	denial := m.denials[agentID]
+	delete(m.denials, agentID)
+	m.mu.Unlock()
+	if denial != nil {
+		return denial
+	}
+	return err
+}
+
+// WithScratchPage runs fn on a new page in the agent's browser that is closed afterwards. It lets
+// an agent load several pages at once without disturbing its persistent page.
+func (m *BrowserManager) WithScratchPage(ctx context.Context, agentID string, sandbox container.Sandbox, fn func(page *rod.Page) error) error {
+	browser, err := m.GetBrowserForAgent(ctx, agentID, sandbox)
+	if err != nil {
+		return fmt.Errorf("could not get browser: %w", err)
+	}
+
+	select {
+	case m.slots <- struct{}{}:
+		defer func() { <-m.slots }()
+	case <-ctx.Done():
+		return fmt.Errorf("timed out waiting for a free browser page: %w", ctx.Err())
+	}
+
+	page, err := browser.Page(proto.TargetCreateTarget{})
+	if err != nil {
+		return fmt.Errorf("could not open page: %w", err)
+	}
+	defer page.Close()
+
+	denials := make(chan error, 1)
+	stop := m.enforcePolicy(agentID, page, func(err error) {
+		select {
+		case denials <- err:
+		default:
+		}
+	})
+	defer stop()
+
+	err = fn(page.Context(ctx))
+
+	select {
+	case denial := <-denials:
+		return denial
+	default:
+		return err
+	}
+}
+
+// SetPolicy sets a URL policy for the agent, applied on top of the global policy.
+func (m *BrowserManager) SetPolicy(agentID string, policy *URLPolicy) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.policies[agentID] = policy
+}
+
+// Policy returns the agent's own URL policy, or nil if only the global policy applies.
+func (m *BrowserManager) Policy(agentID string) *URLPolicy {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.policies[agentID]
+}
+
+// policiesFor returns the global URL policy and the agent's own, which is nil if it has none.
+func (m *BrowserManager) policiesFor(agentID string) []*URLPolicy {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return []*URLPolicy{m.policy, m.policies[agentID]}
+}
+
+// CheckURL returns a *PolicyError if the agent may not access the URL under the global policy or its own.
+func (m *BrowserManager) CheckURL(ctx context.Context, agentID, rawURL string) error {
+	return m.checkURL(ctx, agentID, rawURL, false)
+}
+
+// checkURL is CheckURL, ignoring robots.txt for the subresources of a page.
+func (m *BrowserManager) checkURL(ctx context.Context, agentID, rawURL string, subresource bool) error {
+	for _, policy := range m.policiesFor(agentID) {
+		if policy == nil {
+			continue
+		}
+		check := policy.Check
+		if subresource {
+			check = policy.CheckSubresource
+		}
+		if err := check(ctx, rawURL); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// enforcePolicy intercepts every request of the page, including subresources, fetches and
+// websockets, and blocks those the URL policy denies. Only a blocked load of the page itself is
+// passed to deny for the caller to report; a page that loads a blocked script or image still
+// works without it. The returned function stops the interception.
+func (m *BrowserManager) enforcePolicy(agentID string, page *rod.Page, deny func(error)) func() {
+	ctx, cancel := context.WithCancel(context.Background())
+	guarded := page.Context(ctx)
+	_ = proto.FetchEnable{Patterns: []*proto.FetchRequestPattern{{URLPattern: "*"}}}.Call(guarded)
+
+	wait := guarded.EachEvent(func(e *proto.FetchRequestPaused) bool {
+		go m.screenRequest(agentID, guarded, e, deny)
+		return false
+	})
+	go wait()
+	return cancel
+}
+
+// screenRequest lets a paused request continue if the URL policy allows it and fails it otherwise.
+func (m *BrowserManager) screenRequest(agentID string, page *rod.Page, e *proto.FetchRequestPaused, deny func(error)) {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	subresource := e.ResourceType != proto.NetworkResourceTypeDocument
+	if err := m.checkURL(ctx, agentID, e.Request.URL, subresource); err != nil {
+		if isPageLoad(page, e) {
+			deny(err)
+		}
+		_ = proto.FetchFailRequest{RequestID: e.RequestID, ErrorReason: proto.NetworkErrorReasonBlockedByClient}.Call(page)
+		return
+	}
+	_ = proto.FetchContinueRequest{RequestID: e.RequestID}.Call(page)
+}
+
+// isPageLoad reports whether a request loads the document of the page's main frame, as opposed
+// to a subresource or a frame embedded in it.
+func isPageLoad(page *rod.Page, e *proto.FetchRequestPaused) bool {
+	return e.ResourceType == proto.NetworkResourceTypeDocument && e.FrameID == page.FrameID
+}
+
+// CleanupForAgent closes the agent's browser context and releases its resources.
+func (m *BrowserManager) CleanupForAgent(agentID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if stop, exists := m.guards[agentID]; exists {
+		stop()
+		delete(m.guards, agentID)
+	}
+	if page, exists := m.pages[agentID]; exists {
+		_ = page.Close()
+		delete(m.pages, agentID)
+	}
+	delete(m.policies, agentID)
+	delete(m.denials, agentID)
+	if browser, exists := m.contexts[agentID]; exists {
+		_ = browser.Close() // Best-effort close, disposes the incognito context or the remote browser
+		delete(m.contexts, agentID)
+	}
+}
+
+// Close shuts down all browser contexts and the shared Chromium process.
+func (m *BrowserManager) Close() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for agentID, stop := range m.guards {
+		stop()
+		delete(m.guards, agentID)
+	}
+	for agentID, page := range m.pages {
+		_ = page.Close()
+		delete(m.pages, agentID)
+	}
+	for agentID, browser := range m.contexts {
+		_ = browser.Close()
+		delete(m.contexts, agentID)
+	}
+	if m.browser != nil {
+		_ = m.browser.Close()
+		m.browser = nil
+	}
+	if m.launcher != nil {
+		m.launcher.Cleanup()
+		m.launcher = nil
+	}
+}
+
+// sharedContext creates the agent's incognito context in the shared browser, launching
+// Chromium first if it is not running yet. Launching takes a while, so it is done without
+// holding the lock; if another agent launched Chromium meanwhile, this launch is discarded.
+func (m *BrowserManager) sharedContext(agentID string) (*rod.Browser, error) {
+	m.mu.Lock()
+	running := m.browser != nil
+	m.mu.Unlock()
+
+	if !running {
+		l, browser, err := launchBrowser()
+		if err != nil {
+			return nil, err
+		}
+
+		m.mu.Lock()
+		if m.browser == nil {
+			m.launcher = l
+			m.browser = browser
+		} else {
+			_ = browser.Close()
+			l.Kill()
+		}
+		m.mu.Unlock()
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if existing, exists := m.contexts[agentID]; exists {
+		return existing, nil
+	}
+	if m.browser == nil {
+		return nil, errors.New("the browser was closed")
+	}
+
+	incognito, err := m.browser.Incognito()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create incognito context: %w", err)
+	}
+	m.contexts[agentID] = incognito
+	return incognito, nil
+}
+
+// launchBrowser launches a headless Chromium process on the host and connects to it.
+func launchBrowser() (*launcher.Launcher, *rod.Browser, error) {
+	l := launcher.New().Headless(true)
+	if path, found := launcher.LookPath(); found {
+		l = l.Bin(path)
+	}
+
+	controlURL, err := l.Launch()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to launch browser: %w", err)
+	}
+
+	browser := rod.New().ControlURL(controlURL)
+	if err := browser.Connect(); err != nil {
+		l.Kill()
+		return nil, nil, fmt.Errorf("failed to connect to browser: %w", err)
+	}
+	return l, browser, nil
+}
+
+// connectToSandbox starts Chromium inside the agent's container and connects to it through
+// the container runtime. Chromium only listens on the container's loopback interface, so its
+// unauthenticated debugging port is never reachable from the container network. Chromium is
+// installed first if the image does not include it.
+func (m *BrowserManager) connectToSandbox(ctx context.Context, sandbox container.Sandbox) (*rod.Browser, error) {
+	dialer, ok := sandbox.(container.LoopbackDialer)
+	if sandbox == nil || !ok {
+		return nil, errors.New("browser mode 'container' requires a docker or podman sandbox")
+	}
+
+	start := fmt.Sprintf(
+		`command -v chromium >/dev/null 2>&1 || (apt-get update -qq && apt-get install -y -qq chromium) >/dev/null 2>&1; `+
+			`nohup chromium --headless --no-sandbox --disable-gpu --remote-debugging-address=127.0.0.1 --remote-debugging-port=%d about:blank >/tmp/chromium.log 2>&1 &`,
+		containerDebuggingPort,
+	)
+	if _, err := sandbox.Execute(ctx, []string{"sh", "-c", start}); err != nil {
+		return nil, fmt.Errorf("failed to start browser in container: %w", err)
+	}
+
+	// Chromium takes a moment to open its debugging port, and logs its address once it has.
+	var wsURL string
+	deadline := time.Now().Add(30 * time.Second)
+	for {
+		output, err := sandbox.Execute(ctx, []string{"cat", "/tmp/chromium.log"})
+		if err == nil {
+			if wsURL = devToolsURL(output); wsURL != "" {
+				break
+			}
+		}
+		if time.Now().After(deadline) {
+			return nil, errors.New("browser in container did not become reachable")
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(500 * time.Millisecond):
+		}
+	}
+
+	ws := &cdp.WebSocket{Dialer: loopbackDialer{dialer}}
+	if err := ws.Connect(ctx, wsURL, nil); err != nil {
+		return nil, fmt.Errorf("failed to connect to browser in container: %w", err)
+	}
+
+	browser := rod.New().Client(cdp.New().Start(ws))
+	if err := browser.Connect(); err != nil {
+		return nil, fmt.Errorf("failed to connect to browser in container: %w", err)
+	}
+	return browser, nil
+}
+
+// devToolsListening matches the line Chromium logs once its debugging port is open.
+var devToolsListening = regexp.MustCompile(`DevTools listening on (ws://\S+)`)
+
+// devToolsURL returns the websocket URL from Chromium's log, or an empty string if it is not listening yet.
+func devToolsURL(log string) string {
+	match := devToolsListening.FindStringSubmatch(log)
+	if match == nil {
+		return ""
+	}
+	return match[1]
+}
+
+// loopbackDialer opens the DevTools websocket through the sandbox runtime rather than the network.
+type loopbackDialer struct {
+	sandbox container.LoopbackDialer
+}
+
+func (d loopbackDialer) DialContext(ctx context.Context, _, _ string) (net.Conn, error) {
+	return d.sandbox.DialLoopback(ctx, containerDebuggingPort)
+}
@@ -0,0 +1,462 @@
+package agents
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+	"github.com/openai/openai-go"
+)
+
+// browserActionTimeout bounds a single browser action, including waiting for a free page slot.
+const browserActionTimeout = 60 * time.Second
+
+// browserActionNames lists the agent tools that act on the agent's persistent browser page.
+var browserActionNames = map[string]bool{
+	"browser_click":          true,
+	"browser_fill":           true,
+	"browser_submit":         true,
+	"browser_scroll":         true,
+	"browser_wait_for":       true,
+	"browser_extract_links":  true,
+	"browser_extract_tables": true,
+	"browser_screenshot":     true,
+}
+
+// isBrowserAction reports whether the tool name is one of the browser page actions.
+func isBrowserAction(name string) bool {
+	return browserActionNames[name]
+}
+
+// browserActionTools returns the tool definitions for the browser page actions.
+func browserActionTools() []openai.ChatCompletionToolParam {
+	selector := map[string]string{
+		"type":        "string",
+		"description": "A CSS selector for the target element.",
+	}
+
+	return []openai.ChatCompletionToolParam{
+		{
+			Function: openai.FunctionDefinitionParam{
+				Name:        "browser_click",
+				Description: openai.String("Click an element on the current browser page."),
+				Parameters: openai.FunctionParameters{
+					"type":       "object",
+					"properties": map[string]interface{}{"selector": selector},
+					"required":   []string{"selector"},
+				},
+			},
+		},
+		{
+			Function: openai.FunctionDefinitionParam{
+				Name:        "browser_fill",
+				Description: openai.String("Fill a form field on the current browser page, replacing its value. For select elements, the option with the given text is chosen."),
+				Parameters: openai.FunctionParameters{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"selector": selector,
+						"value": map[string]string{
+							"type":        "string",
+							"description": "The value to enter.",
+						},
+					},
+					"required": []string{"selector", "value"},
+				},
+			},
+		},
+		{
+			Function: openai.FunctionDefinitionParam{
+				Name:        "browser_submit",
+				Description: openai.String("Submit the form containing the given element, or the form itself, and wait for the resulting page to load."),
+				Parameters: openai.FunctionParameters{
+					"type":       "object",
+					"properties": map[string]interface{}{"selector": selector},
+					"required":   []string{"selector"},
+				},
+			},
+		},
+		{
+			Function: openai.FunctionDefinitionParam{
+				Name:        "browser_scroll",
+				Description: openai.String("Scroll the current browser page up or down."),
+				Parameters: openai.FunctionParameters{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"direction": map[string]interface{}{
+							"type":        "string",
+							"enum":        []string{"up", "down"},
+							"description": "The direction to scroll in.",
+						},
+						"pixels": map[string]string{
+							"type":        "integer",
+							"description": "How far to scroll. Defaults to one viewport height.",
+						},
+					},
+					"required": []string{"direction"},
+				},
+			},
+		},
+		{
+			Function: openai.FunctionDefinitionParam{
+				Name:        "browser_wait_for",
+				Description: openai.String("Wait until an element matching the selector is visible on the current browser page."),
+				Parameters: openai.FunctionParameters{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"selector": selector,
+						"timeout_seconds": map[string]string{
+							"type":        "integer",
+							"description": "How long to wait before giving up. Defaults to 10.",
+						},
+					},
+					"required": []string{"selector"},
+				},
+			},
+		},
+		{
+			Function: openai.FunctionDefinitionParam{
+				Name:        "browser_extract_links",
+				Description: openai.String("Extract all links on the current browser page as JSON."),
+			},
+		},
+		{
+			Function: openai.FunctionDefinitionParam{
+				Name:        "browser_extract_tables",
+				Description: openai.String("Extract all tables on the current browser page as JSON, with their headers and rows."),
+			},
+		},
+		{
+			Function: openai.FunctionDefinitionParam{
+				Name:        "browser_screenshot",
+				Description: openai.String("Take a screenshot of the current browser page. It is saved as an artifact of this agent."),
+				Parameters: openai.FunctionParameters{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"full_page": map[string]string{
+							"type":        "boolean",
+							"description": "Capture the whole page instead of just the visible viewport.",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// runBrowserAction executes a browser page action for the agent and returns a short result.
+func (m *AgentManager) runBrowserAction(agent *Agent, name, arguments string) (string, error) {
+	var args struct {
+		Selector       string `json:"selector"`
+		Value          string `json:"value"`
+		Direction      string `json:"direction"`
+		Pixels         int    `json:"pixels"`
+		TimeoutSeconds int    `json:"timeout_seconds"`
+		FullPage       bool   `json:"full_page"`
+	}
+	if arguments != "" {
+		if err := json.Unmarshal([]byte(arguments), &args); err != nil {
+			return "", fmt.Errorf("failed to unmarshal arguments for %s: %w", name, err)
+		}
+	}
+
+	switch name {
+	case "browser_click", "browser_fill", "browser_submit", "browser_wait_for":
+		if args.Selector == "" {
+			return "", fmt.Errorf("%s requires a selector", name)
+		}
+	}
+
+	var result string
+	err := m.withAgentPage(agent.ID, func(page *rod.Page) error {
+		var err error
+		switch name {
+		case "browser_click":
+			err = clickElement(page, args.Selector)
+		case "browser_fill":
+			err = fillElement(page, args.Selector, args.Value)
+		case "browser_submit":
+			err = submitForm(page, args.Selector)
+		case "browser_scroll":
+			err = scrollPage(page, args.Direction, args.Pixels)
+		case "browser_wait_for":
+			err = waitForElement(page, args.Selector, args.TimeoutSeconds)
+		case "browser_extract_links":
+			result, err = extractJSON(page, extractLinksJS)
+			return err
+		case "browser_extract_tables":
+			result, err = extractJSON(page, extractTablesJS)
+			return err
+		case "browser_screenshot":
+			var path string
+			path, err = m.saveScreenshot(agent, page, args.FullPage)
+			if err == nil {
+				result = fmt.Sprintf("Screenshot saved as artifact %s.", path)
+			}
+			return err
+		}
+		if err != nil {
+			return err
+		}
+		result = describePage(page)
+		return nil
+	})
+	return result, err
+}
+
+// withAgentPage runs fn against the agent's persistent browser page.
+func (m *AgentManager) withAgentPage(agentID string, fn func(page *rod.Page) error) error {
+	m.mu.RLock()
+	agent, exists := m.agents[agentID]
+	m.mu.RUnlock()
+	if !exists {
+		return fmt.Errorf("agent not found")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), browserActionTimeout)
+	defer cancel()
+
+	return m.browserManager.WithPage(ctx, agentID, agent.container, fn)
+}
+
+// withScratchPage runs fn against a new browser page of the agent that is closed afterwards.
+func (m *AgentManager) withScratchPage(agentID string, fn func(page *rod.Page) error) error {
+	m.mu.RLock()
+	agent, exists := m.agents[agentID]
+	m.mu.RUnlock()
+	if !exists {
+		return fmt.Errorf("agent not found")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), browserActionTimeout)
+	defer cancel()
+
+	return m.browserManager.WithScratchPage(ctx, agentID, agent.container, fn)
+}
+
+// describePage summarizes where the page is after an action, so the agent can tell whether it navigated.
+func describePage(page *rod.Page) string {
+	info, err := page.Info()
+	if err != nil {
+		return "Done."
+	}
+	return fmt.Sprintf("Done. Current page: %q (%s)", info.Title, info.URL)
+}
+
+// clickElement clicks the element and gives any resulting navigation or re-render a moment to settle.
+func clickElement(page *rod.Page, selector string) error {
+	el, err := page.Element(selector)
+	if err != nil {
+		return fmt.Errorf("element %s not found: %w", selector, err)
+	}
+	if err := el.Click(proto.InputMouseButtonLeft, 1); err != nil {
+		return fmt.Errorf("failed to click %s: %w", selector, err)
+	}
+	_ = page.WaitStable(500 * time.Millisecond)
+	return nil
+}
+
+// fillElement replaces the value of an input or textarea, or picks an option of a select by its text.
+func fillElement(page *rod.Page, selector, value string) error {
+	el, err := page.Element(selector)
+	if err != nil {
+		return fmt.Errorf("element %s not found: %w", selector, err)
+	}
+
+	tag, err := el.Eval(`() => this.tagName`)
+	if err != nil {
+		return fmt.Errorf("failed to inspect %s: %w", selector, err)
+	}
+
+	if tag.Value.Str() == "SELECT" {
+		if err := el.Select([]string{value}, true, rod.SelectorTypeText); err != nil {
+			return fmt.Errorf("failed to select %q in %s: %w", value, selector, err)
+		}
+		return nil
+	}
+
+	if err := el.SelectAllText(); err != nil {
+		return fmt.Errorf("failed to clear %s: %w", selector, err)
+	}
+	if err := el.Input(value); err != nil {
+		return fmt.Errorf("failed to fill %s: %w", selector, err)
+	}
+	return nil
+}
+
+// submitForm submits the form the element belongs to, running its submit handlers like a real submit would.
+func submitForm(page *rod.Page, selector string) error {
+	el, err := page.Element(selector)
+	if err != nil {
+		return fmt.Errorf("element %s not found: %w", selector, err)
+	}
+
+	wait := page.WaitNavigation(proto.PageLifecycleEventNameLoad)
+	submitted, err := el.Eval(`() => {
+		const form = this.tagName === 'FORM' ? this : this.form;
+		if (!form) return false;
+		form.requestSubmit();
+		return true;
+	}`)
+	if err != nil {
+		return fmt.Errorf("failed to submit %s: %w", selector, err)
+	}
+	if !submitted.Value.Bool() {
+		return fmt.Errorf("element %s is not a form or inside one", selector)
+	}
+
+	// Forms handled by scripts may not navigate at all, so only wait briefly for a page load.
+	done := make(chan struct{})
+	go func() {
+		wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+	}
+	_ = page.WaitStable(500 * time.Millisecond)
+	return nil
+}
+
+// scrollPage scrolls the window by the given number of pixels, or by one viewport height.
+func scrollPage(page *rod.Page, direction string, pixels int) error {
+	sign := 1
+	switch direction {
+	case "down":
+	case "up":
+		sign = -1
+	default:
+		return fmt.Errorf("invalid direction '%s'. Must be 'up' or 'down'", direction)
+	}
+
+	_, err := page.Eval(`(sign, pixels) => {
+		window.scrollBy(0, sign * (pixels > 0 ? pixels : window.innerHeight));
+	}`, sign, pixels)
+	if err != nil {
+		return fmt.Errorf("failed to scroll: %w", err)
+	}
+	return nil
+}
+
+// waitForElement waits until an element matching the selector is visible.
+func waitForElement(page *rod.Page, selector string, timeoutSeconds int) error {
+	if timeoutSeconds <= 0 {
+		timeoutSeconds = 10
+	}
+
+	el, err := page.Timeout(time.Duration(timeoutSeconds) * time.Second).Element(selector)
+	if err == nil {
+		err = el.WaitVisible()
+	}
+	if err != nil {
+		return fmt.Errorf("element %s did not appear within %d seconds: %w", selector, timeoutSeconds, err)
+	}
+	return nil
+}
+
+const extractLinksJS = `() => Array.from(document.querySelectorAll('a[href]')).map(a => ({
+	text: a.innerText.trim(),
+	href: a.href,
+}))`
+
+const extractTablesJS = `() => Array.from(document.querySelectorAll('table')).map(table => {
+	const rows = Array.from(table.rows).map(row =>
+		Array.from(row.cells).map(cell => cell.innerText.trim())
+	);
+	let headers = [];
+	if (table.tHead && table.tHead.rows.length > 0) {
+		headers = Array.from(table.tHead.rows[0].cells).map(cell => cell.innerText.trim());
+		rows.splice(0, table.tHead.rows.length);
+	} else if (table.rows.length > 0 && Array.from(table.rows[0].cells).every(cell => cell.tagName === 'TH')) {
+		headers = rows.shift();
+	}
+	return { caption: table.caption ? table.caption.innerText.trim() : '', headers, rows };
+})`
+
+// maxExtractChars bounds the JSON returned by the extract actions.
+const maxExtractChars = 8000
+
+// extractJSON evaluates a JS function returning an array and renders it as indented JSON.
+func extractJSON(page *rod.Page, js string) (string, error) {
+	result, err := page.Eval(js)
+	if err != nil {
+		return "", fmt.Errorf("failed to extract content: %w", err)
+	}
+
+	var items []json.RawMessage
+	if err := json.Unmarshal([]byte(result.Value.JSON("", "")), &items); err != nil {
+		return "", fmt.Errorf("failed to decode extracted content: %w", err)
+	}
+	return renderExtracted(items, maxExtractChars)
+}
+
+// renderExtracted renders the items as an indented JSON array. If that exceeds the limit, only
+// the leading items that fit are kept, so the result stays valid JSON, and it is wrapped in an
+// object saying how many were left out.
+func renderExtracted(items []json.RawMessage, limit int) (string, error) {
+	content, err := json.MarshalIndent(items, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	if len(content) <= limit {
+		return string(content), nil
+	}
+
+	type truncated struct {
+		Items []json.RawMessage `json:"items"`
+		Note  string            `json:"note"`
+	}
+	render := func(kept int) ([]byte, error) {
+		return json.MarshalIndent(truncated{
+			Items: items[:kept],
+			Note:  fmt.Sprintf("showing the first %d of %d items, the rest did not fit", kept, len(items)),
+		}, "", "  ")
+	}
+
+	// Find the most items that fit, the rendered size grows with every item kept.
+	kept := sort.Search(len(items)+1, func(n int) bool {
+		content, err := render(n)
+		return err != nil || len(content) > limit
+	}) - 1
+	if kept < 0 {
+		kept = 0
+	}
+
+	content, err = render(kept)
+	if err != nil {
+		return "", err
+	}
+	return string(content), nil
+}
+
+// saveScreenshot captures the page as PNG into the agent's artifact directory and records it on the agent.
+func (m *AgentManager) saveScreenshot(agent *Agent, page *rod.Page, fullPage bool) (string, error) {
+	data, err := page.Screenshot(fullPage, &proto.PageCaptureScreenshot{
+		Format: proto.PageCaptureScreenshotFormatPng,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to take screenshot: %w", err)
+	}
+
+	dir := filepath.Join(m.artifactDir, agent.ID)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create artifact directory: %w", err)
+	}
+
+	path := filepath.Join(dir, fmt.Sprintf("screenshot-%s.png", time.Now().Format("20060102-150405.000")))
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return "", fmt.Errorf("failed to save screenshot: %w", err)
+	}
+
+	agent.mu.Lock()
+	agent.Artifacts = append(agent.Artifacts, path)
+	agent.mu.Unlock()
+
+	return path, nil
+}
@@ -0,0 +1,58 @@
+package agents
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestRenderExtracted(t *testing.T) {
+	Convey("Given extracted links", t, func() {
+		links := func(count int) []json.RawMessage {
+			items := make([]json.RawMessage, count)
+			for i := range items {
+				items[i] = json.RawMessage(fmt.Sprintf(`{"text":"Überschrift %d","href":"https://example.com/%d"}`, i, i))
+			}
+			return items
+		}
+
+		Convey("It should render them all when they fit", func() {
+			content, err := renderExtracted(links(3), maxExtractChars)
+
+			So(err, ShouldBeNil)
+			var items []map[string]string
+			So(json.Unmarshal([]byte(content), &items), ShouldBeNil)
+			So(items, ShouldHaveLength, 3)
+		})
+
+		Convey("It should keep the leading items that fit as valid JSON when they do not", func() {
+			content, err := renderExtracted(links(500), maxExtractChars)
+
+			So(err, ShouldBeNil)
+			So(len(content), ShouldBeLessThanOrEqualTo, maxExtractChars)
+
+			var result struct {
+				Items []map[string]string `json:"items"`
+				Note  string              `json:"note"`
+			}
+			So(json.Unmarshal([]byte(content), &result), ShouldBeNil)
+			So(len(result.Items), ShouldBeGreaterThan, 0)
+			So(len(result.Items), ShouldBeLessThan, 500)
+			So(result.Items[0]["text"], ShouldEqual, "Überschrift 0")
+			So(result.Note, ShouldEqual, fmt.Sprintf("showing the first %d of 500 items, the rest did not fit", len(result.Items)))
+		})
+
+		Convey("It should keep no items when even the first does not fit", func() {
+			huge := []json.RawMessage{json.RawMessage(`"` + strings.Repeat("x", maxExtractChars) + `"`)}
+
+			content, err := renderExtracted(huge, maxExtractChars)
+
+			So(err, ShouldBeNil)
+			So(json.Valid([]byte(content)), ShouldBeTrue)
+			So(content, ShouldContainSubstring, "showing the first 0 of 1 items")
+		})
+	})
+}
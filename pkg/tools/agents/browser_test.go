@@ -0,0 +1,57 @@
+package agents
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/theapemachine/mcp-server-devops-bridge/core/container"
+)
+
+func TestDevToolsURL(t *testing.T) {
+	Convey("Given the log of a Chromium process", t, func() {
+		Convey("It should return the websocket URL once Chromium is listening", func() {
+			log := "[0101/000000.000000:WARNING:dns_config_service_linux.cc(429)] Failed to read DnsConfig.\n" +
+				"\nDevTools listening on ws://127.0.0.1:9222/devtools/browser/3f1c2a7e-8d4b-4a51-9d0e-6c1b2f3a4d5e\n"
+
+			So(devToolsURL(log), ShouldEqual, "ws://127.0.0.1:9222/devtools/browser/3f1c2a7e-8d4b-4a51-9d0e-6c1b2f3a4d5e")
+		})
+
+		Convey("It should return nothing while Chromium is still starting", func() {
+			So(devToolsURL(""), ShouldBeEmpty)
+			So(devToolsURL("[0101/000000.000000:WARNING:sandbox_linux.cc(418)] Starting up.\n"), ShouldBeEmpty)
+		})
+	})
+}
+
+func TestConnectToSandbox(t *testing.T) {
+	Convey("Given a browser manager in container mode", t, func() {
+		m := &BrowserManager{mode: BrowserModeContainer, contexts: make(map[string]*rod.Browser)}
+
+		Convey("It should refuse sandboxes it cannot dial into", func() {
+			_, err := m.GetBrowserForAgent(context.Background(), "agent", container.NewLocalSandbox())
+
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "requires a docker or podman sandbox")
+			So(m.contexts, ShouldBeEmpty)
+		})
+	})
+}
+
+func TestIsPageLoad(t *testing.T) {
+	Convey("Given requests paused on a page", t, func() {
+		page := &rod.Page{FrameID: "main"}
+		paused := func(resourceType proto.NetworkResourceType, frameID proto.PageFrameID) *proto.FetchRequestPaused {
+			return &proto.FetchRequestPaused{ResourceType: resourceType, FrameID: frameID}
+		}
+
+		Convey("It should only count the document of the main frame as a page load", func() {
+			So(isPageLoad(page, paused(proto.NetworkResourceTypeDocument, "main")), ShouldBeTrue)
+			So(isPageLoad(page, paused(proto.NetworkResourceTypeDocument, "embedded")), ShouldBeFalse)
+			So(isPageLoad(page, paused(proto.NetworkResourceTypeScript, "main")), ShouldBeFalse)
+			So(isPageLoad(page, paused(proto.NetworkResourceTypeWebSocket, "main")), ShouldBeFalse)
+		})
+	})
+}
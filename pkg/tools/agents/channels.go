@@ -0,0 +1,572 @@
+package agents
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/openai/openai-go"
+)
+
+const (
+	// defaultRequestTimeout and maxRequestTimeout bound how long request_agent waits for a reply.
+	defaultRequestTimeout = 2 * time.Minute
+	maxRequestTimeout     = 10 * time.Minute
+)
+
+// Message types carried by the message bus.
+const (
+	MessageTopic   = "topic"
+	MessageRequest = "request"
+	MessageReply   = "reply"
+)
+
+// AgentMessage is the envelope of a message between agents. It is delivered to the
+// recipient as JSON, so the agent can tell topics, requests and their correlation IDs apart.
+type AgentMessage struct {
+	Type          string    `json:"type"`
+	From          string    `json:"from"`
+	Topic         string    `json:"topic,omitempty"`
+	CorrelationID string    `json:"correlation_id,omitempty"`
+	Body          string    `json:"body"`
+	SentAt        time.Time `json:"sent_at"`
+}
+
+// BlackboardEntry is a value on the shared blackboard. The version increases with every write,
+// which is what compare-and-swap checks against.
+type BlackboardEntry struct {
+	Key       string    `json:"key"`
+	Value     string    `json:"value"`
+	Version   int       `json:"version"`
+	UpdatedBy string    `json:"updated_by"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// pendingRequest is a request_agent call waiting for its reply.
+type pendingRequest struct {
+	from  string
+	to    string
+	reply chan AgentMessage
+}
+
+// messageBus holds topic subscriptions, outstanding requests and the shared blackboard.
+type messageBus struct {
+	subscriptions map[string]map[string]bool // Topic to subscribed agent IDs
+	requests      map[string]*pendingRequest // Correlation ID to the waiting request
+	blackboard    map[string]*BlackboardEntry
+	mu            sync.Mutex
+}
+
+func newMessageBus() *messageBus {
+	return &messageBus{
+		subscriptions: make(map[string]map[string]bool),
+		requests:      make(map[string]*pendingRequest),
+		blackboard:    make(map[string]*BlackboardEntry),
+	}
+}
+
+// removeAgent drops the agent's subscriptions, so topics stop delivering to it.
+func (b *messageBus) removeAgent(agentID string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for topic, subscribers := range b.subscriptions {
+		delete(subscribers, agentID)
+		if len(subscribers) == 0 {
+			delete(b.subscriptions, topic)
+		}
+	}
+}
+
+// channelActionNames lists the agent tools backed by the message bus.
+var channelActionNames = map[string]bool{
+	"subscribe_topic":   true,
+	"unsubscribe_topic": true,
+	"publish_topic":     true,
+	"request_agent":     true,
+	"reply_to_request":  true,
+	"blackboard_get":    true,
+	"blackboard_set":    true,
+	"blackboard_cas":    true,
+	"blackboard_list":   true,
+}
+
+// isChannelAction reports whether the tool name is one of the message bus actions.
+func isChannelAction(name string) bool {
+	return channelActionNames[name]
+}
+
+// channelTools returns the tool definitions for topics, request/reply and the blackboard.
+func channelTools() []openai.ChatCompletionToolParam {
+	topic := map[string]string{
+		"type":        "string",
+		"description": "The topic name, such as 'test-results'.",
+	}
+	key := map[string]string{
+		"type":        "string",
+		"description": "The blackboard key. Use prefixes such as 'task/' to group related keys.",
+	}
+	value := map[string]string{
+		"type":        "string",
+		"description": "The value to store. Use JSON for structured data.",
+	}
+
+	return []openai.ChatCompletionToolParam{
+		{
+			Function: openai.FunctionDefinitionParam{
+				Name:        "subscribe_topic",
+				Description: openai.String("Subscribe to a topic. Messages published to it by other agents are delivered to you."),
+				Parameters: openai.FunctionParameters{
+					"type":       "object",
+					"properties": map[string]interface{}{"topic": topic},
+					"required":   []string{"topic"},
+				},
+			},
+		},
+		{
+			Function: openai.FunctionDefinitionParam{
+				Name:        "unsubscribe_topic",
+				Description: openai.String("Stop receiving messages published to a topic."),
+				Parameters: openai.FunctionParameters{
+					"type":       "object",
+					"properties": map[string]interface{}{"topic": topic},
+					"required":   []string{"topic"},
+				},
+			},
+		},
+		{
+			Function: openai.FunctionDefinitionParam{
+				Name:        "publish_topic",
+				Description: openai.String("Publish a message to every other agent subscribed to a topic."),
+				Parameters: openai.FunctionParameters{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"topic": topic,
+						"message": map[string]string{
+							"type":        "string",
+							"description": "The message to publish.",
+						},
+					},
+					"required": []string{"topic", "message"},
+				},
+			},
+		},
+		{
+			Function: openai.FunctionDefinitionParam{
+				Name:        "request_agent",
+				Description: openai.String("Send a request to another agent and wait for its reply, which is returned as the result of this call. The recipient answers with 'reply_to_request'."),
+				Parameters: openai.FunctionParameters{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"recipient_id": map[string]string{
+							"type":        "string",
+							"description": "The ID of the agent to ask.",
+						},
+						"message": map[string]string{
+							"type":        "string",
+							"description": "The request.",
+						},
+						"timeout_seconds": map[string]string{
+							"type":        "integer",
+							"description": "How long to wait for the reply. Defaults to 120, at most 600.",
+						},
+					},
+					"required": []string{"recipient_id", "message"},
+				},
+			},
+		},
+		{
+			Function: openai.FunctionDefinitionParam{
+				Name:        "reply_to_request",
+				Description: openai.String("Answer a request another agent sent you, identified by its correlation_id."),
+				Parameters: openai.FunctionParameters{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"correlation_id": map[string]string{
+							"type":        "string",
+							"description": "The correlation_id of the request.",
+						},
+						"message": map[string]string{
+							"type":        "string",
+							"description": "The reply.",
+						},
+					},
+					"required": []string{"correlation_id", "message"},
+				},
+			},
+		},
+		{
+			Function: openai.FunctionDefinitionParam{
+				Name:        "blackboard_get",
+				Description: openai.String("Read a value from the blackboard shared by all agents, with its version."),
+				Parameters: openai.FunctionParameters{
+					"type":       "object",
+					"properties": map[string]interface{}{"key": key},
+					"required":   []string{"key"},
+				},
+			},
+		},
+		{
+			Function: openai.FunctionDefinitionParam{
+				Name:        "blackboard_set",
+				Description: openai.String("Write a value to the shared blackboard, overwriting any current value."),
+				Parameters: openai.FunctionParameters{
+					"type":       "object",
+					"properties": map[string]interface{}{"key": key, "value": value},
+					"required":   []string{"key", "value"},
+				},
+			},
+		},
+		{
+			Function: openai.FunctionDefinitionParam{
+				Name:        "blackboard_cas",
+				Description: openai.String("Write a value to the shared blackboard only if the key is still at the expected version, e.g. to claim a task without racing other agents. Version 0 means the key must not exist yet."),
+				Parameters: openai.FunctionParameters{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"key":   key,
+						"value": value,
+						"expected_version": map[string]string{
+							"type":        "integer",
+							"description": "The version read with blackboard_get, or 0 if the key must not exist.",
+						},
+					},
+					"required": []string{"key", "value", "expected_version"},
+				},
+			},
+		},
+		{
+			Function: openai.FunctionDefinitionParam{
+				Name:        "blackboard_list",
+				Description: openai.String("List the keys on the shared blackboard, optionally only those starting with a prefix."),
+				Parameters: openai.FunctionParameters{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"prefix": map[string]string{
+							"type":        "string",
+							"description": "Only list keys starting with this prefix.",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// runChannelAction executes a message bus action for the agent and returns a short result.
+func (m *AgentManager) runChannelAction(agent *Agent, name, arguments string) (string, error) {
+	var args struct {
+		Topic           string `json:"topic"`
+		Message         string `json:"message"`
+		RecipientID     string `json:"recipient_id"`
+		TimeoutSeconds  int    `json:"timeout_seconds"`
+		CorrelationID   string `json:"correlation_id"`
+		Key             string `json:"key"`
+		Value           string `json:"value"`
+		ExpectedVersion int    `json:"expected_version"`
+		Prefix          string `json:"prefix"`
+	}
+	if arguments != "" {
+		if err := json.Unmarshal([]byte(arguments), &args); err != nil {
+			return "", fmt.Errorf("failed to unmarshal arguments for %s: %w", name, err)
+		}
+	}
+
+	switch name {
+	case "subscribe_topic":
+		if err := m.Subscribe(agent.ID, args.Topic); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Subscribed to topic %s.", args.Topic), nil
+	case "unsubscribe_topic":
+		m.Unsubscribe(agent.ID, args.Topic)
+		return fmt.Sprintf("Unsubscribed from topic %s.", args.Topic), nil
+	case "publish_topic":
+		delivered, err := m.Publish(agent.ID, args.Topic, args.Message)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Message published to topic %s and delivered to %d subscriber(s).", args.Topic, delivered), nil
+	case "request_agent":
+		timeout := defaultRequestTimeout
+		if args.TimeoutSeconds > 0 {
+			timeout = min(time.Duration(args.TimeoutSeconds)*time.Second, maxRequestTimeout)
+		}
+		reply, err := m.Request(context.Background(), agent.ID, args.RecipientID, args.Message, timeout)
+		if err != nil {
+			return "", err
+		}
+		return renderMessage(reply), nil
+	case "reply_to_request":
+		if err := m.Reply(agent.ID, args.CorrelationID, args.Message); err != nil {
+			return "", err
+		}
+		return "Reply sent.", nil
+	case "blackboard_get":
+		entry, exists := m.BlackboardGet(args.Key)
+		if !exists {
+			return fmt.Sprintf("Key %s is not set (version 0).", args.Key), nil
+		}
+		return renderJSON(entry)
+	case "blackboard_set":
+		entry, err := m.BlackboardSet(agent.ID, args.Key, args.Value)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Key %s set (version %d).", entry.Key, entry.Version), nil
+	case "blackboard_cas":
+		entry, err := m.BlackboardCompareAndSwap(agent.ID, args.Key, args.ExpectedVersion, args.Value)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Key %s set (version %d).", entry.Key, entry.Version), nil
+	case "blackboard_list":
+		return renderJSON(m.BlackboardKeys(args.Prefix))
+	}
+	return "", fmt.Errorf("unknown tool call: %s", name)
+}
+
+// Subscribe adds the agent to the topic's subscribers.
+func (m *AgentManager) Subscribe(agentID, topic string) error {
+	if strings.TrimSpace(topic) == "" {
+		return fmt.Errorf("topic must not be empty")
+	}
+
+	m.bus.mu.Lock()
+	defer m.bus.mu.Unlock()
+
+	if m.bus.subscriptions[topic] == nil {
+		m.bus.subscriptions[topic] = make(map[string]bool)
+	}
+	m.bus.subscriptions[topic][agentID] = true
+	return nil
+}
+
+// Unsubscribe removes the agent from the topic's subscribers.
+func (m *AgentManager) Unsubscribe(agentID, topic string) {
+	m.bus.mu.Lock()
+	defer m.bus.mu.Unlock()
+
+	delete(m.bus.subscriptions[topic], agentID)
+	if len(m.bus.subscriptions[topic]) == 0 {
+		delete(m.bus.subscriptions, topic)
+	}
+}
+
+// Publish delivers a message to every subscriber of the topic except the sender and returns
+// how many agents received it.
+func (m *AgentManager) Publish(senderID, topic, message string) (int, error) {
+	if strings.TrimSpace(topic) == "" {
+		return 0, fmt.Errorf("topic must not be empty")
+	}
+
+	m.bus.mu.Lock()
+	var subscribers []string
+	for agentID := range m.bus.subscriptions[topic] {
+		if agentID != senderID {
+			subscribers = append(subscribers, agentID)
+		}
+	}
+	m.bus.mu.Unlock()
+
+	msg := AgentMessage{Type: MessageTopic, From: senderID, Topic: topic, Body: message, SentAt: time.Now()}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	delivered := 0
+	for _, agentID := range subscribers {
+		if recipient, exists := m.agents[agentID]; exists {
+			m.deliver(recipient, renderMessage(msg))
+			delivered++
+		}
+	}
+	return delivered, nil
+}
+
+// Request sends a request to another agent and blocks until it replies, the timeout passes, the
+// context is cancelled or the sender is shut down. A request that would complete a cycle of
+// agents waiting on each other is rejected, since none of them could ever reply.
+func (m *AgentManager) Request(ctx context.Context, senderID, recipientID, message string, timeout time.Duration) (AgentMessage, error) {
+	if senderID == recipientID {
+		return AgentMessage{}, fmt.Errorf("an agent cannot send a request to itself")
+	}
+
+	pending := &pendingRequest{from: senderID, to: recipientID, reply: make(chan AgentMessage, 1)}
+	msg := AgentMessage{Type: MessageRequest, From: senderID, CorrelationID: uuid.New().String(), Body: message, SentAt: time.Now()}
+
+	m.mu.RLock()
+	recipient, exists := m.agents[recipientID]
+	if !exists {
+		m.mu.RUnlock()
+		return AgentMessage{}, fmt.Errorf("recipient agent with ID %s not found", recipientID)
+	}
+	var shutdown <-chan struct{}
+	if sender, exists := m.agents[senderID]; exists {
+		shutdown = sender.shutdownChan
+	}
+
+	m.bus.mu.Lock()
+	if m.bus.waitsOn(recipientID, senderID) {
+		m.bus.mu.Unlock()
+		m.mu.RUnlock()
+		return AgentMessage{}, fmt.Errorf("agent %s is waiting for a reply from you, so it cannot answer a request; reply to its request first", recipientID)
+	}
+	m.bus.requests[msg.CorrelationID] = pending
+	m.bus.mu.Unlock()
+
+	m.deliver(recipient, renderMessage(msg))
+	m.mu.RUnlock()
+
+	defer func() {
+		m.bus.mu.Lock()
+		delete(m.bus.requests, msg.CorrelationID)
+		m.bus.mu.Unlock()
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case reply := <-pending.reply:
+		return reply, nil
+	case <-timer.C:
+		return AgentMessage{}, fmt.Errorf("agent %s did not reply to request %s within %s", recipientID, msg.CorrelationID, timeout)
+	case <-ctx.Done():
+		return AgentMessage{}, fmt.Errorf("request %s to agent %s was cancelled: %w", msg.CorrelationID, recipientID, ctx.Err())
+	case <-shutdown:
+		return AgentMessage{}, fmt.Errorf("request %s to agent %s was abandoned because you were shut down", msg.CorrelationID, recipientID)
+	}
+}
+
+// waitsOn reports whether the agent is blocked, directly or through other agents' requests, on a
+// reply from target. The caller must hold b.mu.
+func (b *messageBus) waitsOn(agentID, target string) bool {
+	visited := map[string]bool{agentID: true}
+	queue := []string{agentID}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, pending := range b.requests {
+			if pending.from != current || visited[pending.to] {
+				continue
+			}
+			if pending.to == target {
+				return true
+			}
+			visited[pending.to] = true
+			queue = append(queue, pending.to)
+		}
+	}
+	return false
+}
+
+// Reply answers a pending request addressed to the agent.
+func (m *AgentManager) Reply(senderID, correlationID, message string) error {
+	m.bus.mu.Lock()
+	pending, exists := m.bus.requests[correlationID]
+	if exists && pending.to == senderID {
+		delete(m.bus.requests, correlationID)
+	}
+	m.bus.mu.Unlock()
+
+	if !exists {
+		return fmt.Errorf("no pending request with correlation ID %s; it may have timed out", correlationID)
+	}
+	if pending.to != senderID {
+		return fmt.Errorf("request %s was not addressed to you", correlationID)
+	}
+
+	pending.reply <- AgentMessage{Type: MessageReply, From: senderID, CorrelationID: correlationID, Body: message, SentAt: time.Now()}
+	return nil
+}
+
+// BlackboardGet returns the entry stored under key.
+func (m *AgentManager) BlackboardGet(key string) (BlackboardEntry, bool) {
+	m.bus.mu.Lock()
+	defer m.bus.mu.Unlock()
+
+	entry, exists := m.bus.blackboard[key]
+	if !exists {
+		return BlackboardEntry{}, false
+	}
+	return *entry, true
+}
+
+// BlackboardSet stores a value under key unconditionally.
+func (m *AgentManager) BlackboardSet(agentID, key, value string) (BlackboardEntry, error) {
+	return m.blackboardWrite(agentID, key, value, -1)
+}
+
+// BlackboardCompareAndSwap stores a value under key only if the key is at expectedVersion.
+func (m *AgentManager) BlackboardCompareAndSwap(agentID, key string, expectedVersion int, value string) (BlackboardEntry, error) {
+	if expectedVersion < 0 {
+		return BlackboardEntry{}, fmt.Errorf("expected_version must be 0 or greater")
+	}
+	return m.blackboardWrite(agentID, key, value, expectedVersion)
+}
+
+// blackboardWrite stores a value, checking the current version unless expectedVersion is negative.
+func (m *AgentManager) blackboardWrite(agentID, key, value string, expectedVersion int) (BlackboardEntry, error) {
+	if strings.TrimSpace(key) == "" {
+		return BlackboardEntry{}, fmt.Errorf("key must not be empty")
+	}
+
+	m.bus.mu.Lock()
+	defer m.bus.mu.Unlock()
+
+	current, exists := m.bus.blackboard[key]
+	version := 0
+	if exists {
+		version = current.Version
+	}
+
+	if expectedVersion >= 0 && version != expectedVersion {
+		if !exists {
+			return BlackboardEntry{}, fmt.Errorf("compare-and-swap failed: key %s does not exist (expected version %d)", key, expectedVersion)
+		}
+		return BlackboardEntry{}, fmt.Errorf("compare-and-swap failed: key %s is at version %d (expected %d), last written by agent %s with value %q",
+			key, version, expectedVersion, current.UpdatedBy, current.Value)
+	}
+
+	entry := &BlackboardEntry{Key: key, Value: value, Version: version + 1, UpdatedBy: agentID, UpdatedAt: time.Now()}
+	m.bus.blackboard[key] = entry
+	return *entry, nil
+}
+
+// BlackboardKeys returns the sorted keys on the blackboard that start with prefix.
+func (m *AgentManager) BlackboardKeys(prefix string) []string {
+	m.bus.mu.Lock()
+	defer m.bus.mu.Unlock()
+
+	keys := make([]string, 0, len(m.bus.blackboard))
+	for key := range m.bus.blackboard {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// renderMessage formats a message envelope for delivery to an agent.
+func renderMessage(msg AgentMessage) string {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return msg.Body
+	}
+	return "[Agent message]: " + string(data)
+}
+
+func renderJSON(v interface{}) (string, error) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to serialize result: %w", err)
+	}
+	return string(data), nil
+}
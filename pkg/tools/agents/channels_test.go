@@ -0,0 +1,123 @@
+package agents
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestRequest(t *testing.T) {
+	Convey("Given agents on a message bus", t, func() {
+		m := &AgentManager{agents: make(map[string]*Agent), bus: newMessageBus()}
+		for _, id := range []string{"a", "b", "c"} {
+			m.agents[id] = &Agent{ID: id, shutdownChan: make(chan struct{})}
+		}
+
+		// pendingTo waits for the request from one agent to another and returns its correlation ID.
+		pendingTo := func(from, to string) string {
+			for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+				m.bus.mu.Lock()
+				for correlationID, pending := range m.bus.requests {
+					if pending.from == from && pending.to == to {
+						m.bus.mu.Unlock()
+						return correlationID
+					}
+				}
+				m.bus.mu.Unlock()
+			}
+			return ""
+		}
+
+		type result struct {
+			reply AgentMessage
+			err   error
+		}
+		request := func(ctx context.Context, from, to string, timeout time.Duration) chan result {
+			done := make(chan result, 1)
+			go func() {
+				reply, err := m.Request(ctx, from, to, "status?", timeout)
+				done <- result{reply, err}
+			}()
+			return done
+		}
+
+		Convey("It should return the recipient's reply", func() {
+			done := request(context.Background(), "a", "b", time.Minute)
+
+			So(m.Reply("b", pendingTo("a", "b"), "all good"), ShouldBeNil)
+
+			res := <-done
+			So(res.err, ShouldBeNil)
+			So(res.reply.Body, ShouldEqual, "all good")
+			So(res.reply.From, ShouldEqual, "b")
+			So(m.bus.requests, ShouldBeEmpty)
+		})
+
+		Convey("It should give up when the timeout passes", func() {
+			_, err := m.Request(context.Background(), "a", "b", "status?", 10*time.Millisecond)
+
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "did not reply")
+		})
+
+		Convey("It should give up when the context is cancelled", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			done := request(ctx, "a", "b", time.Minute)
+			pendingTo("a", "b")
+
+			cancel()
+
+			So((<-done).err, ShouldWrap, context.Canceled)
+		})
+
+		Convey("It should give up when the sender is shut down", func() {
+			done := request(context.Background(), "a", "b", time.Minute)
+			pendingTo("a", "b")
+
+			close(m.agents["a"].shutdownChan)
+
+			res := <-done
+			So(res.err, ShouldNotBeNil)
+			So(res.err.Error(), ShouldContainSubstring, "you were shut down")
+			So(m.bus.requests, ShouldBeEmpty)
+		})
+
+		Convey("It should reject a request to an agent waiting on the sender", func() {
+			request(context.Background(), "a", "b", time.Minute)
+			pendingTo("a", "b")
+
+			_, err := m.Request(context.Background(), "b", "a", "status?", time.Minute)
+
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "agent a is waiting for a reply from you")
+			close(m.agents["a"].shutdownChan)
+		})
+
+		Convey("It should reject a request closing a longer cycle", func() {
+			request(context.Background(), "a", "b", time.Minute)
+			request(context.Background(), "b", "c", time.Minute)
+			pendingTo("a", "b")
+			pendingTo("b", "c")
+
+			_, err := m.Request(context.Background(), "c", "a", "status?", time.Minute)
+			So(err, ShouldNotBeNil)
+
+			// Requests that do not close a cycle are still allowed.
+			So(m.bus.waitsOn("c", "a"), ShouldBeFalse)
+			So(m.bus.waitsOn("a", "c"), ShouldBeTrue)
+
+			close(m.agents["a"].shutdownChan)
+			close(m.agents["b"].shutdownChan)
+		})
+
+		Convey("It should reject requests to itself and to unknown agents", func() {
+			_, err := m.Request(context.Background(), "a", "a", "status?", time.Minute)
+			So(err, ShouldNotBeNil)
+
+			_, err = m.Request(context.Background(), "a", "z", "status?", time.Minute)
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
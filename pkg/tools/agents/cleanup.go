@@ -0,0 +1,129 @@
+package agents
+
+import (
+	"context"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/theapemachine/mcp-server-devops-bridge/core/container"
+	"github.com/theapemachine/mcp-server-devops-bridge/pkg/config"
+)
+
+// StartAgentCleanup starts the background goroutine that shuts down idle agents and
+// removes sandboxes left behind by agents that no longer exist, including those of a
+// previous server process that crashed.
+func StartAgentCleanup() {
+	m, err := NewAgentManager()
+	if err != nil || m == nil {
+		return
+	}
+
+	cfg := config.Load()
+	interval := cfg.Agents.ReapInterval
+	if interval <= 0 {
+		interval = time.Minute
+	}
+
+	reaper, err := container.NewReaper(m.runtime)
+	if err != nil {
+		log.Warnf("Orphaned sandboxes will not be removed: %v", err)
+	}
+
+	go func() {
+		// A missing runtime makes every pass fail the same way, so only the first failure is a warning.
+		failing := false
+		pass := func() {
+			m.shutdownIdleAgents(cfg.Agents.IdleTimeout)
+			if reaper == nil {
+				return
+			}
+
+			err := m.reapOrphans(reaper)
+			switch {
+			case err != nil && !failing:
+				log.Warnf("Failed to reap orphaned sandboxes, will keep retrying quietly: %v", err)
+			case err != nil:
+				log.Debugf("Failed to reap orphaned sandboxes: %v", err)
+			case failing:
+				log.Infof("Reaping orphaned sandboxes works again")
+			}
+			failing = err != nil
+		}
+
+		pass()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			pass()
+		}
+	}()
+}
+
+// retire stops the sandbox and browser of a finished agent, which never runs again, and hands
+// its slot to the next queued agent. The agent's record and result are kept, so they can still
+// be read until the agent is shut down.
+func (m *AgentManager) retire(agent *Agent) {
+	m.mu.Lock()
+	sandbox := agent.container
+	agent.container = nil
+	m.mu.Unlock()
+
+	if sandbox != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := sandbox.StopAndRemove(ctx); err != nil {
+			log.Warnf("Failed to stop the sandbox of finished agent %s: %v", agent.ID, err)
+		}
+	}
+	m.browserManager.CleanupForAgent(agent.ID)
+
+	m.yieldSlot(agent)
+}
+
+// shutdownIdleAgents shuts down the agents that have been waiting for input for longer than the
+// idle timeout. Completed and failed agents are kept, so their results can still be read; their
+// sandboxes were already stopped when they finished.
+func (m *AgentManager) shutdownIdleAgents(idleTimeout time.Duration) {
+	if idleTimeout <= 0 {
+		return
+	}
+
+	now := time.Now()
+	for _, agent := range m.ListAgents() {
+		state := agent.state()
+		if !idleExpired(state, idleTimeout, now) {
+			continue
+		}
+		log.Infof("Shutting down agent %s after being idle for %s", agent.ID, now.Sub(state.LastActive).Round(time.Second))
+		if err := m.ShutdownAgent(agent.ID); err != nil {
+			log.Warnf("Failed to shut down idle agent %s: %v", agent.ID, err)
+		}
+	}
+}
+
+// idleExpired reports whether an agent has been waiting for input for longer than the idle timeout.
+func idleExpired(state agentState, idleTimeout time.Duration, now time.Time) bool {
+	return state.Status == StatusWaiting && now.Sub(state.LastActive) > idleTimeout
+}
+
+// reapOrphans runs a single pass of orphaned sandbox removal.
+func (m *AgentManager) reapOrphans(reaper *container.Reaper) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	reaped, err := reaper.Reap(ctx, func(agentID string) bool {
+		m.mu.RLock()
+		defer m.mu.RUnlock()
+		_, exists := m.agents[agentID]
+		return exists
+	})
+	if err != nil {
+		return err
+	}
+	for _, agentID := range reaped {
+		log.Infof("Removed orphaned sandbox of agent %s", agentID)
+	}
+	return nil
+}
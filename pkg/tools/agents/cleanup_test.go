@@ -0,0 +1,53 @@
+package agents
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/theapemachine/mcp-server-devops-bridge/core/container"
+)
+
+func TestIdleExpired(t *testing.T) {
+	Convey("Given an idle timeout", t, func() {
+		now := time.Now()
+		timeout := 10 * time.Minute
+		longAgo := now.Add(-time.Hour)
+
+		Convey("An agent waiting for input past the timeout is idle", func() {
+			So(idleExpired(agentState{Status: StatusWaiting, LastActive: longAgo}, timeout, now), ShouldBeTrue)
+			So(idleExpired(agentState{Status: StatusWaiting, LastActive: now.Add(-time.Minute)}, timeout, now), ShouldBeFalse)
+		})
+
+		Convey("Finished agents are kept so their results can be read", func() {
+			So(idleExpired(agentState{Status: StatusCompleted, LastActive: longAgo}, timeout, now), ShouldBeFalse)
+			So(idleExpired(agentState{Status: StatusFailed, LastActive: longAgo}, timeout, now), ShouldBeFalse)
+		})
+
+		Convey("Working agents are never idle", func() {
+			for _, status := range []Status{StatusRunning, StatusInitializing, StatusQueued, StatusAwaitingApproval} {
+				So(idleExpired(agentState{Status: status, LastActive: longAgo}, timeout, now), ShouldBeFalse)
+			}
+		})
+	})
+}
+
+func TestRetire(t *testing.T) {
+	Convey("Given an agent that finished its task", t, func() {
+		m := &AgentManager{agents: make(map[string]*Agent), runtime: container.RuntimeLocal, browserManager: &BrowserManager{}}
+		agent := &Agent{ID: "finished", taskChan: make(chan string), shutdownChan: make(chan struct{})}
+		So(m.admit(agent), ShouldBeNil)
+		sandbox := agent.container
+		agent.setStatus(StatusCompleted, "All done.")
+
+		m.retire(agent)
+
+		Convey("Its sandbox is stopped while its record and result are kept", func() {
+			So(sandbox.IsRunning(context.Background()), ShouldBeFalse)
+			So(agent.container, ShouldBeNil)
+			So(m.agents, ShouldContainKey, "finished")
+			So(agent.state().Result, ShouldEqual, "All done.")
+		})
+	})
+}
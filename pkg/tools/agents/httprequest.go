@@ -0,0 +1,228 @@
+package agents
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"strings"
+	"syscall"
+	"time"
+	"unicode/utf8"
+
+	"github.com/openai/openai-go"
+)
+
+const (
+	// httpMaxResponseBytes is how much of a response body is read; the rest is discarded.
+	httpMaxResponseBytes = 1 << 20
+	// httpMaxResultChars is how much of the formatted response is returned to the agent.
+	httpMaxResultChars = 16000
+	// httpDefaultTimeout and httpMaxTimeout bound how long a request may take.
+	httpDefaultTimeout = 30 * time.Second
+	httpMaxTimeout     = 2 * time.Minute
+	// httpMaxRedirects is the number of redirects followed before giving up.
+	httpMaxRedirects = 5
+)
+
+// httpRequestArgs are the arguments of the http_request agent tool.
+type httpRequestArgs struct {
+	Method         string            `json:"method"`
+	URL            string            `json:"url"`
+	Headers        map[string]string `json:"headers"`
+	Body           string            `json:"body"`
+	TimeoutSeconds int               `json:"timeout_seconds"`
+}
+
+// httpRequestTool returns the definition of the http_request agent tool.
+func httpRequestTool() openai.ChatCompletionToolParam {
+	return openai.ChatCompletionToolParam{
+		Function: openai.FunctionDefinitionParam{
+			Name:        "http_request",
+			Description: openai.String("Make an HTTP request without a browser and return the status, content type and body. Best for JSON APIs and raw files. JSON responses are pretty-printed."),
+			Parameters: openai.FunctionParameters{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"method": map[string]interface{}{
+						"type":        "string",
+						"enum":        []string{"GET", "HEAD", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+						"description": "The HTTP method. Defaults to GET.",
+					},
+					"url": map[string]string{
+						"type":        "string",
+						"description": "The URL to request.",
+					},
+					"headers": map[string]interface{}{
+						"type":                 "object",
+						"additionalProperties": map[string]string{"type": "string"},
+						"description":          "Request headers, such as Accept or Content-Type.",
+					},
+					"body": map[string]string{
+						"type":        "string",
+						"description": "The request body.",
+					},
+					"timeout_seconds": map[string]string{
+						"type":        "integer",
+						"description": "How long to wait for the response. Defaults to 30, at most 120.",
+					},
+				},
+				"required": []string{"url"},
+			},
+		},
+	}
+}
+
+// httpRequest performs an HTTP request for the agent. The URL and every redirect are checked
+// against the same URL policy as browse_web.
+func (m *AgentManager) httpRequest(agentID string, args httpRequestArgs) (string, error) {
+	method := strings.ToUpper(strings.TrimSpace(args.Method))
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	timeout := httpDefaultTimeout
+	if args.TimeoutSeconds > 0 {
+		timeout = min(time.Duration(args.TimeoutSeconds)*time.Second, httpMaxTimeout)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if err := m.browserManager.CheckURL(ctx, agentID, args.URL); err != nil {
+		return "", err
+	}
+
+	var body io.Reader
+	if args.Body != "" {
+		body = strings.NewReader(args.Body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, args.URL, body)
+	if err != nil {
+		return "", fmt.Errorf("invalid request: %w", err)
+	}
+	for key, value := range args.Headers {
+		req.Header.Set(key, value)
+	}
+	if req.Header.Get("User-Agent") == "" {
+		req.Header.Set("User-Agent", robotsUserAgent)
+	}
+
+	resp, err := m.httpClient(agentID).Do(req)
+	if err != nil {
+		return "", fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, httpMaxResponseBytes+1))
+	if err != nil {
+		return "", fmt.Errorf("failed to read response: %w", err)
+	}
+	truncated := len(data) > httpMaxResponseBytes
+	if truncated {
+		data = data[:httpMaxResponseBytes]
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "HTTP %s\n", resp.Status)
+	for _, key := range []string{"Content-Type", "Content-Length", "Location"} {
+		if value := resp.Header.Get(key); value != "" {
+			fmt.Fprintf(&b, "%s: %s\n", key, value)
+		}
+	}
+	b.WriteString("\n")
+	b.WriteString(formatResponseBody(resp.Header.Get("Content-Type"), data, truncated))
+
+	return truncateResult(b.String(), httpMaxResultChars), nil
+}
+
+// truncateResult cuts the result down to at most limit bytes on a rune boundary.
+func truncateResult(result string, limit int) string {
+	if len(result) <= limit {
+		return result
+	}
+	end := limit
+	for end > 0 && !utf8.RuneStart(result[end]) {
+		end--
+	}
+	return result[:end] + "... (content truncated)"
+}
+
+// httpClient returns a client that checks redirects against the agent's URL policy and, when
+// the global or the agent's policy blocks private networks, refuses to connect to private
+// addresses. The dial check catches hosts that resolve differently between the policy check
+// and the connection.
+func (m *AgentManager) httpClient(agentID string) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: 10 * time.Second,
+		Control: dialControl(m.browserManager.policiesFor(agentID)),
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = dialer.DialContext
+	transport.Proxy = nil // A proxy would bypass the address check
+
+	return &http.Client{
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= httpMaxRedirects {
+				return fmt.Errorf("stopped after %d redirects", httpMaxRedirects)
+			}
+			return m.browserManager.CheckURL(req.Context(), agentID, req.URL.String())
+		},
+	}
+}
+
+// dialControl returns a dialer check that refuses private addresses if any of the policies
+// blocks private networks, or nil if none does.
+func dialControl(policies []*URLPolicy) func(network, address string, _ syscall.RawConn) error {
+	blocked := false
+	for _, policy := range policies {
+		blocked = blocked || (policy != nil && policy.BlockPrivateNetworks)
+	}
+	if !blocked {
+		return nil
+	}
+
+	return func(network, address string, _ syscall.RawConn) error {
+		host, _, err := net.SplitHostPort(address)
+		if err != nil {
+			return err
+		}
+		if ip := net.ParseIP(host); ip != nil && !isPublicIP(ip) {
+			return &PolicyError{URL: address, Reason: fmt.Sprintf("connecting to the private address %s is not allowed", ip)}
+		}
+		return nil
+	}
+}
+
+// formatResponseBody renders a response body for the agent, pretty-printing JSON and
+// leaving out binary content.
+func formatResponseBody(contentType string, data []byte, truncated bool) string {
+	if len(data) == 0 {
+		return "(empty body)"
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	isJSON := mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+	if !truncated && (isJSON || mediaType == "" || mediaType == "text/plain") {
+		var indented bytes.Buffer
+		if err := json.Indent(&indented, data, "", "  "); err == nil {
+			return indented.String()
+		}
+	}
+
+	if !utf8.Valid(data) {
+		return fmt.Sprintf("(binary content of %d bytes omitted)", len(data))
+	}
+
+	body := string(data)
+	if truncated {
+		body += fmt.Sprintf("\n... (response truncated at %d bytes)", httpMaxResponseBytes)
+	}
+	return body
+}
@@ -0,0 +1,83 @@
+package agents
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestTruncateResult(t *testing.T) {
+	Convey("Given a result with multi-byte runes", t, func() {
+		result := strings.Repeat("ü", 10) // Two bytes each
+
+		Convey("It should leave results within the limit alone", func() {
+			So(truncateResult(result, 20), ShouldEqual, result)
+		})
+
+		Convey("It should cut on a rune boundary", func() {
+			truncated := truncateResult(result, 5)
+
+			So(utf8.ValidString(truncated), ShouldBeTrue)
+			So(truncated, ShouldEqual, "üü... (content truncated)")
+		})
+	})
+}
+
+func TestDialControl(t *testing.T) {
+	Convey("Given URL policies", t, func() {
+		Convey("It should not check addresses when no policy blocks private networks", func() {
+			So(dialControl([]*URLPolicy{{}, nil}), ShouldBeNil)
+		})
+
+		Convey("It should refuse private addresses when only the agent's policy blocks them", func() {
+			control := dialControl([]*URLPolicy{{}, {BlockPrivateNetworks: true}})
+
+			So(control, ShouldNotBeNil)
+			So(control("tcp", "93.184.216.34:443", nil), ShouldBeNil)
+
+			err := control("tcp", "127.0.0.1:8080", nil)
+			var policyErr *PolicyError
+			So(errors.As(err, &policyErr), ShouldBeTrue)
+			So(policyErr.Reason, ShouldContainSubstring, "127.0.0.1")
+		})
+
+		Convey("It should refuse connections that resolve to a private address after the policy check", func() {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+			defer server.Close()
+
+			dialer := &net.Dialer{Control: dialControl([]*URLPolicy{nil, {BlockPrivateNetworks: true}})}
+			transport := &http.Transport{DialContext: dialer.DialContext}
+
+			_, err := (&http.Client{Transport: transport}).Get(server.URL)
+
+			var policyErr *PolicyError
+			So(errors.As(err, &policyErr), ShouldBeTrue)
+		})
+	})
+}
+
+func TestFormatResponseBody(t *testing.T) {
+	Convey("Given response bodies", t, func() {
+		Convey("It should pretty-print JSON", func() {
+			So(formatResponseBody("application/json; charset=utf-8", []byte(`{"a":1}`), false), ShouldEqual, "{\n  \"a\": 1\n}")
+		})
+
+		Convey("It should leave out binary content", func() {
+			So(formatResponseBody("application/octet-stream", []byte{0xff, 0xfe, 0x00}, false), ShouldEqual, "(binary content of 3 bytes omitted)")
+		})
+
+		Convey("It should say when the body was truncated", func() {
+			So(formatResponseBody("text/html", []byte("<p>"), true), ShouldEndWith, "(response truncated at 1048576 bytes)")
+		})
+
+		Convey("It should say when the body is empty", func() {
+			So(formatResponseBody("text/plain", nil, false), ShouldEqual, "(empty body)")
+		})
+	})
+}
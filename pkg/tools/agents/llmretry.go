@@ -0,0 +1,128 @@
+package agents
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/openai/openai-go"
+)
+
+// errAgentShutdown is returned when the agent is shut down during an LLM request or while waiting to retry.
+var errAgentShutdown = errors.New("the agent was shut down")
+
+// llmError classifies a failed LLM request.
+type llmError struct {
+	retryable  bool
+	retryAfter time.Duration // Delay the server asked for, zero if it did not
+}
+
+// classifyLLMError decides whether a failed LLM request is worth retrying. Rate limits, timeouts
+// and server errors are; authentication, permission and invalid-request errors fail right away,
+// as does a 429 for an exhausted quota, which no amount of waiting fixes. Errors without a
+// response, such as connection failures, are retried.
+func classifyLLMError(err error) llmError {
+	var apiErr *openai.Error
+	if !errors.As(err, &apiErr) {
+		return llmError{retryable: !errors.Is(err, context.Canceled)}
+	}
+
+	var result llmError
+	if apiErr.Response != nil {
+		result.retryAfter = retryAfter(apiErr.Response.Header)
+	}
+
+	switch {
+	case apiErr.StatusCode == http.StatusTooManyRequests:
+		result.retryable = apiErr.Code != "insufficient_quota"
+	case apiErr.StatusCode == http.StatusRequestTimeout, apiErr.StatusCode == http.StatusConflict:
+		result.retryable = true
+	case apiErr.StatusCode >= 500:
+		result.retryable = true
+	}
+	return result
+}
+
+// retryAfter parses the Retry-After-Ms and Retry-After headers. Retry-After may be a number of
+// seconds or an HTTP date.
+func retryAfter(header http.Header) time.Duration {
+	if ms, err := strconv.ParseFloat(header.Get("Retry-After-Ms"), 64); err == nil && ms > 0 {
+		return time.Duration(ms * float64(time.Millisecond))
+	}
+
+	value := header.Get("Retry-After")
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil && seconds > 0 {
+		return time.Duration(seconds * float64(time.Second))
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return max(time.Until(date), 0)
+	}
+	return 0
+}
+
+// backoffDelay returns the delay before the given retry, counting from zero: exponential in the
+// attempt with full jitter, capped at maxDelay.
+func backoffDelay(attempt int, baseDelay, maxDelay time.Duration) time.Duration {
+	ceiling := min(baseDelay, maxDelay)
+	for i := 0; i < attempt && ceiling < maxDelay; i++ {
+		ceiling = min(ceiling*2, maxDelay)
+	}
+	if ceiling <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(ceiling)) + 1)
+}
+
+// completeWithRetry calls the LLM for the agent, retrying transient errors with exponential
+// backoff. Waiting happens within the current iteration, so retries do not count against the
+// agent's iteration limit. A delay requested by the server is never shortened, but one longer
+// than the maximum retry delay fails the request instead of stalling the agent. Shutting the
+// agent down cancels the request in flight.
+func (m *AgentManager) completeWithRetry(agent *Agent, params openai.ChatCompletionNewParams) (*openai.ChatCompletion, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-agent.shutdownChan:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	for attempt := 0; ; attempt++ {
+		completion, err := m.chatCompletion(ctx, params)
+		if err == nil {
+			return completion, nil
+		}
+		if ctx.Err() != nil {
+			return nil, errAgentShutdown
+		}
+
+		classified := classifyLLMError(err)
+		if !classified.retryable {
+			return nil, err
+		}
+		if attempt >= m.llmMaxRetries {
+			return nil, fmt.Errorf("giving up after %d retries: %w", attempt, err)
+		}
+		if classified.retryAfter > m.llmRetryMaxDelay {
+			return nil, fmt.Errorf("giving up, the server asked to retry in %s, longer than the maximum retry delay of %s: %w",
+				classified.retryAfter.Round(time.Second), m.llmRetryMaxDelay, err)
+		}
+
+		delay := max(backoffDelay(attempt, m.llmRetryBaseDelay, m.llmRetryMaxDelay), classified.retryAfter)
+		log.Warnf("LLM request of agent %s failed, retrying in %s (retry %d of %d): %v", agent.ID, delay.Round(time.Millisecond), attempt+1, m.llmMaxRetries, err)
+		agent.setResult(fmt.Sprintf("LLM request failed, retrying in %s (retry %d of %d): %v", delay.Round(time.Millisecond), attempt+1, m.llmMaxRetries, err))
+
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return nil, errAgentShutdown
+		}
+	}
+}
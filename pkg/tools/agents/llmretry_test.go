@@ -0,0 +1,212 @@
+package agents
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/openai/openai-go"
+	"github.com/openai/openai-go/option"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestClassifyLLMError(t *testing.T) {
+	Convey("Given failed LLM requests", t, func() {
+		apiError := func(status int, code string, header http.Header) error {
+			return &openai.Error{StatusCode: status, Code: code, Response: &http.Response{StatusCode: status, Header: header}}
+		}
+
+		Convey("It should retry rate limits, timeouts, conflicts and server errors", func() {
+			for _, status := range []int{http.StatusTooManyRequests, http.StatusRequestTimeout, http.StatusConflict, http.StatusInternalServerError, http.StatusServiceUnavailable} {
+				So(classifyLLMError(apiError(status, "", nil)).retryable, ShouldBeTrue)
+			}
+		})
+
+		Convey("It should fail right away on client errors and exhausted quotas", func() {
+			for _, status := range []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound} {
+				So(classifyLLMError(apiError(status, "", nil)).retryable, ShouldBeFalse)
+			}
+			So(classifyLLMError(apiError(http.StatusTooManyRequests, "insufficient_quota", nil)).retryable, ShouldBeFalse)
+		})
+
+		Convey("It should keep the delay the server asked for", func() {
+			classified := classifyLLMError(apiError(http.StatusTooManyRequests, "", http.Header{"Retry-After": []string{"7"}}))
+
+			So(classified.retryAfter, ShouldEqual, 7*time.Second)
+		})
+
+		Convey("It should retry errors without a response, unless the request was cancelled", func() {
+			So(classifyLLMError(errors.New("connection reset by peer")).retryable, ShouldBeTrue)
+			So(classifyLLMError(context.Canceled).retryable, ShouldBeFalse)
+		})
+	})
+}
+
+func TestRetryAfter(t *testing.T) {
+	Convey("Given retry headers", t, func() {
+		Convey("It should prefer milliseconds", func() {
+			So(retryAfter(http.Header{"Retry-After-Ms": []string{"1500"}, "Retry-After": []string{"9"}}), ShouldEqual, 1500*time.Millisecond)
+		})
+
+		Convey("It should parse seconds and HTTP dates", func() {
+			So(retryAfter(http.Header{"Retry-After": []string{"0.5"}}), ShouldEqual, 500*time.Millisecond)
+
+			delay := retryAfter(http.Header{"Retry-After": []string{time.Now().Add(time.Minute).UTC().Format(http.TimeFormat)}})
+			So(delay, ShouldBeBetween, 58*time.Second, time.Minute+time.Second)
+
+			So(retryAfter(http.Header{"Retry-After": []string{time.Now().Add(-time.Minute).UTC().Format(http.TimeFormat)}}), ShouldEqual, 0)
+		})
+
+		Convey("It should ignore missing and invalid values", func() {
+			So(retryAfter(http.Header{}), ShouldEqual, 0)
+			So(retryAfter(http.Header{"Retry-After": []string{"soon"}}), ShouldEqual, 0)
+		})
+	})
+}
+
+func TestBackoffDelay(t *testing.T) {
+	Convey("Given a base and maximum delay", t, func() {
+		base, maxDelay := 100*time.Millisecond, time.Second
+
+		Convey("It should stay within the doubling ceiling of each attempt", func() {
+			for attempt, ceiling := range []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond, time.Second, time.Second} {
+				for i := 0; i < 50; i++ {
+					So(backoffDelay(attempt, base, maxDelay), ShouldBeBetweenOrEqual, time.Nanosecond, ceiling)
+				}
+			}
+		})
+
+		Convey("It should not overflow on many attempts", func() {
+			So(backoffDelay(1000, base, maxDelay), ShouldBeBetweenOrEqual, time.Nanosecond, maxDelay)
+		})
+
+		Convey("It should not wait without a delay", func() {
+			So(backoffDelay(3, 0, maxDelay), ShouldEqual, 0)
+		})
+	})
+}
+
+func TestCompleteWithRetry(t *testing.T) {
+	Convey("Given an LLM that fails before it answers", t, func() {
+		calls := 0
+		statuses := []int{http.StatusServiceUnavailable, http.StatusTooManyRequests}
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			w.Header().Set("Content-Type", "application/json")
+			if calls <= len(statuses) {
+				w.WriteHeader(statuses[calls-1])
+				_, _ = w.Write([]byte(`{"error":{"message":"try again"}}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"id":"1","object":"chat.completion","choices":[{"index":0,"message":{"role":"assistant","content":"hello"}}]}`))
+		}))
+		defer server.Close()
+
+		client := openai.NewClient(option.WithBaseURL(server.URL), option.WithAPIKey("test"), option.WithMaxRetries(0))
+		m := &AgentManager{openaiCli: &client, llmRetryBaseDelay: time.Millisecond, llmRetryMaxDelay: 5 * time.Millisecond}
+		agent := &Agent{ID: "agent", shutdownChan: make(chan struct{})}
+		params := openai.ChatCompletionNewParams{Model: "gpt-4o", Messages: []openai.ChatCompletionMessageParamUnion{openai.UserMessage("hi")}}
+
+		Convey("It should retry until the LLM answers", func() {
+			m.llmMaxRetries = 3
+
+			completion, err := m.completeWithRetry(agent, params)
+
+			So(err, ShouldBeNil)
+			So(completion.Choices[0].Message.Content, ShouldEqual, "hello")
+			So(calls, ShouldEqual, 3)
+			So(agent.state().Result, ShouldContainSubstring, "retry 2 of 3")
+		})
+
+		Convey("It should give up after the maximum number of retries", func() {
+			m.llmMaxRetries = 1
+
+			_, err := m.completeWithRetry(agent, params)
+
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "giving up after 1 retries")
+			So(calls, ShouldEqual, 2)
+		})
+
+		Convey("It should stop waiting when the agent is shut down", func() {
+			m.llmMaxRetries = 3
+			m.llmRetryBaseDelay, m.llmRetryMaxDelay = time.Hour, time.Hour
+			close(agent.shutdownChan)
+
+			_, err := m.completeWithRetry(agent, params)
+
+			So(err, ShouldEqual, errAgentShutdown)
+		})
+	})
+
+	Convey("Given an LLM that asks to retry much later", t, func() {
+		calls := 0
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("Retry-After", "3600")
+			w.WriteHeader(http.StatusTooManyRequests)
+			_, _ = w.Write([]byte(`{"error":{"message":"slow down"}}`))
+		}))
+		defer server.Close()
+
+		client := openai.NewClient(option.WithBaseURL(server.URL), option.WithAPIKey("test"), option.WithMaxRetries(0))
+		m := &AgentManager{openaiCli: &client, llmMaxRetries: 3, llmRetryBaseDelay: time.Millisecond, llmRetryMaxDelay: time.Minute}
+
+		Convey("It should fail instead of waiting longer than the maximum retry delay", func() {
+			_, err := m.completeWithRetry(&Agent{ID: "agent", shutdownChan: make(chan struct{})}, openai.ChatCompletionNewParams{Model: "gpt-4o"})
+
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "asked to retry in 1h0m0s")
+			So(calls, ShouldEqual, 1)
+		})
+	})
+
+	Convey("Given an LLM that does not answer", t, func() {
+		release := make(chan struct{})
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			select {
+			case <-r.Context().Done():
+			case <-release:
+			}
+		}))
+		defer server.Close()
+		defer close(release)
+
+		client := openai.NewClient(option.WithBaseURL(server.URL), option.WithAPIKey("test"), option.WithMaxRetries(0))
+		m := &AgentManager{openaiCli: &client, llmMaxRetries: 3, llmRetryBaseDelay: time.Millisecond, llmRetryMaxDelay: time.Millisecond}
+
+		Convey("It should cancel the request when the agent is shut down", func() {
+			agent := &Agent{ID: "agent", shutdownChan: make(chan struct{})}
+			time.AfterFunc(20*time.Millisecond, func() { close(agent.shutdownChan) })
+
+			_, err := m.completeWithRetry(agent, openai.ChatCompletionNewParams{Model: "gpt-4o"})
+
+			So(err, ShouldEqual, errAgentShutdown)
+		})
+	})
+
+	Convey("Given an LLM that rejects the request", t, func() {
+		calls := 0
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":{"message":"bad model"}}`))
+		}))
+		defer server.Close()
+
+		client := openai.NewClient(option.WithBaseURL(server.URL), option.WithAPIKey("test"), option.WithMaxRetries(0))
+		m := &AgentManager{openaiCli: &client, llmMaxRetries: 3, llmRetryBaseDelay: time.Millisecond, llmRetryMaxDelay: time.Millisecond}
+
+		Convey("It should fail without retrying", func() {
+			_, err := m.completeWithRetry(&Agent{ID: "agent", shutdownChan: make(chan struct{})}, openai.ChatCompletionNewParams{Model: "gpt-4o"})
+
+			So(err, ShouldNotBeNil)
+			So(calls, ShouldEqual, 1)
+		})
+	})
+}
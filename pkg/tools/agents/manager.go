@@ -3,78 +3,34 @@ package agents
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/go-rod/rod"
-	"github.com/go-rod/rod/lib/launcher"
 	"github.com/theapemachine/mcp-server-devops-bridge/core/container"
+	"github.com/theapemachine/mcp-server-devops-bridge/pkg/config"
 
 	"github.com/google/uuid"
 	"github.com/openai/openai-go"
+	"github.com/openai/openai-go/option"
 )
 
-// --- BrowserManager ---
-
-// BrowserManager manages Rod browser instances for agents.
-type BrowserManager struct {
-	browsers map[string]*rod.Browser
-	mu       sync.Mutex
-}
-
-var (
-	browserManager *BrowserManager
-	browserOnce    sync.Once
-)
-
-// NewBrowserManager creates and returns a new BrowserManager.
-func NewBrowserManager() *BrowserManager {
-	browserOnce.Do(func() {
-		browserManager = &BrowserManager{
-			browsers: make(map[string]*rod.Browser),
-		}
-	})
-	return browserManager
-}
-
-// GetBrowserForAgent returns a browser instance for a given agent.
-func (m *BrowserManager) GetBrowserForAgent(agentID string) (*rod.Browser, error) {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-
-	if browser, exists := m.browsers[agentID]; exists {
-		return browser, nil
-	}
-
-	path, _ := launcher.LookPath()
-	u := launcher.New().Bin(path).MustLaunch()
-
-	browser := rod.New().ControlURL(u).MustConnect()
-	m.browsers[agentID] = browser
-	return browser, nil
-}
-
-// CleanupForAgent closes the browser and cleans up resources for a given agent.
-func (m *BrowserManager) CleanupForAgent(agentID string) {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-
-	if browser, exists := m.browsers[agentID]; exists {
-		_ = browser.Close() // Best-effort close
-		delete(m.browsers, agentID)
-	}
-}
-
 // Status represents the status of an agent.
 type Status string
 
 const (
+	// StatusQueued is the status for an agent waiting for a free slot before it is created.
+	StatusQueued Status = "queued"
 	// StatusInitializing is the status for an agent that is being created.
 	StatusInitializing Status = "initializing"
 	// StatusRunning is the status for an agent that is currently processing.
 	StatusRunning Status = "running"
+	// StatusAwaitingApproval is the status for an agent paused until a human approves or rejects its next action.
+	StatusAwaitingApproval Status = "awaiting_approval"
 	// StatusWaiting is the status for an agent that is waiting for input.
 	StatusWaiting Status = "waiting_for_input"
 	// StatusCompleted is the status for an agent that has completed its work.
@@ -86,7 +42,7 @@ const (
 // Agent represents a sub-agent managed by the AgentManager.
 type Agent struct {
 	ID               string
-	container        *container.Container
+	container        container.Sandbox
 	Status           Status
 	SystemPrompt     string
 	Messages         []openai.ChatCompletionMessageParamUnion
@@ -94,18 +50,118 @@ type Agent struct {
 	Temperature      float64
 	MaxIterations    int
 	CurrentIteration int
+	MaxParallelTools int                    // Independent tool calls of one response that may run at once
+	Model            string                 // The LLM the agent runs on, empty for the default
+	Template         string                 // The template the agent was launched from, if any
+	Output           json.RawMessage        // The structured result passed to complete_task, for agents with an output schema
+	Artifacts        []string               // Paths of files the agent produced, such as screenshots
+	LastActive       time.Time              // When the agent last started or stopped working
+	image            string                 // The image the agent's sandbox is created from
+	allowedTools     []string               // Tool name patterns the agent may use, empty for all tools
+	outputSchema     map[string]interface{} // JSON schema the complete_task output must follow, nil for none
+	runOnStart       bool                   // Whether the execution loop starts once the sandbox is ready
+	hasSlot          bool                   // Whether the agent holds one of the concurrent agent slots
 	taskChan         chan string
 	shutdownChan     chan struct{}
 	pendingMessages  []openai.ChatCompletionMessageParamUnion
 	pendingMu        sync.Mutex
+	mu               sync.RWMutex // Guards Status, Result, Output, Artifacts, Messages, CurrentIteration and LastActive
+}
+
+// agentState is a consistent copy of the fields of an agent that change while it runs.
+type agentState struct {
+	Status     Status
+	Result     string
+	Output     json.RawMessage
+	Artifacts  []string
+	Messages   []openai.ChatCompletionMessageParamUnion
+	Iteration  int
+	LastActive time.Time
+}
+
+// state copies the fields of the agent that its run loop changes, so they can be read from
+// other goroutines.
+func (a *Agent) state() agentState {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	return agentState{
+		Status:     a.Status,
+		Result:     a.Result,
+		Output:     a.Output,
+		Artifacts:  append([]string(nil), a.Artifacts...),
+		Messages:   append([]openai.ChatCompletionMessageParamUnion(nil), a.Messages...),
+		Iteration:  a.CurrentIteration,
+		LastActive: a.LastActive,
+	}
+}
+
+// status returns the current status of the agent.
+func (a *Agent) status() Status {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.Status
+}
+
+// setStatus changes the status of the agent, and its latest result unless result is empty.
+// A status change means the agent started or stopped working, so it also counts as activity.
+func (a *Agent) setStatus(status Status, result string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.Status = status
+	if result != "" {
+		a.Result = result
+	}
+	a.LastActive = time.Now()
+}
+
+// setResult stores the latest result of the agent.
+func (a *Agent) setResult(result string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.Result = result
+}
+
+// addMessages appends messages to the conversation of the agent.
+func (a *Agent) addMessages(messages ...openai.ChatCompletionMessageParamUnion) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.Messages = append(a.Messages, messages...)
+}
+
+// nextIteration starts the next iteration of the run loop and returns its number.
+func (a *Agent) nextIteration() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.CurrentIteration++
+	return a.CurrentIteration
 }
 
 // AgentManager manages the lifecycle of agents.
 type AgentManager struct {
-	agents         map[string]*Agent
-	mu             sync.RWMutex
-	openaiCli      *openai.Client
-	browserManager *BrowserManager
+	agents            map[string]*Agent
+	snapshots         map[string]*Snapshot
+	mu                sync.RWMutex
+	workflows         map[string]*Workflow
+	workflowsMu       sync.Mutex
+	openaiCli         *openai.Client
+	browserManager    *BrowserManager
+	bus               *messageBus
+	approvalPolicy    *ApprovalPolicy
+	approvals         *approvalQueue
+	runtime           container.Runtime
+	image             string
+	artifactDir       string        // Directory agent artifacts are saved under, one subdirectory per agent
+	templateDir       string        // Directory agent templates are loaded from
+	maxAgents         int           // Maximum number of unfinished agents holding a sandbox at once, 0 means unlimited
+	activeAgents      int           // Number of agents currently holding a slot
+	launchQueue       []*Agent      // Agents waiting for a slot, in FIFO order
+	llmSlots          chan struct{} // Semaphore bounding concurrent LLM calls, nil means unlimited
+	maxParallelTools  int           // Default MaxParallelTools of new agents
+	llmMaxRetries     int           // Retries of a transient LLM error before the agent fails
+	llmRetryBaseDelay time.Duration // First backoff delay between LLM retries
+	llmRetryMaxDelay  time.Duration // Upper bound on the backoff delay
 }
 
 var (
@@ -122,74 +178,130 @@ func NewAgentManager() (*AgentManager, error) {
 			initErr = fmt.Errorf("OPENAI_API_KEY environment variable not set")
 			return
 		}
-		openaiCli := openai.NewClient()
+		cfg := config.Load()
+		runtime, err := container.ParseRuntime(cfg.Agents.Runtime)
+		if err != nil {
+			initErr = err
+			return
+		}
+
+		approvalPolicy, err := NewApprovalPolicy()
+		if err != nil {
+			initErr = err
+			return
+		}
+
+		// Retries are handled per agent in completeWithRetry, with backoff that does not count against iterations.
+		openaiCli := openai.NewClient(option.WithMaxRetries(0))
 
 		manager = &AgentManager{
-			agents:         make(map[string]*Agent),
-			openaiCli:      &openaiCli,
-			browserManager: NewBrowserManager(),
+			agents:            make(map[string]*Agent),
+			snapshots:         make(map[string]*Snapshot),
+			workflows:         make(map[string]*Workflow),
+			openaiCli:         &openaiCli,
+			browserManager:    NewBrowserManager(),
+			bus:               newMessageBus(),
+			approvalPolicy:    approvalPolicy,
+			approvals:         newApprovalQueue(),
+			runtime:           runtime,
+			image:             cfg.Agents.Image,
+			artifactDir:       cfg.Agents.ArtifactDir,
+			templateDir:       cfg.Agents.TemplateDir,
+			maxAgents:         cfg.Agents.MaxConcurrent,
+			maxParallelTools:  cfg.Agents.MaxParallelTools,
+			llmMaxRetries:     cfg.Agents.LLMMaxRetries,
+			llmRetryBaseDelay: cfg.Agents.LLMRetryBaseDelay,
+			llmRetryMaxDelay:  cfg.Agents.LLMRetryMaxDelay,
+		}
+		if cfg.Agents.MaxLLMCalls > 0 {
+			manager.llmSlots = make(chan struct{}, cfg.Agents.MaxLLMCalls)
 		}
 	})
 	return manager, initErr
 }
 
-// LaunchAgent creates a new agent, starts its execution loop, and creates a docker container.
-func (m *AgentManager) LaunchAgent(systemPrompt, userPrompt string, temperature float64, maxIterations int) (*Agent, error) {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-
-	ctx := context.Background()
-
-	// Create a new container manager for the agent
-	agentContainer, err := container.NewContainer("debian:stable-slim")
-	if err != nil {
-		return nil, fmt.Errorf("failed to create container manager: %w", err)
-	}
-
-	// Start the container and keep it running
-	err = agentContainer.Run(ctx, []string{"tail", "-f", "/dev/null"}, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to start container: %w", err)
-	}
-
-	// Inject meta-instructions into the system prompt
-	enhancedSystemPrompt := systemPrompt + fmt.Sprintf(`
-
-You are an autonomous agent running in a sandboxed Debian Linux container. You operate in an iterative loop with a maximum of %d iterations.
-1. You analyze the user's request and your current state (you can use the current context as a scratchpad).
-2. You decide which tool to use and call it. You have access to a shell via 'execute_command' and a web browser via 'browse_web' for research.
-3. You receive the result from the tool.
-4. You analyze the result and repeat the process, deciding on the next action.
-Use your available tools sequentially to break down the task and accomplish the goal.
-When the entire task is finished, use the 'complete_task' tool. If you reach the iteration limit, you must use 'complete_task' and summarize your work.`, maxIterations)
-
-	id := uuid.New().String()
+// LaunchAgent creates a new agent with its own sandbox and starts its execution loop.
+// When the maximum number of concurrent agents is reached, the agent is queued instead
+// and started as soon as another agent shuts down. An optional URL policy restricts the agent's
+// web access further than the global policy.
+func (m *AgentManager) LaunchAgent(systemPrompt, userPrompt string, temperature float64, maxIterations int, policy *URLPolicy) (*Agent, error) {
 	agent := &Agent{
-		ID:               id,
-		container:        agentContainer,
+		ID:               uuid.New().String(),
 		Status:           StatusInitializing,
-		SystemPrompt:     enhancedSystemPrompt,
+		SystemPrompt:     m.agentSystemPrompt(systemPrompt, maxIterations, false),
 		Messages:         []openai.ChatCompletionMessageParamUnion{openai.UserMessage(userPrompt)},
 		Temperature:      temperature,
 		MaxIterations:    maxIterations,
 		CurrentIteration: 0,
+		MaxParallelTools: m.maxParallelTools,
+		LastActive:       time.Now(),
+		image:            m.image,
+		runOnStart:       true,
 		pendingMessages:  make([]openai.ChatCompletionMessageParamUnion, 0),
 		taskChan:         make(chan string),
 		shutdownChan:     make(chan struct{}),
 	}
 
-	m.agents[id] = agent
-	go m.runAgent(agent)
+	if err := m.launch(agent, policy); err != nil {
+		return nil, err
+	}
 	return agent, nil
 }
 
+// launch sets the agent's URL policy and admits it. The policy must be in place before the agent starts running.
+func (m *AgentManager) launch(agent *Agent, policy *URLPolicy) error {
+	if policy != nil {
+		m.browserManager.SetPolicy(agent.ID, policy)
+	}
+
+	if err := m.admit(agent); err != nil {
+		m.browserManager.CleanupForAgent(agent.ID)
+		return err
+	}
+	return nil
+}
+
+// agentSystemPrompt appends the meta-instructions describing the agent loop to a system prompt.
+// Agents restricted to a subset of tools are not told about tools they cannot use.
+func (m *AgentManager) agentSystemPrompt(systemPrompt string, maxIterations int, restrictedTools bool) string {
+	environment := "a sandboxed Debian Linux container"
+	if m.runtime == container.RuntimeLocal {
+		environment = "a temporary working directory on the host machine (no container isolation)"
+	}
+
+	tools := "You have access to a shell via 'execute_command', a web browser via 'browse_web' for research, and 'http_request' for JSON APIs and raw files. After browsing, the 'browser_*' tools act on the same page: click, fill in and submit forms, scroll, wait for elements, extract links or tables, and take screenshots. To coordinate with other agents, subscribe and publish to topics, send requests that wait for a reply with 'request_agent', and share state on the blackboard ('blackboard_*'); answer requests sent to you with 'reply_to_request'."
+	if restrictedTools {
+		tools = "Only the tools you are given are available to you."
+	}
+
+	// Inject meta-instructions into the system prompt
+	return systemPrompt + fmt.Sprintf(`
+
+You are an autonomous agent running in %s. You operate in an iterative loop with a maximum of %d iterations.
+1. You analyze the user's request and your current state (you can use the current context as a scratchpad).
+2. You decide which tool to use and call it. %s
+3. You receive the result from the tool.
+4. You analyze the result and repeat the process, deciding on the next action.
+Use your available tools sequentially to break down the task and accomplish the goal.
+When the entire task is finished, use the 'complete_task' tool. If you reach the iteration limit, you must use 'complete_task' and summarize your work.`, environment, maxIterations, tools)
+}
+
 func (m *AgentManager) runAgent(agent *Agent) {
-	agent.Status = StatusRunning
+	agent.setStatus(StatusRunning, "")
 	defer func() {
 		// If the loop exits, ensure the agent's status is not left as 'running'.
+		agent.mu.Lock()
 		if agent.Status == StatusRunning {
 			agent.Status = StatusWaiting
 		}
+		agent.LastActive = time.Now()
+		finished := agent.Status == StatusCompleted || agent.Status == StatusFailed
+		agent.mu.Unlock()
+
+		// A finished agent no longer works, so its sandbox and browser are stopped.
+		if finished {
+			m.retire(agent)
+		}
 	}()
 
 	// Define the tools available to the agent
@@ -219,7 +331,7 @@ func (m *AgentManager) runAgent(agent *Agent) {
 		{
 			Function: openai.FunctionDefinitionParam{
 				Name:        "browse_web",
-				Description: openai.String("Navigate to a URL and return its text content. Useful for research."),
+				Description: openai.String("Navigate to a URL and return its main content as Markdown. Long pages are returned in parts; pass the cursor from the previous result to continue reading. Useful for research."),
 				Parameters: openai.FunctionParameters{
 					"type": "object",
 					"properties": map[string]interface{}{
@@ -227,6 +339,10 @@ func (m *AgentManager) runAgent(agent *Agent) {
 							"type":        "string",
 							"description": "The URL to browse.",
 						},
+						"cursor": map[string]string{
+							"type":        "integer",
+							"description": "The character offset to continue reading from, as given by a previous browse_web result. Defaults to 0, the start of the page.",
+						},
 					},
 					"required": []string{"url"},
 				},
@@ -292,22 +408,32 @@ func (m *AgentManager) runAgent(agent *Agent) {
 		},
 	}
 
+	tools = append(tools, httpRequestTool())
+	tools = append(tools, browserActionTools()...)
+	tools = append(tools, channelTools()...)
+	tools = agentTools(agent, tools)
+
+	model := openai.ChatModelGPT4o
+	if agent.Model != "" {
+		model = agent.Model
+	}
+
 	for {
 		// At the start of a cycle, absorb any messages that have been queued.
 		agent.pendingMu.Lock()
 		if len(agent.pendingMessages) > 0 {
-			agent.Messages = append(agent.Messages, agent.pendingMessages...)
+			agent.addMessages(agent.pendingMessages...)
 			agent.pendingMessages = make([]openai.ChatCompletionMessageParamUnion, 0) // Clear the queue
 		}
 		agent.pendingMu.Unlock()
 
-		agent.CurrentIteration++
+		iteration := agent.nextIteration()
 
 		// Check for shutdown signal or iteration limit before making API call
-		if agent.CurrentIteration > agent.MaxIterations {
-			agent.Status = StatusFailed
-			agent.Result = fmt.Sprintf("Task failed: Exceeded maximum of %d iterations.", agent.MaxIterations)
-			agent.Messages = append(agent.Messages, openai.UserMessage(agent.Result))
+		if iteration > agent.MaxIterations {
+			result := fmt.Sprintf("Task failed: Exceeded maximum of %d iterations.", agent.MaxIterations)
+			agent.setStatus(StatusFailed, result)
+			agent.addMessages(openai.UserMessage(result))
 			return
 		}
 
@@ -328,27 +454,31 @@ func (m *AgentManager) runAgent(agent *Agent) {
 		apiMessages = append(apiMessages, agent.Messages...)
 		// Add a final context-setting user message for the current iteration
 		apiMessages = append(apiMessages, openai.UserMessage(
-			fmt.Sprintf("You are now on iteration %d of %d. Analyze the situation and decide your next tool call.", agent.CurrentIteration, agent.MaxIterations),
+			fmt.Sprintf("You are now on iteration %d of %d. Analyze the situation and decide your next tool call.", iteration, agent.MaxIterations),
 		))
 
 		params := openai.ChatCompletionNewParams{
-			Model:       openai.ChatModelGPT4o,
+			Model:       model,
 			Messages:    apiMessages,
 			Tools:       tools,
 			Temperature: openai.Opt(agent.Temperature),
 		}
 
-		completion, err := m.openaiCli.Chat.Completions.New(context.Background(), params)
+		completion, err := m.completeWithRetry(agent, params)
+		if errors.Is(err, errAgentShutdown) {
+			return
+		}
 		if err != nil {
-			agent.Result = fmt.Sprintf("Error from LLM: %v", err)
-			agent.Messages = append(agent.Messages, openai.UserMessage(agent.Result))
-			time.Sleep(1 * time.Second) // Avoid rapid-fire errors
-			continue
+			// Retrying cannot fix this error, or transient errors persisted through every retry.
+			result := fmt.Sprintf("Error from LLM: %v", err)
+			agent.setStatus(StatusFailed, result)
+			agent.addMessages(openai.UserMessage(result))
+			return
 		}
 
 		responseMessage := completion.Choices[0].Message
-		agent.Messages = append(agent.Messages, responseMessage.ToParam())
-		agent.Result = responseMessage.Content // Store latest text response
+		agent.addMessages(responseMessage.ToParam())
+		agent.setResult(responseMessage.Content) // Store latest text response
 
 		// If there are no tool calls, the agent might be responding or asking a question.
 		// We'll wait for the next instruction.
@@ -362,22 +492,55 @@ func (m *AgentManager) runAgent(agent *Agent) {
 				continue // New messages arrived, start a new work cycle immediately.
 			}
 
-			agent.Status = StatusWaiting
+			agent.setStatus(StatusWaiting, "")
 			return // No more work, exit loop and wait for new instructions
 		}
 
-		// Handle Tool Calls
-		for _, toolCall := range responseMessage.ToolCalls {
+		// Handle Tool Calls. Consecutive independent calls run concurrently, the others one by one.
+		for _, batch := range m.toolBatches(agent, responseMessage.ToolCalls) {
+			if len(batch) > 1 {
+				m.runToolBatch(agent, batch)
+				continue
+			}
+
+			toolCall := batch[0]
 			var toolResultContent string
 			var toolErr error
 
+			if !agent.toolAllowed(toolCall.Function.Name) {
+				toolResultContent = fmt.Sprintf("Error: the tool %s is not available to this agent", toolCall.Function.Name)
+				agent.addMessages(openai.ToolMessage(toolResultContent, toolCall.ID))
+				continue
+			}
+
+			if reason := m.approvalPolicy.Reason(toolCall.Function.Name, toolCall.Function.Arguments); reason != "" {
+				decision, note := m.awaitApproval(agent, toolCall.Function.Name, toolCall.Function.Arguments, reason)
+				if decision != ApprovalApproved {
+					toolResultContent = fmt.Sprintf("Error: the action needs human approval (%s) and was not run: %s", reason, decision)
+					if note != "" {
+						toolResultContent += ": " + note
+					}
+					agent.addMessages(openai.ToolMessage(toolResultContent, toolCall.ID))
+					continue
+				}
+			}
+
 			switch toolCall.Function.Name {
 			case "complete_task":
-				agent.Status = StatusCompleted
-				agent.Result = "Task completed successfully."
+				if agent.outputSchema != nil {
+					output, err := taskOutput(toolCall.Function.Arguments, agent.outputSchema)
+					if err != nil {
+						toolErr = err
+						break
+					}
+					agent.mu.Lock()
+					agent.Output = output
+					agent.mu.Unlock()
+				}
 				toolResultContent = "Task marked as complete. Agent is shutting down."
 				// Append this final tool message before exiting
-				agent.Messages = append(agent.Messages, openai.ToolMessage(toolResultContent, toolCall.ID))
+				agent.addMessages(openai.ToolMessage(toolResultContent, toolCall.ID))
+				agent.setStatus(StatusCompleted, "Task completed successfully.")
 				return // Exit the run loop
 
 			case "set_status":
@@ -389,9 +552,9 @@ func (m *AgentManager) runAgent(agent *Agent) {
 				} else if Status(args.Status) != StatusWaiting {
 					toolErr = fmt.Errorf("invalid status '%s'. Only 'waiting_for_input' is allowed", args.Status)
 				} else {
-					agent.Status = StatusWaiting
+					agent.setStatus(StatusWaiting, "")
 					toolResultContent = "Status set to 'waiting_for_input'. Pausing execution."
-					agent.Messages = append(agent.Messages, openai.ToolMessage(toolResultContent, toolCall.ID))
+					agent.addMessages(openai.ToolMessage(toolResultContent, toolCall.ID))
 
 					// Before actually pausing, check if new work has arrived.
 					agent.pendingMu.Lock()
@@ -399,162 +562,188 @@ func (m *AgentManager) runAgent(agent *Agent) {
 					agent.pendingMu.Unlock()
 					if hasPending {
 						// New work is waiting, so don't pause. Continue to the next cycle.
-						agent.Status = StatusRunning // Set status back to running
+						agent.setStatus(StatusRunning, "") // Set status back to running
 						continue
 					}
 					return // No new work, so exit the loop.
 				}
 
-			case "browse_web":
-				var args struct {
-					URL string `json:"url"`
-				}
-				if err := json.Unmarshal([]byte(toolCall.Function.Arguments), &args); err != nil {
-					toolErr = fmt.Errorf("failed to unmarshal arguments for browse_web: %w", err)
-				} else {
-					toolResultContent, toolErr = m.browseWeb(agent.ID, args.URL)
-				}
-
-			case "list_agents":
-				agents := m.ListAgents()
-				// Filter out the current agent from the list
-				otherAgents := make([]map[string]string, 0)
-				for _, a := range agents {
-					if a.ID != agent.ID {
-						otherAgents = append(otherAgents, map[string]string{
-							"id":     a.ID,
-							"status": string(a.Status),
-							"result": a.Result,
-						})
-					}
-				}
-
-				if len(otherAgents) == 0 {
-					toolResultContent = "No other agents are currently active."
-				} else {
-					jsonResult, err := json.Marshal(otherAgents)
-					if err != nil {
-						toolErr = fmt.Errorf("failed to serialize agent list: %w", err)
-					} else {
-						toolResultContent = string(jsonResult)
-					}
-				}
-
-			case "broadcast_message":
-				var args struct {
-					Message string `json:"message"`
-				}
-				if err := json.Unmarshal([]byte(toolCall.Function.Arguments), &args); err != nil {
-					toolErr = fmt.Errorf("failed to unmarshal arguments for broadcast_message: %w", err)
-				} else {
-					m.BroadcastMessage(agent.ID, args.Message)
-					toolResultContent = "Message broadcasted to all other agents."
-				}
-
-			case "execute_command":
-				var args struct {
-					Command string `json:"command"`
-				}
-				if err := json.Unmarshal([]byte(toolCall.Function.Arguments), &args); err != nil {
-					toolErr = fmt.Errorf("failed to unmarshal arguments for execute_command: %w", err)
-				} else {
-					toolResultContent, toolErr = m.executeInContainer(agent.ID, args.Command)
-				}
-
-			case "send_message":
-				var args struct {
-					RecipientID string `json:"recipient_id"`
-					Message     string `json:"message"`
-				}
-				if err := json.Unmarshal([]byte(toolCall.Function.Arguments), &args); err != nil {
-					toolErr = fmt.Errorf("failed to unmarshal arguments for send_message: %w", err)
-				} else {
-					toolErr = m.SendMessageToAgent(agent.ID, args.RecipientID, args.Message)
-					if toolErr == nil {
-						toolResultContent = "Message sent successfully."
-					}
-				}
-
 			default:
-				toolErr = fmt.Errorf("unknown tool call: %s", toolCall.Function.Name)
+				toolResultContent, toolErr = m.runTool(agent, toolCall, false)
 			}
 
 			if toolErr != nil {
 				toolResultContent = fmt.Sprintf("Error: %v", toolErr)
 			}
 
-			agent.Messages = append(agent.Messages, openai.ToolMessage(toolResultContent, toolCall.ID))
+			agent.addMessages(openai.ToolMessage(toolResultContent, toolCall.ID))
 		}
 		// After processing tool calls, loop again to let the model process the results.
 	}
 }
 
-// browseWeb uses rod to navigate to a url and extract the main content text.
-func (m *AgentManager) browseWeb(agentID, url string) (text string, err error) {
-	// Defer a recover function to catch any panics from the rod library.
-	// This prevents the entire server from crashing on a navigation error.
-	defer func() {
-		if r := recover(); r != nil {
-			err = fmt.Errorf("recovered from panic browsing to %s: %v", url, r)
+// runTool runs a tool call other than complete_task and set_status, which control the run loop.
+// With scratchPage set, browse_web loads the page in a new page instead of the agent's persistent one.
+func (m *AgentManager) runTool(agent *Agent, toolCall openai.ChatCompletionMessageToolCall, scratchPage bool) (toolResultContent string, toolErr error) {
+	switch toolCall.Function.Name {
+	case "browse_web":
+		var args struct {
+			URL    string `json:"url"`
+			Cursor int    `json:"cursor"`
+		}
+		if err := json.Unmarshal([]byte(toolCall.Function.Arguments), &args); err != nil {
+			toolErr = fmt.Errorf("failed to unmarshal arguments for browse_web: %w", err)
+		} else {
+			toolResultContent, toolErr = m.browseWeb(agent.ID, args.URL, args.Cursor, scratchPage)
 		}
-	}()
 
-	browser, err := m.browserManager.GetBrowserForAgent(agentID)
-	if err != nil {
-		return "", fmt.Errorf("could not get browser: %w", err)
+	case "http_request":
+		var args httpRequestArgs
+		if err := json.Unmarshal([]byte(toolCall.Function.Arguments), &args); err != nil {
+			toolErr = fmt.Errorf("failed to unmarshal arguments for http_request: %w", err)
+		} else {
+			toolResultContent, toolErr = m.httpRequest(agent.ID, args)
+		}
+
+	case "list_agents":
+		agents := m.ListAgents()
+		// Filter out the current agent from the list
+		otherAgents := make([]map[string]string, 0)
+		for _, a := range agents {
+			if a.ID != agent.ID {
+				state := a.state()
+				otherAgents = append(otherAgents, map[string]string{
+					"id":     a.ID,
+					"status": string(state.Status),
+					"result": state.Result,
+				})
+			}
+		}
+
+		if len(otherAgents) == 0 {
+			toolResultContent = "No other agents are currently active."
+		} else {
+			jsonResult, err := json.Marshal(otherAgents)
+			if err != nil {
+				toolErr = fmt.Errorf("failed to serialize agent list: %w", err)
+			} else {
+				toolResultContent = string(jsonResult)
+			}
+		}
+
+	case "broadcast_message":
+		var args struct {
+			Message string `json:"message"`
+		}
+		if err := json.Unmarshal([]byte(toolCall.Function.Arguments), &args); err != nil {
+			toolErr = fmt.Errorf("failed to unmarshal arguments for broadcast_message: %w", err)
+		} else {
+			m.BroadcastMessage(agent.ID, args.Message)
+			toolResultContent = "Message broadcasted to all other agents."
+		}
+
+	case "execute_command":
+		var args struct {
+			Command string `json:"command"`
+		}
+		if err := json.Unmarshal([]byte(toolCall.Function.Arguments), &args); err != nil {
+			toolErr = fmt.Errorf("failed to unmarshal arguments for execute_command: %w", err)
+		} else {
+			toolResultContent, toolErr = m.executeInContainer(agent.ID, args.Command)
+		}
+
+	case "send_message":
+		var args struct {
+			RecipientID string `json:"recipient_id"`
+			Message     string `json:"message"`
+		}
+		if err := json.Unmarshal([]byte(toolCall.Function.Arguments), &args); err != nil {
+			toolErr = fmt.Errorf("failed to unmarshal arguments for send_message: %w", err)
+		} else {
+			toolErr = m.SendMessageToAgent(agent.ID, args.RecipientID, args.Message)
+			if toolErr == nil {
+				toolResultContent = "Message sent successfully."
+			}
+		}
+
+	default:
+		if isBrowserAction(toolCall.Function.Name) {
+			toolResultContent, toolErr = m.runBrowserAction(agent, toolCall.Function.Name, toolCall.Function.Arguments)
+		} else if isChannelAction(toolCall.Function.Name) {
+			toolResultContent, toolErr = m.runChannelAction(agent, toolCall.Function.Name, toolCall.Function.Arguments)
+		} else {
+			toolErr = fmt.Errorf("unknown tool call: %s", toolCall.Function.Name)
+		}
 	}
+	return toolResultContent, toolErr
+}
 
-	page := browser.MustPage(url)
-	defer func() { _ = page.Close() }()
+// browseWeb uses rod to navigate the agent's page to a url and returns its main content as
+// Markdown, one page of readablePageSize characters at a time. A non-zero cursor continues
+// reading the page the agent is already on, without navigating again. On a scratch page the
+// agent's persistent page is left alone.
+func (m *AgentManager) browseWeb(agentID, url string, cursor int, scratchPage bool) (string, error) {
+	var title, pageURL, content string
 
-	if err := page.WaitLoad(); err != nil {
-		return "", fmt.Errorf("failed to wait for page load: %w", err)
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	if err := m.browserManager.CheckURL(ctx, agentID, url); err != nil {
+		return "", err
+	}
+
+	withPage := m.withAgentPage
+	if scratchPage {
+		withPage = m.withScratchPage
 	}
 
-	// This JS function attempts to find the main content of the page,
-	// stripping out common noise like navs, footers, and scripts.
-	js := `() => {
-		Array.from(
-			document.querySelectorAll(
-				'nav, footer, header, aside, script, style, img, iframe, video, audio, svg, [aria-hidden="true"]'
-			)
-		).forEach(el => el.remove());
-
-		let bestElement = null;
-		let maxScore = -1;
-		const candidates = document.querySelectorAll('main, article, div');
-
-		for (const el of candidates) {
-			if (!el.textContent) continue;
-			let score = el.textContent.trim().length;
-			if (el.tagName === 'ARTICLE') score *= 1.5;
-			else if (el.tagName === 'MAIN') score *= 1.2;
-			if (score > maxScore) {
-				maxScore = score;
-				bestElement = el;
+	err := withPage(agentID, func(page *rod.Page) error {
+		info, err := page.Info()
+		if cursor == 0 || err != nil || info.URL != url {
+			if err := page.Navigate(url); err != nil {
+				return fmt.Errorf("failed to navigate to %s: %w", url, err)
+			}
+
+			if err := page.WaitLoad(); err != nil {
+				return fmt.Errorf("failed to wait for page load: %w", err)
 			}
 		}
 
-		if (bestElement) return bestElement.innerText;
-		return document.body.innerText; // Fallback
-	}`
+		document, err := page.HTML()
+		if err != nil {
+			return fmt.Errorf("failed to read page content: %w", err)
+		}
+
+		// Links are resolved against the final URL, after any redirects.
+		pageURL = url
+		if info, err := page.Info(); err == nil {
+			pageURL = info.URL
+		}
 
-	result, err := page.Evaluate(rod.Eval(js))
+		title, content, err = extractMarkdown(document, pageURL)
+		return err
+	})
 	if err != nil {
-		return "", fmt.Errorf("failed to execute JS to extract content: %w", err)
+		return "", err
 	}
 
-	textContent := result.Value.Str()
-
-	// Limit the content size to avoid overwhelming the context.
-	if len(textContent) > 8000 {
-		textContent = textContent[:8000] + "... (content truncated)"
+	chunk, next, err := paginateContent(content, cursor, readablePageSize)
+	if err != nil {
+		return "", err
 	}
 
-	return textContent, nil
+	var b strings.Builder
+	if title != "" {
+		fmt.Fprintf(&b, "# %s\n", title)
+	}
+	fmt.Fprintf(&b, "Source: %s\n\n%s", pageURL, chunk)
+	if next > 0 {
+		fmt.Fprintf(&b, "\n\n[Showing characters %d-%d of %d. Call browse_web with url %s and cursor %d to continue reading.]",
+			cursor, next, len(content), pageURL, next)
+	}
+	return b.String(), nil
 }
 
-// executeInContainer runs a command in the agent's dedicated docker container.
+// executeInContainer runs a command in the agent's dedicated sandbox.
 func (m *AgentManager) executeInContainer(agentID string, command string) (string, error) {
 	m.mu.RLock()
 	agent, exists := m.agents[agentID]
@@ -598,37 +787,47 @@ func (m *AgentManager) InstructAgent(id string, prompt string) error {
 
 	// If the agent was waiting for input, it means its run loop is not active.
 	// Wake it up by starting a new run loop, which will process the pending message.
-	if agent.Status == StatusWaiting {
+	if agent.status() == StatusWaiting {
 		go m.runAgent(agent)
 	}
 
 	return nil
 }
 
-// ShutdownAgent stops a running agent and its container.
+// ShutdownAgent stops a running agent and its sandbox. A queued agent is simply removed from the queue.
 func (m *AgentManager) ShutdownAgent(id string) error {
 	m.mu.Lock()
-	defer m.mu.Unlock()
-
 	agent, exists := m.agents[id]
 	if !exists {
+		m.mu.Unlock()
 		return fmt.Errorf("agent with ID %s not found", id)
 	}
 
-	// Stop and remove the container
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+	// Remove from the map first so the agent can no longer be reached, then tear it
+	// down without holding the lock, since stopping a container can take a while.
+	delete(m.agents, id)
+	m.removeFromQueue(agent)
+	sandbox := agent.container
+	m.mu.Unlock()
 
-	if err := agent.container.StopAndRemove(ctx); err != nil {
-		// Log or handle the error if necessary, but don't block shutdown
+	// Signal the agent's goroutine to stop
+	close(agent.shutdownChan)
+
+	// Stop and remove the sandbox
+	if sandbox != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		if err := sandbox.StopAndRemove(ctx); err != nil {
+			// Log or handle the error if necessary, but don't block shutdown
+		}
 	}
 
-	// Clean up the browser instance for the agent
+	// Clean up the browser instance and topic subscriptions for the agent
 	m.browserManager.CleanupForAgent(id)
+	m.bus.removeAgent(id)
 
-	// Signal the agent's goroutine to stop and remove from map
-	close(agent.shutdownChan)
-	delete(m.agents, id)
+	m.yieldSlot(agent)
 
 	return nil
 }
@@ -654,15 +853,7 @@ func (m *AgentManager) BroadcastMessage(senderID, message string) {
 
 	for _, recipient := range m.agents {
 		if recipient.ID != senderID {
-			// Queue the message for every other agent.
-			recipient.pendingMu.Lock()
-			recipient.pendingMessages = append(recipient.pendingMessages, openai.UserMessage(formattedMessage))
-			recipient.pendingMu.Unlock()
-
-			// If the agent was waiting for input, wake it up to process the new message.
-			if recipient.Status == StatusWaiting {
-				go m.runAgent(recipient)
-			}
+			m.deliver(recipient, formattedMessage)
 		}
 	}
 }
@@ -678,15 +869,17 @@ func (m *AgentManager) SendMessageToAgent(senderID, recipientID, message string)
 	}
 
 	// Format the message to indicate the sender and queue it.
-	formattedMessage := fmt.Sprintf("[Message from Agent %s]: %s", senderID, message)
+	m.deliver(recipient, fmt.Sprintf("[Message from Agent %s]: %s", senderID, message))
+	return nil
+}
+
+// deliver queues a message for the recipient and wakes it up if it was waiting for input.
+func (m *AgentManager) deliver(recipient *Agent, message string) {
 	recipient.pendingMu.Lock()
-	recipient.pendingMessages = append(recipient.pendingMessages, openai.UserMessage(formattedMessage))
+	recipient.pendingMessages = append(recipient.pendingMessages, openai.UserMessage(message))
 	recipient.pendingMu.Unlock()
 
-	// If the recipient was waiting, wake it up to process the message.
-	if recipient.Status == StatusWaiting {
+	if recipient.status() == StatusWaiting {
 		go m.runAgent(recipient)
 	}
-
-	return nil
 }
@@ -0,0 +1,94 @@
+package agents
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/openai/openai-go"
+)
+
+// parallelSafe reports whether a tool call only reads, and neither depends on nor changes state
+// that other tool calls in the same response could touch, such as the agent's persistent browser
+// page or its shell. Such calls may run concurrently.
+func parallelSafe(name, arguments string) bool {
+	switch name {
+	case "browse_web", "list_agents", "blackboard_get", "blackboard_list":
+		return true
+	case "http_request":
+		var args struct {
+			Method string `json:"method"`
+		}
+		if err := json.Unmarshal([]byte(arguments), &args); err != nil {
+			return false
+		}
+		switch strings.ToUpper(strings.TrimSpace(args.Method)) {
+		case "", "GET", "HEAD", "OPTIONS":
+			return true
+		}
+	}
+	return false
+}
+
+// toolBatches groups the tool calls of a response, in order. Runs of consecutive calls that are
+// parallel safe, allowed and need no approval form one batch; every other call is a batch of its
+// own, so calls that may depend on each other still run in the order the model gave them.
+func (m *AgentManager) toolBatches(agent *Agent, calls []openai.ChatCompletionMessageToolCall) [][]openai.ChatCompletionMessageToolCall {
+	var batches [][]openai.ChatCompletionMessageToolCall
+	var current []openai.ChatCompletionMessageToolCall
+
+	for _, call := range calls {
+		name, arguments := call.Function.Name, call.Function.Arguments
+		if agent.MaxParallelTools > 1 && agent.toolAllowed(name) && parallelSafe(name, arguments) && m.approvalPolicy.Reason(name, arguments) == "" {
+			current = append(current, call)
+			continue
+		}
+		if len(current) > 0 {
+			batches = append(batches, current)
+			current = nil
+		}
+		batches = append(batches, []openai.ChatCompletionMessageToolCall{call})
+	}
+	if len(current) > 0 {
+		batches = append(batches, current)
+	}
+	return batches
+}
+
+// runToolBatch runs the calls concurrently, at most agent.MaxParallelTools at a time, and appends
+// their results to the agent's messages in the original order. The last browse_web call uses the
+// agent's persistent page and the others scratch pages, so the agent ends up on the same page as
+// if the calls had run one by one.
+func (m *AgentManager) runToolBatch(agent *Agent, batch []openai.ChatCompletionMessageToolCall) {
+	lastBrowse := -1
+	for i, call := range batch {
+		if call.Function.Name == "browse_web" {
+			lastBrowse = i
+		}
+	}
+
+	results := make([]string, len(batch))
+	slots := make(chan struct{}, agent.MaxParallelTools)
+	var wg sync.WaitGroup
+
+	for i, call := range batch {
+		wg.Add(1)
+		go func(i int, call openai.ChatCompletionMessageToolCall) {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+
+			content, err := m.runTool(agent, call, call.Function.Name == "browse_web" && i != lastBrowse)
+			if err != nil {
+				content = fmt.Sprintf("Error: %v", err)
+			}
+			results[i] = content
+		}(i, call)
+	}
+	wg.Wait()
+
+	for i, call := range batch {
+		agent.addMessages(openai.ToolMessage(results[i], call.ID))
+	}
+}
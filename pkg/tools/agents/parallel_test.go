@@ -0,0 +1,87 @@
+package agents
+
+import (
+	"testing"
+
+	"github.com/openai/openai-go"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestParallelSafe(t *testing.T) {
+	Convey("Given tool calls", t, func() {
+		Convey("It should allow reads to run concurrently", func() {
+			So(parallelSafe("browse_web", `{"url":"https://example.com"}`), ShouldBeTrue)
+			So(parallelSafe("blackboard_get", `{"key":"plan"}`), ShouldBeTrue)
+			So(parallelSafe("http_request", `{"url":"https://example.com"}`), ShouldBeTrue)
+			So(parallelSafe("http_request", `{"method":" head ","url":"https://example.com"}`), ShouldBeTrue)
+		})
+
+		Convey("It should run writes and stateful calls one by one", func() {
+			So(parallelSafe("http_request", `{"method":"POST","url":"https://example.com"}`), ShouldBeFalse)
+			So(parallelSafe("http_request", `not json`), ShouldBeFalse)
+			So(parallelSafe("execute_command", `{"command":"ls"}`), ShouldBeFalse)
+			So(parallelSafe("browser_click", `{"selector":"a"}`), ShouldBeFalse)
+			So(parallelSafe("blackboard_set", `{"key":"plan"}`), ShouldBeFalse)
+		})
+	})
+}
+
+func TestToolBatches(t *testing.T) {
+	Convey("Given a response with several tool calls", t, func() {
+		call := func(id, name, arguments string) openai.ChatCompletionMessageToolCall {
+			return openai.ChatCompletionMessageToolCall{ID: id, Function: openai.ChatCompletionMessageToolCallFunction{Name: name, Arguments: arguments}}
+		}
+		calls := []openai.ChatCompletionMessageToolCall{
+			call("1", "browse_web", `{"url":"https://a.example"}`),
+			call("2", "http_request", `{"url":"https://b.example"}`),
+			call("3", "execute_command", `{"command":"ls"}`),
+			call("4", "browse_web", `{"url":"https://c.example"}`),
+			call("5", "http_request", `{"method":"DELETE","url":"https://d.example"}`),
+			call("6", "blackboard_get", `{"key":"plan"}`),
+			call("7", "blackboard_list", `{}`),
+		}
+		ids := func(batches [][]openai.ChatCompletionMessageToolCall) [][]string {
+			var result [][]string
+			for _, batch := range batches {
+				var batchIDs []string
+				for _, c := range batch {
+					batchIDs = append(batchIDs, c.ID)
+				}
+				result = append(result, batchIDs)
+			}
+			return result
+		}
+
+		Convey("It should group consecutive parallel safe calls, in order", func() {
+			m := &AgentManager{approvalPolicy: &ApprovalPolicy{}}
+
+			batches := m.toolBatches(&Agent{MaxParallelTools: 4}, calls)
+
+			So(ids(batches), ShouldResemble, [][]string{{"1", "2"}, {"3"}, {"4"}, {"5"}, {"6", "7"}})
+		})
+
+		Convey("It should run every call on its own when the agent may not run tools in parallel", func() {
+			m := &AgentManager{approvalPolicy: &ApprovalPolicy{}}
+
+			batches := m.toolBatches(&Agent{MaxParallelTools: 1}, calls)
+
+			So(batches, ShouldHaveLength, len(calls))
+		})
+
+		Convey("It should run calls that need approval on their own", func() {
+			m := &AgentManager{approvalPolicy: &ApprovalPolicy{Tools: []string{"blackboard_list"}}}
+
+			batches := m.toolBatches(&Agent{MaxParallelTools: 4}, calls[5:])
+
+			So(ids(batches), ShouldResemble, [][]string{{"6"}, {"7"}})
+		})
+
+		Convey("It should run calls the agent may not use on their own, so they are refused in order", func() {
+			m := &AgentManager{approvalPolicy: &ApprovalPolicy{}}
+
+			batches := m.toolBatches(&Agent{MaxParallelTools: 4, allowedTools: []string{"browse_web"}}, calls[:2])
+
+			So(ids(batches), ShouldResemble, [][]string{{"1"}, {"2"}})
+		})
+	})
+}
@@ -0,0 +1,143 @@
+package agents
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/openai/openai-go"
+	"github.com/theapemachine/mcp-server-devops-bridge/core/container"
+)
+
+// admit registers a new agent and starts it if a slot is free, otherwise it is put at the
+// back of the launch queue. The sandbox is created without holding the manager lock, so a
+// slow image pull does not block every other agent operation.
+func (m *AgentManager) admit(agent *Agent) error {
+	m.mu.Lock()
+	m.agents[agent.ID] = agent
+	if m.maxAgents > 0 && m.activeAgents >= m.maxAgents {
+		agent.setStatus(StatusQueued, "")
+		m.launchQueue = append(m.launchQueue, agent)
+		m.mu.Unlock()
+		return nil
+	}
+	m.activeAgents++
+	agent.hasSlot = true
+	m.mu.Unlock()
+
+	if err := m.startSandbox(agent); err != nil {
+		m.mu.Lock()
+		delete(m.agents, agent.ID)
+		m.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// startSandbox creates and starts the agent's sandbox, then starts its execution loop if requested.
+// On failure the agent's slot is released.
+func (m *AgentManager) startSandbox(agent *Agent) error {
+	ctx := context.Background()
+
+	sandbox, err := container.NewSandbox(m.runtime, agent.image, container.AgentLabels(agent.ID))
+	if err == nil {
+		// Start the sandbox and keep it running
+		err = sandbox.Run(ctx, []string{"tail", "-f", "/dev/null"}, nil)
+		if err != nil {
+			_ = sandbox.StopAndRemove(ctx)
+		}
+	}
+	if err != nil {
+		m.yieldSlot(agent)
+		return fmt.Errorf("failed to start %s sandbox: %w", m.runtime, err)
+	}
+
+	m.mu.Lock()
+	if _, exists := m.agents[agent.ID]; !exists {
+		// The agent was shut down while its sandbox was being created.
+		m.mu.Unlock()
+		_ = sandbox.StopAndRemove(ctx)
+		return fmt.Errorf("agent %s was shut down during startup", agent.ID)
+	}
+	agent.container = sandbox
+	m.mu.Unlock()
+
+	if agent.runOnStart {
+		go m.runAgent(agent)
+	} else {
+		agent.setStatus(StatusWaiting, "")
+	}
+	return nil
+}
+
+// yieldSlot releases the agent's slot, if it holds one. It is safe to call more than once. Callers
+// remove the agent's sandbox first, so the slots bound the number of live sandboxes.
+func (m *AgentManager) yieldSlot(agent *Agent) {
+	m.mu.Lock()
+	hadSlot := agent.hasSlot
+	agent.hasSlot = false
+	m.mu.Unlock()
+
+	if hadSlot {
+		m.releaseSlot()
+	}
+}
+
+// releaseSlot frees a concurrent agent slot and hands it to the next queued agent, if any.
+func (m *AgentManager) releaseSlot() {
+	m.mu.Lock()
+	m.activeAgents--
+
+	var next *Agent
+	if len(m.launchQueue) > 0 {
+		next = m.launchQueue[0]
+		m.launchQueue = m.launchQueue[1:]
+		m.activeAgents++
+		next.hasSlot = true
+		next.setStatus(StatusInitializing, "")
+	}
+	m.mu.Unlock()
+
+	if next != nil {
+		go func() {
+			if err := m.startSandbox(next); err != nil {
+				next.setStatus(StatusFailed, fmt.Sprintf("Failed to start queued agent: %v", err))
+			}
+		}()
+	}
+}
+
+// removeFromQueue drops the agent from the launch queue. The caller must hold m.mu.
+func (m *AgentManager) removeFromQueue(agent *Agent) {
+	for i, queued := range m.launchQueue {
+		if queued == agent {
+			m.launchQueue = append(m.launchQueue[:i], m.launchQueue[i+1:]...)
+			return
+		}
+	}
+}
+
+// QueuePosition returns the 1-based position of the agent in the launch queue, or 0 if it is not queued.
+func (m *AgentManager) QueuePosition(id string) int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for i, queued := range m.launchQueue {
+		if queued.ID == id {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// chatCompletion calls the LLM, waiting for a free slot when concurrent calls are limited.
+func (m *AgentManager) chatCompletion(ctx context.Context, params openai.ChatCompletionNewParams) (*openai.ChatCompletion, error) {
+	if m.llmSlots != nil {
+		select {
+		case m.llmSlots <- struct{}{}:
+			defer func() { <-m.llmSlots }()
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	return m.openaiCli.Chat.Completions.New(ctx, params)
+}
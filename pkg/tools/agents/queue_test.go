@@ -0,0 +1,99 @@
+package agents
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/theapemachine/mcp-server-devops-bridge/core/container"
+)
+
+func TestLaunchQueue(t *testing.T) {
+	Convey("Given a manager with a single agent slot", t, func() {
+		m := &AgentManager{agents: make(map[string]*Agent), runtime: container.RuntimeLocal, maxAgents: 1}
+		newAgent := func(id string) *Agent {
+			return &Agent{ID: id, taskChan: make(chan string), shutdownChan: make(chan struct{})}
+		}
+		first, second, third := newAgent("first"), newAgent("second"), newAgent("third")
+		defer func() {
+			for _, agent := range []*Agent{first, second, third} {
+				if agent.container != nil {
+					_ = agent.container.StopAndRemove(context.Background())
+				}
+			}
+		}()
+
+		So(m.admit(first), ShouldBeNil)
+		So(m.admit(second), ShouldBeNil)
+		So(m.admit(third), ShouldBeNil)
+
+		Convey("The first agent starts and the others queue in launch order", func() {
+			So(first.status(), ShouldEqual, StatusWaiting)
+			So(second.status(), ShouldEqual, StatusQueued)
+			So(m.QueuePosition("first"), ShouldEqual, 0)
+			So(m.QueuePosition("second"), ShouldEqual, 1)
+			So(m.QueuePosition("third"), ShouldEqual, 2)
+		})
+
+		Convey("A finished agent hands its slot to the next queued agent", func() {
+			first.setStatus(StatusCompleted, "done")
+			m.yieldSlot(first)
+			m.yieldSlot(first) // Releasing twice must not free a second slot
+
+			deadline := time.Now().Add(5 * time.Second)
+			for second.status() != StatusWaiting && time.Now().Before(deadline) {
+				time.Sleep(10 * time.Millisecond)
+			}
+
+			So(second.status(), ShouldEqual, StatusWaiting)
+			So(m.QueuePosition("third"), ShouldEqual, 1)
+			So(third.status(), ShouldEqual, StatusQueued)
+		})
+	})
+}
+
+// stoppingSandbox is a sandbox that runs a hook when it is stopped.
+type stoppingSandbox struct {
+	container.Sandbox
+	onStop func()
+}
+
+func (s *stoppingSandbox) StopAndRemove(ctx context.Context) error {
+	s.onStop()
+	return s.Sandbox.StopAndRemove(ctx)
+}
+
+func TestRetireReleasesSlotAfterSandbox(t *testing.T) {
+	Convey("Given a running agent with another one queued behind it", t, func() {
+		m := &AgentManager{agents: make(map[string]*Agent), runtime: container.RuntimeLocal, maxAgents: 1, browserManager: &BrowserManager{}}
+		first := &Agent{ID: "first", taskChan: make(chan string), shutdownChan: make(chan struct{})}
+		second := &Agent{ID: "second", taskChan: make(chan string), shutdownChan: make(chan struct{})}
+		So(m.admit(first), ShouldBeNil)
+		So(m.admit(second), ShouldBeNil)
+		defer func() {
+			m.mu.RLock()
+			sandbox := second.container
+			m.mu.RUnlock()
+			if sandbox != nil {
+				_ = sandbox.StopAndRemove(context.Background())
+			}
+		}()
+
+		var queuedWhileStopping int
+		first.container = &stoppingSandbox{Sandbox: first.container, onStop: func() { queuedWhileStopping = m.QueuePosition("second") }}
+
+		Convey("The queued agent only starts once the finished agent's sandbox is removed", func() {
+			first.setStatus(StatusCompleted, "done")
+			m.retire(first)
+
+			So(queuedWhileStopping, ShouldEqual, 1)
+
+			deadline := time.Now().Add(5 * time.Second)
+			for second.status() != StatusWaiting && time.Now().Before(deadline) {
+				time.Sleep(10 * time.Millisecond)
+			}
+			So(second.status(), ShouldEqual, StatusWaiting)
+		})
+	})
+}
@@ -0,0 +1,162 @@
+package agents
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/theapemachine/mcp-server-devops-bridge/pkg/tools/htmlmd"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// readablePageSize is the number of characters of extracted content returned per browse_web call.
+const readablePageSize = 8000
+
+// noiseElements never contribute readable content.
+var noiseElements = map[atom.Atom]bool{
+	atom.Script: true, atom.Style: true, atom.Noscript: true, atom.Template: true,
+	atom.Svg: true, atom.Iframe: true, atom.Object: true, atom.Embed: true,
+	atom.Video: true, atom.Audio: true, atom.Canvas: true, atom.Button: true,
+	atom.Nav: true, atom.Aside: true, atom.Footer: true, atom.Head: true,
+	atom.Input: true, atom.Select: true, atom.Textarea: true, atom.Dialog: true,
+}
+
+// extractMarkdown converts an HTML document into Markdown, keeping only its main content.
+// Headings, code blocks, lists, tables and links are preserved; relative links are
+// resolved against pageURL. It returns the document title and the Markdown body.
+func extractMarkdown(document, pageURL string) (string, string, error) {
+	root, err := html.Parse(strings.NewReader(document))
+	if err != nil {
+		return "", "", fmt.Errorf("failed to parse HTML: %w", err)
+	}
+
+	base, _ := url.Parse(pageURL)
+
+	title := ""
+	if node := htmlmd.FindFirst(root, atom.Title); node != nil {
+		title = htmlmd.CollapseSpace(htmlmd.TextContent(node))
+	}
+
+	content := htmlmd.Convert(contentRoot(root), htmlmd.Options{
+		Base: base,
+		Skip: isNoise,
+		Inline: func(n *html.Node, _ string) (string, bool) {
+			// Images without alternative text are decoration.
+			return "", n.DataAtom == atom.Img && htmlmd.CollapseSpace(htmlmd.Attr(n, "alt")) == ""
+		},
+	})
+
+	if title == "" {
+		if h1 := htmlmd.FindFirst(root, atom.H1); h1 != nil {
+			title = htmlmd.CollapseSpace(htmlmd.TextContent(h1))
+		}
+	}
+	return title, content, nil
+}
+
+// paginateContent returns the part of content starting at cursor that fits in size characters,
+// ending on a paragraph or line break where possible. next is the cursor of the following
+// part, or 0 when the end of the content was reached.
+func paginateContent(content string, cursor, size int) (string, int, error) {
+	if cursor < 0 || (cursor > 0 && cursor >= len(content)) {
+		return "", 0, fmt.Errorf("cursor %d is outside the content, which is %d characters long", cursor, len(content))
+	}
+
+	end := cursor + size
+	if end >= len(content) {
+		return content[cursor:], 0, nil
+	}
+
+	// Prefer breaking between paragraphs, then between lines, as long as at least half a page is kept.
+	chunk := content[cursor:end]
+	if i := strings.LastIndex(chunk, "\n\n"); i > size/2 {
+		end = cursor + i + 2
+	} else if i := strings.LastIndex(chunk, "\n"); i > size/2 {
+		end = cursor + i + 1
+	} else {
+		for end > cursor && !utf8.RuneStart(content[end]) {
+			end--
+		}
+	}
+	return content[cursor:end], end, nil
+}
+
+// contentRoot picks the node holding the main content of the page: a single article, the
+// main landmark, or otherwise the element whose paragraphs contain the most text.
+func contentRoot(root *html.Node) *html.Node {
+	if articles := htmlmd.FindAll(root, atom.Article); len(articles) == 1 {
+		return articles[0]
+	}
+	if main := htmlmd.FindFirst(root, atom.Main); main != nil {
+		return main
+	}
+
+	var roleMain *html.Node
+	var candidates []*html.Node
+	scores := make(map[*html.Node]int)
+	credit := func(n *html.Node, score int) {
+		if _, seen := scores[n]; !seen {
+			candidates = append(candidates, n)
+		}
+		scores[n] += score
+	}
+	htmlmd.Walk(root, func(n *html.Node) bool {
+		if n.Type != html.ElementNode {
+			return true
+		}
+		if noiseElements[n.DataAtom] {
+			return false
+		}
+		if roleMain == nil && htmlmd.Attr(n, "role") == "main" {
+			roleMain = n
+		}
+		switch n.DataAtom {
+		case atom.P, atom.Pre, atom.Table, atom.Ul, atom.Ol:
+			score := len(htmlmd.CollapseSpace(htmlmd.TextContent(n)))
+			if parent := n.Parent; parent != nil {
+				credit(parent, score)
+				if grandparent := parent.Parent; grandparent != nil {
+					credit(grandparent, score/2)
+				}
+			}
+			return false
+		}
+		return true
+	})
+	if roleMain != nil {
+		return roleMain
+	}
+
+	var best *html.Node
+	for _, node := range candidates {
+		if best == nil || scores[node] > scores[best] {
+			best = node
+		}
+	}
+	if best != nil {
+		return best
+	}
+	if body := htmlmd.FindFirst(root, atom.Body); body != nil {
+		return body
+	}
+	return root
+}
+
+// isNoise reports whether an element should be dropped from the output. Page-level headers
+// are noise, but headers inside an article or main element usually hold its title.
+func isNoise(n *html.Node) bool {
+	if noiseElements[n.DataAtom] || htmlmd.Attr(n, "aria-hidden") == "true" || htmlmd.HasAttr(n, "hidden") {
+		return true
+	}
+	if n.DataAtom == atom.Header {
+		for p := n.Parent; p != nil; p = p.Parent {
+			if p.DataAtom == atom.Article || p.DataAtom == atom.Main {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
@@ -0,0 +1,80 @@
+package agents
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+const testDocument = `<html>
+<head><title>Widget Guide</title><script>var tracking = true;</script></head>
+<body>
+	<header><a href="/">Home</a> <a href="/docs">Docs</a></header>
+	<nav><ul><li><a href="/a">Sidebar link</a></li></ul></nav>
+	<main>
+		<h1>Installing widgets</h1>
+		<p>Read the <a href="/docs/setup">setup guide</a> <strong>first</strong>.</p>
+		<pre><code class="language-go">func main() {
+	fmt.Println("hi")
+}</code></pre>
+		<ul>
+			<li>One</li>
+			<li>Two
+				<ol><li>Nested</li></ol>
+			</li>
+		</ul>
+		<table>
+			<tr><th>Name</th><th>Size</th></tr>
+			<tr><td>Small | light</td><td>1</td></tr>
+		</table>
+	</main>
+	<footer>Copyright</footer>
+</body>
+</html>`
+
+func TestExtractMarkdown(t *testing.T) {
+	Convey("Given an HTML document with page chrome around its main content", t, func() {
+		title, content, err := extractMarkdown(testDocument, "https://example.com/guide/widgets")
+
+		Convey("Then the title and main content are converted to Markdown", func() {
+			So(err, ShouldBeNil)
+			So(title, ShouldEqual, "Widget Guide")
+			So(content, ShouldStartWith, "# Installing widgets")
+			So(content, ShouldContainSubstring, "Read the [setup guide](https://example.com/docs/setup) **first**.")
+			So(content, ShouldContainSubstring, "```go\nfunc main() {\n\tfmt.Println(\"hi\")\n}\n```")
+			So(content, ShouldContainSubstring, "- One\n- Two\n  1. Nested")
+			So(content, ShouldContainSubstring, "| Name | Size |\n| --- | --- |\n| Small \\| light | 1 |")
+		})
+
+		Convey("Then navigation, footers and scripts are left out", func() {
+			So(content, ShouldNotContainSubstring, "Sidebar link")
+			So(content, ShouldNotContainSubstring, "Copyright")
+			So(content, ShouldNotContainSubstring, "tracking")
+			So(content, ShouldNotContainSubstring, "Home")
+		})
+	})
+}
+
+func TestPaginateContent(t *testing.T) {
+	Convey("Given content longer than one page", t, func() {
+		content := strings.Repeat("a", 60) + "\n\n" + strings.Repeat("b", 60)
+
+		Convey("Then pages break between paragraphs and the cursor walks to the end", func() {
+			first, next, err := paginateContent(content, 0, 100)
+			So(err, ShouldBeNil)
+			So(first, ShouldEqual, strings.Repeat("a", 60)+"\n\n")
+			So(next, ShouldEqual, 62)
+
+			second, next, err := paginateContent(content, next, 100)
+			So(err, ShouldBeNil)
+			So(second, ShouldEqual, strings.Repeat("b", 60))
+			So(next, ShouldEqual, 0)
+		})
+
+		Convey("Then a cursor past the end is rejected", func() {
+			_, _, err := paginateContent(content, len(content), 100)
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
@@ -0,0 +1,212 @@
+package agents
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/openai/openai-go"
+	"github.com/theapemachine/mcp-server-devops-bridge/core/container"
+)
+
+// snapshotRepository is the image repository agent snapshots are committed to.
+const snapshotRepository = "mcp-agent-snapshot"
+
+var invalidTagChars = regexp.MustCompile(`[^a-zA-Z0-9_.-]+`)
+
+// Snapshot is a checkpoint of an agent: the committed state of its sandbox plus a copy
+// of its conversation, from which new agents can be forked.
+type Snapshot struct {
+	ID               string                                   `json:"id"`
+	AgentID          string                                   `json:"agent_id"`
+	Image            string                                   `json:"image"`
+	Runtime          container.Runtime                        `json:"runtime"`
+	SystemPrompt     string                                   `json:"-"`
+	Messages         []openai.ChatCompletionMessageParamUnion `json:"-"`
+	Temperature      float64                                  `json:"temperature"`
+	MaxIterations    int                                      `json:"max_iterations"`
+	Iteration        int                                      `json:"iteration"`
+	MaxParallelTools int                                      `json:"max_parallel_tools"`
+	Model            string                                   `json:"model,omitempty"`
+	Template         string                                   `json:"template,omitempty"`
+	AllowedTools     []string                                 `json:"allowed_tools,omitempty"`
+	OutputSchema     map[string]interface{}                   `json:"-"`
+	URLPolicy        *URLPolicy                               `json:"url_policy,omitempty"`
+	CreatedAt        time.Time                                `json:"created_at"`
+}
+
+// SnapshotAgent commits the agent's sandbox to a tagged image and stores a copy of its messages.
+func (m *AgentManager) SnapshotAgent(ctx context.Context, agentID, tag string) (*Snapshot, error) {
+	m.mu.RLock()
+	agent, exists := m.agents[agentID]
+	var sandbox container.Sandbox
+	if exists {
+		sandbox = agent.container
+	}
+	m.mu.RUnlock()
+	if !exists {
+		return nil, fmt.Errorf("agent with ID %s not found", agentID)
+	}
+
+	// Queued agents have no sandbox yet, and finished agents no longer have one.
+	if sandbox == nil {
+		return nil, fmt.Errorf("agent %s has no sandbox (status: %s)", agentID, agent.status())
+	}
+
+	snapshotter, ok := sandbox.(container.Snapshotter)
+	if !ok {
+		return nil, fmt.Errorf("the %s sandbox runtime does not support snapshots", m.runtime)
+	}
+
+	if tag == "" {
+		tag = fmt.Sprintf("%s-%d", agentID[:8], time.Now().Unix())
+	}
+	tag = strings.Trim(invalidTagChars.ReplaceAllString(tag, "-"), "-.")
+	if tag == "" {
+		return nil, fmt.Errorf("snapshot tag must contain at least one alphanumeric character")
+	}
+
+	m.mu.RLock()
+	_, taken := m.snapshots[tag]
+	m.mu.RUnlock()
+	if taken {
+		return nil, fmt.Errorf("a snapshot with tag %s already exists", tag)
+	}
+
+	// The conversation is copied before the sandbox is committed, so the snapshot never holds
+	// messages about changes the committed filesystem does not have yet.
+	state := agent.state()
+
+	image, err := snapshotter.Snapshot(ctx, snapshotRepository+":"+tag)
+	if err != nil {
+		return nil, fmt.Errorf("failed to snapshot sandbox of agent %s: %w", agentID, err)
+	}
+
+	snapshot := &Snapshot{
+		ID:               tag,
+		AgentID:          agentID,
+		Image:            image,
+		Runtime:          m.runtime,
+		SystemPrompt:     agent.SystemPrompt,
+		Messages:         completeToolExchanges(state.Messages),
+		Temperature:      agent.Temperature,
+		MaxIterations:    agent.MaxIterations,
+		Iteration:        state.Iteration,
+		MaxParallelTools: agent.MaxParallelTools,
+		Model:            agent.Model,
+		Template:         agent.Template,
+		AllowedTools:     agent.allowedTools,
+		OutputSchema:     agent.outputSchema,
+		URLPolicy:        m.browserManager.Policy(agentID),
+		CreatedAt:        time.Now(),
+	}
+
+	m.mu.Lock()
+	m.snapshots[snapshot.ID] = snapshot
+	m.mu.Unlock()
+
+	return snapshot, nil
+}
+
+// DeleteSnapshot removes a snapshot and the image or directory holding its sandbox state.
+// Agents already forked from it keep running.
+func (m *AgentManager) DeleteSnapshot(ctx context.Context, snapshotID string) error {
+	m.mu.Lock()
+	snapshot, exists := m.snapshots[snapshotID]
+	delete(m.snapshots, snapshotID)
+	m.mu.Unlock()
+	if !exists {
+		return fmt.Errorf("snapshot %s not found", snapshotID)
+	}
+
+	if err := container.RemoveSnapshot(ctx, snapshot.Runtime, snapshot.Image); err != nil {
+		return fmt.Errorf("snapshot %s was forgotten, but its image %s could not be removed: %w", snapshotID, snapshot.Image, err)
+	}
+	return nil
+}
+
+// ForkAgent launches a new agent from a snapshot. The new agent starts with the snapshot's
+// sandbox state and conversation. If a prompt is given it is queued and the agent starts
+// working immediately, otherwise it waits for instructions.
+func (m *AgentManager) ForkAgent(snapshotID, prompt string, maxIterations int) (*Agent, error) {
+	m.mu.RLock()
+	snapshot, exists := m.snapshots[snapshotID]
+	m.mu.RUnlock()
+	if !exists {
+		return nil, fmt.Errorf("snapshot %s not found", snapshotID)
+	}
+
+	if maxIterations <= 0 {
+		maxIterations = snapshot.MaxIterations
+	}
+
+	messages := make([]openai.ChatCompletionMessageParamUnion, len(snapshot.Messages))
+	copy(messages, snapshot.Messages)
+
+	agent := &Agent{
+		ID:               uuid.New().String(),
+		Status:           StatusInitializing,
+		SystemPrompt:     snapshot.SystemPrompt,
+		Messages:         messages,
+		Result:           fmt.Sprintf("Forked from snapshot %s of agent %s.", snapshot.ID, snapshot.AgentID),
+		Temperature:      snapshot.Temperature,
+		MaxIterations:    maxIterations,
+		MaxParallelTools: snapshot.MaxParallelTools,
+		Model:            snapshot.Model,
+		Template:         snapshot.Template,
+		LastActive:       time.Now(),
+		image:            snapshot.Image,
+		allowedTools:     snapshot.AllowedTools,
+		outputSchema:     snapshot.OutputSchema,
+		runOnStart:       prompt != "",
+		pendingMessages:  make([]openai.ChatCompletionMessageParamUnion, 0),
+		taskChan:         make(chan string),
+		shutdownChan:     make(chan struct{}),
+	}
+
+	if prompt != "" {
+		agent.pendingMessages = append(agent.pendingMessages, openai.UserMessage(prompt))
+	}
+
+	if snapshot.URLPolicy != nil {
+		m.browserManager.SetPolicy(agent.ID, snapshot.URLPolicy)
+	}
+
+	if err := m.admit(agent); err != nil {
+		m.browserManager.CleanupForAgent(agent.ID)
+		return nil, err
+	}
+	return agent, nil
+}
+
+// completeToolExchanges copies the messages, dropping a trailing assistant message whose tool
+// calls have not all been answered yet. A snapshot taken mid-iteration would otherwise produce
+// a conversation the model API rejects when the fork resumes.
+func completeToolExchanges(messages []openai.ChatCompletionMessageParamUnion) []openai.ChatCompletionMessageParamUnion {
+	end := len(messages)
+
+	for i := len(messages) - 1; i >= 0; i-- {
+		assistant := messages[i].OfAssistant
+		if assistant == nil || len(assistant.ToolCalls) == 0 {
+			continue
+		}
+
+		answered := 0
+		for _, msg := range messages[i+1:] {
+			if msg.OfTool != nil {
+				answered++
+			}
+		}
+		if answered < len(assistant.ToolCalls) {
+			end = i
+		}
+		break
+	}
+
+	result := make([]openai.ChatCompletionMessageParamUnion, end)
+	copy(result, messages[:end])
+	return result
+}
@@ -0,0 +1,73 @@
+package agents
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/openai/openai-go"
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/theapemachine/mcp-server-devops-bridge/core/container"
+)
+
+func TestCompleteToolExchanges(t *testing.T) {
+	Convey("Given a conversation", t, func() {
+		call := func(id string) openai.ChatCompletionMessageToolCallParam {
+			return openai.ChatCompletionMessageToolCallParam{ID: id, Function: openai.ChatCompletionMessageToolCallFunctionParam{Name: "execute_command"}}
+		}
+		assistant := openai.ChatCompletionMessageParamUnion{OfAssistant: &openai.ChatCompletionAssistantMessageParam{
+			ToolCalls: []openai.ChatCompletionMessageToolCallParam{call("a"), call("b")},
+		}}
+		messages := []openai.ChatCompletionMessageParamUnion{openai.UserMessage("task"), assistant, openai.ToolMessage("ok", "a")}
+
+		Convey("A trailing tool call without all its answers is dropped", func() {
+			So(completeToolExchanges(messages), ShouldHaveLength, 1)
+		})
+
+		Convey("A fully answered tool call is kept", func() {
+			complete := append(messages, openai.ToolMessage("ok", "b"))
+			So(completeToolExchanges(complete), ShouldHaveLength, 4)
+		})
+	})
+}
+
+func TestAgentState(t *testing.T) {
+	Convey("The state of an agent is a copy", t, func() {
+		agent := &Agent{Status: StatusRunning, Messages: []openai.ChatCompletionMessageParamUnion{openai.UserMessage("task")}, CurrentIteration: 3}
+
+		state := agent.state()
+		agent.addMessages(openai.UserMessage("more"))
+		agent.setStatus(StatusCompleted, "done")
+
+		So(state.Messages, ShouldHaveLength, 1)
+		So(state.Iteration, ShouldEqual, 3)
+		So(state.Status, ShouldEqual, StatusRunning)
+		So(agent.state().Result, ShouldEqual, "done")
+	})
+}
+
+func TestDeleteSnapshot(t *testing.T) {
+	Convey("Given a snapshot of a local sandbox", t, func() {
+		ctx := context.Background()
+		sandbox := container.NewLocalSandbox()
+		So(sandbox.Run(ctx, nil, nil), ShouldBeNil)
+		defer sandbox.StopAndRemove(ctx)
+
+		dir, err := sandbox.Snapshot(ctx, "test")
+		So(err, ShouldBeNil)
+
+		m := &AgentManager{snapshots: map[string]*Snapshot{"test": {ID: "test", Image: dir, Runtime: container.RuntimeLocal}}}
+
+		Convey("Deleting it removes the snapshot directory", func() {
+			So(m.DeleteSnapshot(ctx, "test"), ShouldBeNil)
+			_, err := os.Stat(dir)
+			So(os.IsNotExist(err), ShouldBeTrue)
+			So(m.snapshots, ShouldBeEmpty)
+		})
+
+		Convey("An unknown snapshot cannot be deleted", func() {
+			So(m.DeleteSnapshot(ctx, "other"), ShouldNotBeNil)
+			So(os.RemoveAll(dir), ShouldBeNil)
+		})
+	})
+}
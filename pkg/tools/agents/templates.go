@@ -0,0 +1,302 @@
+package agents
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/openai/openai-go"
+	"gopkg.in/yaml.v3"
+)
+
+// agentToolNames lists every tool an agent can be given, for validating template tool lists.
+var agentToolNames = []string{
+	"complete_task", "set_status", "browse_web", "http_request", "list_agents",
+	"broadcast_message", "execute_command", "send_message",
+	"browser_click", "browser_fill", "browser_submit", "browser_scroll", "browser_wait_for",
+	"browser_extract_links", "browser_extract_tables", "browser_screenshot",
+	"subscribe_topic", "unsubscribe_topic", "publish_topic", "request_agent", "reply_to_request",
+	"blackboard_get", "blackboard_set", "blackboard_cas", "blackboard_list",
+}
+
+// alwaysAllowedTools are available to every agent, whatever its template allows, since
+// without them an agent could never finish or pause.
+var alwaysAllowedTools = []string{"complete_task", "set_status"}
+
+// AgentTemplate is a named agent configuration loaded from a YAML file in the template directory.
+type AgentTemplate struct {
+	Name             string                 `yaml:"name" json:"name"`
+	Description      string                 `yaml:"description" json:"description,omitempty"`
+	SystemPrompt     string                 `yaml:"system_prompt" json:"system_prompt"`
+	Model            string                 `yaml:"model" json:"model,omitempty"`
+	Temperature      *float64               `yaml:"temperature" json:"temperature,omitempty"`
+	MaxIterations    int                    `yaml:"max_iterations" json:"max_iterations,omitempty"`
+	Image            string                 `yaml:"image" json:"image,omitempty"`
+	Tools            []string               `yaml:"tools" json:"tools,omitempty"`
+	MaxParallelTools int                    `yaml:"max_parallel_tools" json:"max_parallel_tools,omitempty"`
+	OutputSchema     map[string]interface{} `yaml:"output_schema" json:"output_schema,omitempty"`
+	File             string                 `yaml:"-" json:"file"`
+}
+
+// LoadTemplates reads every *.yaml and *.yml template in dir, sorted by name. Templates are
+// read on every call, so edits take effect without a restart. Files that fail to parse or
+// validate, and templates sharing a name, are skipped and returned as invalid, so one bad
+// file does not hide the others.
+func LoadTemplates(dir string) (templates []*AgentTemplate, invalid []error, err error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil, fmt.Errorf("agent template directory %s does not exist", dir)
+		}
+		return nil, nil, err
+	}
+
+	byName := make(map[string][]*AgentTemplate)
+	for _, entry := range entries {
+		ext := filepath.Ext(entry.Name())
+		if entry.IsDir() || (ext != ".yaml" && ext != ".yml") {
+			continue
+		}
+		template, err := loadTemplate(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			invalid = append(invalid, err)
+			continue
+		}
+		byName[template.Name] = append(byName[template.Name], template)
+	}
+
+	for name, named := range byName {
+		if len(named) > 1 {
+			files := make([]string, len(named))
+			for i, template := range named {
+				files[i] = template.File
+			}
+			invalid = append(invalid, fmt.Errorf("agent template name %s is used by more than one file: %s", name, strings.Join(files, ", ")))
+			continue
+		}
+		templates = append(templates, named[0])
+	}
+
+	sort.Slice(templates, func(i, j int) bool { return templates[i].Name < templates[j].Name })
+	sort.Slice(invalid, func(i, j int) bool { return invalid[i].Error() < invalid[j].Error() })
+	return templates, invalid, nil
+}
+
+// LoadTemplate returns the template with the given name from dir. Invalid templates in dir
+// only matter if the requested one is among them.
+func LoadTemplate(dir, name string) (*AgentTemplate, error) {
+	templates, invalid, err := LoadTemplates(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, template := range templates {
+		if template.Name == name {
+			return template, nil
+		}
+		names = append(names, template.Name)
+	}
+	if len(invalid) > 0 {
+		skipped := make([]string, len(invalid))
+		for i, err := range invalid {
+			skipped[i] = err.Error()
+		}
+		return nil, fmt.Errorf("agent template %s not found (available: %s; skipped: %s)", name, strings.Join(names, ", "), strings.Join(skipped, "; "))
+	}
+	return nil, fmt.Errorf("agent template %s not found (available: %s)", name, strings.Join(names, ", "))
+}
+
+// loadTemplate parses and validates a single template file. The name defaults to the file name.
+func loadTemplate(file string) (*AgentTemplate, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	template := &AgentTemplate{}
+	if err := yaml.Unmarshal(data, template); err != nil {
+		return nil, fmt.Errorf("invalid agent template %s: %w", file, err)
+	}
+
+	template.File = file
+	if template.Name == "" {
+		template.Name = strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+	}
+	if strings.TrimSpace(template.SystemPrompt) == "" {
+		return nil, fmt.Errorf("agent template %s has no system_prompt", file)
+	}
+	if template.Temperature != nil && (*template.Temperature < 0 || *template.Temperature > 2) {
+		return nil, fmt.Errorf("agent template %s: temperature must be between 0 and 2", file)
+	}
+	if template.MaxIterations < 0 {
+		return nil, fmt.Errorf("agent template %s: max_iterations must not be negative", file)
+	}
+	if template.MaxParallelTools < 0 {
+		return nil, fmt.Errorf("agent template %s: max_parallel_tools must not be negative", file)
+	}
+	for _, pattern := range template.Tools {
+		if !matchesAnyTool(pattern) {
+			return nil, fmt.Errorf("agent template %s: tool %q does not match any agent tool (available: %s)", file, pattern, strings.Join(agentToolNames, ", "))
+		}
+	}
+	if template.OutputSchema != nil && template.OutputSchema["type"] != "object" {
+		return nil, fmt.Errorf("agent template %s: output_schema must be a JSON schema of type object", file)
+	}
+
+	return template, nil
+}
+
+// matchesAnyTool reports whether a tool pattern, such as "browser_*", matches at least one agent tool.
+func matchesAnyTool(pattern string) bool {
+	for _, name := range agentToolNames {
+		if matched, _ := path.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// toolAllowed reports whether the agent may use the named tool. Agents without a tool list may use every tool.
+func (a *Agent) toolAllowed(name string) bool {
+	if len(a.allowedTools) == 0 {
+		return true
+	}
+	for _, always := range alwaysAllowedTools {
+		if name == always {
+			return true
+		}
+	}
+	for _, pattern := range a.allowedTools {
+		if matched, _ := path.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// LaunchAgentFromTemplate launches an agent configured by the named template. A positive
+// maxIterations or a non-nil temperature overrides the template's value.
+func (m *AgentManager) LaunchAgentFromTemplate(name, userPrompt string, temperature *float64, maxIterations int, policy *URLPolicy) (*Agent, error) {
+	template, err := LoadTemplate(m.templateDir, name)
+	if err != nil {
+		return nil, err
+	}
+
+	if maxIterations <= 0 {
+		maxIterations = template.MaxIterations
+	}
+	if maxIterations <= 0 {
+		maxIterations = 10
+	}
+	if temperature == nil {
+		temperature = template.Temperature
+	}
+	if temperature == nil {
+		defaultTemperature := 1.0
+		temperature = &defaultTemperature
+	}
+
+	maxParallelTools := template.MaxParallelTools
+	if maxParallelTools == 0 {
+		maxParallelTools = m.maxParallelTools
+	}
+
+	image := template.Image
+	if image == "" {
+		image = m.image
+	}
+
+	systemPrompt := m.agentSystemPrompt(template.SystemPrompt, maxIterations, len(template.Tools) > 0)
+	if template.OutputSchema != nil {
+		systemPrompt += "\nWhen you call 'complete_task', pass your final result as 'output', following its schema exactly."
+	}
+
+	agent := &Agent{
+		ID:               uuid.New().String(),
+		Status:           StatusInitializing,
+		SystemPrompt:     systemPrompt,
+		Messages:         []openai.ChatCompletionMessageParamUnion{openai.UserMessage(userPrompt)},
+		Temperature:      *temperature,
+		MaxIterations:    maxIterations,
+		CurrentIteration: 0,
+		MaxParallelTools: maxParallelTools,
+		Model:            template.Model,
+		Template:         template.Name,
+		LastActive:       time.Now(),
+		image:            image,
+		allowedTools:     template.Tools,
+		outputSchema:     template.OutputSchema,
+		runOnStart:       true,
+		pendingMessages:  make([]openai.ChatCompletionMessageParamUnion, 0),
+		taskChan:         make(chan string),
+		shutdownChan:     make(chan struct{}),
+	}
+
+	if err := m.launch(agent, policy); err != nil {
+		return nil, err
+	}
+	return agent, nil
+}
+
+// agentTools narrows the tool definitions to those the agent may use, and makes complete_task
+// require an output matching the agent's output schema, if it has one.
+func agentTools(agent *Agent, tools []openai.ChatCompletionToolParam) []openai.ChatCompletionToolParam {
+	result := make([]openai.ChatCompletionToolParam, 0, len(tools))
+	for _, tool := range tools {
+		if !agent.toolAllowed(tool.Function.Name) {
+			continue
+		}
+		if tool.Function.Name == "complete_task" && agent.outputSchema != nil {
+			tool.Function.Description = openai.String("Mark the current task as complete, returning your final result as structured output, and stop execution.")
+			tool.Function.Parameters = openai.FunctionParameters{
+				"type":       "object",
+				"properties": map[string]interface{}{"output": agent.outputSchema},
+				"required":   []string{"output"},
+			}
+		}
+		result = append(result, tool)
+	}
+	return result
+}
+
+// taskOutput extracts the output argument of complete_task and checks it is an object holding
+// every property the schema requires.
+func taskOutput(arguments string, schema map[string]interface{}) (json.RawMessage, error) {
+	var args struct {
+		Output json.RawMessage `json:"output"`
+	}
+	if err := json.Unmarshal([]byte(arguments), &args); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal arguments for complete_task: %w", err)
+	}
+	if len(args.Output) == 0 {
+		return nil, errors.New("complete_task requires an 'output' matching the output schema")
+	}
+
+	var output map[string]interface{}
+	if err := json.Unmarshal(args.Output, &output); err != nil {
+		return nil, fmt.Errorf("'output' must be a JSON object matching the output schema: %w", err)
+	}
+
+	required, _ := schema["required"].([]interface{})
+	var missing []string
+	for _, key := range required {
+		if name, ok := key.(string); ok {
+			if _, present := output[name]; !present {
+				missing = append(missing, name)
+			}
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("'output' is missing the required properties: %s", strings.Join(missing, ", "))
+	}
+
+	return args.Output, nil
+}
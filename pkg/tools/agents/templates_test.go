@@ -0,0 +1,169 @@
+package agents
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/openai/openai-go"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestLoadTemplates(t *testing.T) {
+	Convey("Given a template directory", t, func() {
+		dir := t.TempDir()
+		write := func(name, content string) {
+			So(os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644), ShouldBeNil)
+		}
+
+		write("reviewer.yaml", `
+name: code-reviewer
+description: Reviews pull requests
+system_prompt: You review code.
+temperature: 0.2
+tools: [execute_command, "browser_*"]
+output_schema:
+  type: object
+  required: [verdict]
+`)
+		write("researcher.yml", "system_prompt: You research things.\n")
+		write("notes.txt", "not a template")
+		So(os.Mkdir(filepath.Join(dir, "drafts.yaml"), 0o755), ShouldBeNil)
+
+		Convey("It should load every template sorted by name, defaulting the name to the file name", func() {
+			templates, invalid, err := LoadTemplates(dir)
+
+			So(err, ShouldBeNil)
+			So(invalid, ShouldBeEmpty)
+			So(templates, ShouldHaveLength, 2)
+			So(templates[0].Name, ShouldEqual, "code-reviewer")
+			So(*templates[0].Temperature, ShouldEqual, 0.2)
+			So(templates[0].Tools, ShouldResemble, []string{"execute_command", "browser_*"})
+			So(templates[1].Name, ShouldEqual, "researcher")
+			So(templates[1].File, ShouldEqual, filepath.Join(dir, "researcher.yml"))
+		})
+
+		Convey("It should find a template by name", func() {
+			template, err := LoadTemplate(dir, "researcher")
+
+			So(err, ShouldBeNil)
+			So(template.SystemPrompt, ShouldEqual, "You research things.")
+		})
+
+		Convey("It should list the available templates when one is not found", func() {
+			_, err := LoadTemplate(dir, "writer")
+
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "available: code-reviewer, researcher")
+		})
+
+		Convey("It should skip and report invalid templates", func() {
+			for content, message := range map[string]string{
+				"name: empty\n":                                     "has no system_prompt",
+				"system_prompt: x\ntemperature: 3\n":                "temperature must be between 0 and 2",
+				"system_prompt: x\nmax_iterations: -1\n":            "max_iterations must not be negative",
+				"system_prompt: x\nmax_parallel_tools: -2\n":        "max_parallel_tools must not be negative",
+				"system_prompt: x\ntools: [azure_*]\n":              `tool "azure_*" does not match any agent tool`,
+				"system_prompt: x\noutput_schema: {type: string}\n": "output_schema must be a JSON schema of type object",
+				"system_prompt: [unterminated\n":                    "invalid agent template",
+			} {
+				write("broken.yaml", content)
+
+				templates, invalid, err := LoadTemplates(dir)
+
+				So(err, ShouldBeNil)
+				So(templates, ShouldHaveLength, 2)
+				So(invalid, ShouldHaveLength, 1)
+				So(invalid[0].Error(), ShouldContainSubstring, message)
+			}
+
+			template, err := LoadTemplate(dir, "researcher")
+			So(err, ShouldBeNil)
+			So(template.Name, ShouldEqual, "researcher")
+
+			_, err = LoadTemplate(dir, "broken")
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "skipped: ")
+		})
+
+		Convey("It should reject templates sharing a name", func() {
+			write("other-reviewer.yaml", "name: code-reviewer\nsystem_prompt: You also review code.\n")
+
+			templates, invalid, err := LoadTemplates(dir)
+
+			So(err, ShouldBeNil)
+			So(templates, ShouldHaveLength, 1)
+			So(invalid, ShouldHaveLength, 1)
+			So(invalid[0].Error(), ShouldContainSubstring, "name code-reviewer is used by more than one file")
+
+			_, err = LoadTemplate(dir, "code-reviewer")
+			So(err, ShouldNotBeNil)
+		})
+	})
+
+	Convey("Given a missing template directory", t, func() {
+		_, _, err := LoadTemplates(filepath.Join(t.TempDir(), "missing"))
+
+		Convey("It should say so", func() {
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "does not exist")
+		})
+	})
+}
+
+func TestAgentTools(t *testing.T) {
+	Convey("Given an agent restricted to browser tools with an output schema", t, func() {
+		agent := &Agent{
+			allowedTools: []string{"browser_*"},
+			outputSchema: map[string]interface{}{"type": "object"},
+		}
+		tools := []openai.ChatCompletionToolParam{
+			{Function: openai.FunctionDefinitionParam{Name: "complete_task"}},
+			{Function: openai.FunctionDefinitionParam{Name: "execute_command"}},
+			{Function: openai.FunctionDefinitionParam{Name: "browser_click"}},
+		}
+
+		Convey("It should keep the allowed tools and require the structured output", func() {
+			result := agentTools(agent, tools)
+
+			So(result, ShouldHaveLength, 2)
+			So(result[0].Function.Name, ShouldEqual, "complete_task")
+			So(result[0].Function.Parameters["required"], ShouldResemble, []string{"output"})
+			So(result[1].Function.Name, ShouldEqual, "browser_click")
+		})
+	})
+
+	Convey("Given an agent without a tool list", t, func() {
+		Convey("It should allow every tool", func() {
+			So((&Agent{}).toolAllowed("execute_command"), ShouldBeTrue)
+		})
+	})
+}
+
+func TestTaskOutput(t *testing.T) {
+	Convey("Given an output schema with required properties", t, func() {
+		schema := map[string]interface{}{"type": "object", "required": []interface{}{"verdict", "summary"}}
+
+		Convey("It should return an output holding every required property", func() {
+			output, err := taskOutput(`{"output": {"verdict": "approve", "summary": "ok"}}`, schema)
+
+			So(err, ShouldBeNil)
+			So(string(output), ShouldEqual, `{"verdict": "approve", "summary": "ok"}`)
+		})
+
+		Convey("It should name the missing properties", func() {
+			_, err := taskOutput(`{"output": {"verdict": "approve"}}`, schema)
+
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "missing the required properties: summary")
+		})
+
+		Convey("It should reject a missing or non-object output", func() {
+			_, err := taskOutput(`{}`, schema)
+			So(err, ShouldNotBeNil)
+
+			_, err = taskOutput(`{"output": "approve"}`, schema)
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/openai/openai-go"
@@ -74,6 +75,16 @@ func NewAgentProvider() (*AgentProvider, error) {
 	instructTool := NewInstructAgentTool(manager)
 	shutdownTool := NewShutdownAgentTool(manager)
 	bulkManageTool := NewBulkManageAgentsTool(manager)
+	snapshotTool := NewSnapshotAgentTool(manager)
+	forkTool := NewForkAgentTool(manager)
+	deleteSnapshotTool := NewDeleteSnapshotTool(manager)
+	listTemplatesTool := NewListAgentTemplatesTool(manager)
+	launchFromTemplateTool := NewLaunchAgentFromTemplateTool(manager)
+	listApprovalsTool := NewListPendingApprovalsTool(manager)
+	approveTool := NewApproveActionTool(manager)
+	rejectTool := NewRejectActionTool(manager)
+	workflowTool := NewRunAgentWorkflowTool(manager)
+	workflowStatusTool := NewGetWorkflowStatusTool(manager)
 
 	provider.Tools[launchTool.Handle().Name] = launchTool
 	provider.Tools[listTool.Handle().Name] = listTool
@@ -81,6 +92,16 @@ func NewAgentProvider() (*AgentProvider, error) {
 	provider.Tools[instructTool.Handle().Name] = instructTool
 	provider.Tools[shutdownTool.Handle().Name] = shutdownTool
 	provider.Tools[bulkManageTool.Handle().Name] = bulkManageTool
+	provider.Tools[snapshotTool.Handle().Name] = snapshotTool
+	provider.Tools[forkTool.Handle().Name] = forkTool
+	provider.Tools[deleteSnapshotTool.Handle().Name] = deleteSnapshotTool
+	provider.Tools[listTemplatesTool.Handle().Name] = listTemplatesTool
+	provider.Tools[launchFromTemplateTool.Handle().Name] = launchFromTemplateTool
+	provider.Tools[listApprovalsTool.Handle().Name] = listApprovalsTool
+	provider.Tools[approveTool.Handle().Name] = approveTool
+	provider.Tools[rejectTool.Handle().Name] = rejectTool
+	provider.Tools[workflowTool.Handle().Name] = workflowTool
+	provider.Tools[workflowStatusTool.Handle().Name] = workflowStatusTool
 
 	return provider, nil
 }
@@ -105,6 +126,9 @@ func NewLaunchAgentTool(manager *AgentManager) core.Tool {
 		mcp.WithString("user_prompt", mcp.Required(), mcp.Description("The initial user prompt or task for the agent.")),
 		mcp.WithNumber("temperature", mcp.Description("Controls creativity. Value between 0 and 2. Defaults to 1.")),
 		mcp.WithNumber("max_iterations", mcp.Description("The maximum number of iterations the agent can perform. Defaults to 10.")),
+		mcp.WithString("allowed_domains", mcp.Description("Comma-separated domains the agent may browse, e.g. 'docs.example.com,*.github.com'. Applied on top of the server's URL policy.")),
+		mcp.WithString("denied_domains", mcp.Description("Comma-separated domains the agent may never browse.")),
+		mcp.WithBoolean("respect_robots", mcp.Description("Deny paths disallowed by a site's robots.txt.")),
 	)
 	return t
 }
@@ -139,14 +163,48 @@ func (t *LaunchAgentTool) Handler(ctx context.Context, request mcp.CallToolReque
 		}
 	}
 
-	agent, err := t.manager.LaunchAgent(systemPrompt, userPrompt, temperature, maxIterations)
+	agent, err := t.manager.LaunchAgent(systemPrompt, userPrompt, temperature, maxIterations, urlPolicyFromRequest(request))
 	if err != nil {
 		return mcp.NewToolResultError(err.Error()), nil
 	}
 
+	if position := t.manager.QueuePosition(agent.ID); position > 0 {
+		return mcp.NewToolResultText(fmt.Sprintf("Agent queued with ID: %s (position %d in the launch queue)", agent.ID, position)), nil
+	}
+
 	return mcp.NewToolResultText(fmt.Sprintf("Agent launched with ID: %s", agent.ID)), nil
 }
 
+// urlPolicyFromRequest builds an agent URL policy from the launch arguments, or returns nil if none were given.
+func urlPolicyFromRequest(request mcp.CallToolRequest) *URLPolicy {
+	policy := &URLPolicy{}
+	if allowed, ok := request.Params.Arguments["allowed_domains"].(string); ok {
+		policy.AllowedDomains = splitList(allowed)
+	}
+	if denied, ok := request.Params.Arguments["denied_domains"].(string); ok {
+		policy.DeniedDomains = splitList(denied)
+	}
+	if respect, ok := request.Params.Arguments["respect_robots"].(bool); ok {
+		policy.RespectRobots = respect
+	}
+
+	if len(policy.AllowedDomains) == 0 && len(policy.DeniedDomains) == 0 && !policy.RespectRobots {
+		return nil
+	}
+	return policy
+}
+
+// splitList splits a comma-separated list into its trimmed, non-empty items.
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
 // --- ListAgentsTool ---
 
 type ListAgentsTool struct {
@@ -179,7 +237,8 @@ func (t *ListAgentsTool) Handler(ctx context.Context, request mcp.CallToolReques
 
 	infos := make([]agentInfo, len(agents))
 	for i, a := range agents {
-		infos[i] = agentInfo{ID: a.ID, Status: a.Status, Result: a.Result}
+		state := a.state()
+		infos[i] = agentInfo{ID: a.ID, Status: state.Status, Result: state.Result}
 	}
 
 	jsonResult, err := json.MarshalIndent(infos, "", "  ")
@@ -222,17 +281,26 @@ func (t *GetAgentStatusTool) Handler(ctx context.Context, request mcp.CallToolRe
 
 	// Create a clean summary for the main agent
 	type agentStatusResponse struct {
-		ID       string                                   `json:"id"`
-		Status   Status                                   `json:"status"`
-		Result   string                                   `json:"result"`
-		Messages []openai.ChatCompletionMessageParamUnion `json:"messages"`
+		ID        string                                   `json:"id"`
+		Status    Status                                   `json:"status"`
+		Result    string                                   `json:"result"`
+		Template  string                                   `json:"template,omitempty"`
+		Model     string                                   `json:"model,omitempty"`
+		Output    json.RawMessage                          `json:"output,omitempty"`
+		Artifacts []string                                 `json:"artifacts,omitempty"`
+		Messages  []openai.ChatCompletionMessageParamUnion `json:"messages"`
 	}
 
+	state := agent.state()
 	response := agentStatusResponse{
-		ID:       agent.ID,
-		Status:   agent.Status,
-		Result:   agent.Result,
-		Messages: agent.Messages,
+		ID:        agent.ID,
+		Status:    state.Status,
+		Result:    state.Result,
+		Template:  agent.Template,
+		Model:     agent.Model,
+		Output:    state.Output,
+		Artifacts: state.Artifacts,
+		Messages:  state.Messages,
 	}
 
 	jsonResult, err := json.MarshalIndent(response, "", "  ")
@@ -328,7 +396,7 @@ func NewBulkManageAgentsTool(manager *AgentManager) core.Tool {
 	t.handle = mcp.NewTool(
 		"bulkManageAgents",
 		mcp.WithDescription("Sends a batch of instructions to multiple agents in a single request. Can be used to launch, instruct, or shut down agents."),
-		mcp.WithString("operations", mcp.Required(), mcp.Description("A JSON string representing an array of operations. Each operation is an object with 'action' ('launch', 'instruct', or 'shutdown'), 'agent_id' (for instruct/shutdown), and other parameters.")),
+		mcp.WithString("operations", mcp.Required(), mcp.Description("A JSON string representing an array of operations. Each operation is an object with 'action' ('launch', 'instruct', or 'shutdown'), 'agent_id' (for instruct/shutdown), and other parameters. Launch operations may include a 'url_policy' object with 'allowed_domains', 'denied_domains' and 'respect_robots'.")),
 	)
 	return t
 }
@@ -343,12 +411,13 @@ func (t *BulkManageAgentsTool) Handler(ctx context.Context, request mcp.CallTool
 	}
 
 	type operation struct {
-		Action        string  `json:"action"`
-		AgentID       string  `json:"agent_id,omitempty"`
-		Prompt        string  `json:"prompt,omitempty"`
-		SystemPrompt  string  `json:"system_prompt,omitempty"`
-		Temperature   float64 `json:"temperature,omitempty"`
-		MaxIterations int     `json:"max_iterations,omitempty"`
+		Action        string     `json:"action"`
+		AgentID       string     `json:"agent_id,omitempty"`
+		Prompt        string     `json:"prompt,omitempty"`
+		SystemPrompt  string     `json:"system_prompt,omitempty"`
+		Temperature   float64    `json:"temperature,omitempty"`
+		MaxIterations int        `json:"max_iterations,omitempty"`
+		URLPolicy     *URLPolicy `json:"url_policy,omitempty"`
 	}
 
 	var ops []operation
@@ -372,8 +441,10 @@ func (t *BulkManageAgentsTool) Handler(ctx context.Context, request mcp.CallTool
 
 			if op.Prompt == "" || op.SystemPrompt == "" {
 				result = "Launch op: FAILED - 'prompt' and 'system_prompt' are required for 'launch' action."
-			} else if agent, err := t.manager.LaunchAgent(op.SystemPrompt, op.Prompt, temp, iters); err != nil {
+			} else if agent, err := t.manager.LaunchAgent(op.SystemPrompt, op.Prompt, temp, iters, op.URLPolicy); err != nil {
 				result = fmt.Sprintf("Launch op: FAILED - %v", err)
+			} else if position := t.manager.QueuePosition(agent.ID); position > 0 {
+				result = fmt.Sprintf("Launch op: QUEUED - Agent queued with ID: %s (position %d)", agent.ID, position)
 			} else {
 				result = fmt.Sprintf("Launch op: SUCCESS - Agent launched with ID: %s", agent.ID)
 			}
@@ -401,3 +472,432 @@ func (t *BulkManageAgentsTool) Handler(ctx context.Context, request mcp.CallTool
 
 	return mcp.NewToolResultText(strings.Join(results, "\n")), nil
 }
+
+// --- SnapshotAgentTool ---
+
+// SnapshotAgentTool checkpoints an agent's sandbox and conversation.
+type SnapshotAgentTool struct {
+	handle  mcp.Tool
+	manager *AgentManager
+}
+
+// NewSnapshotAgentTool creates a new SnapshotAgentTool.
+func NewSnapshotAgentTool(manager *AgentManager) core.Tool {
+	t := &SnapshotAgentTool{manager: manager}
+	t.handle = mcp.NewTool(
+		"snapshot_agent",
+		mcp.WithDescription("Checkpoints an agent by committing its container to a tagged image and copying its messages. Use the returned snapshot ID with fork_agent to branch work or roll back."),
+		mcp.WithString("agent_id", mcp.Required(), mcp.Description("The ID of the agent to snapshot.")),
+		mcp.WithString("tag", mcp.Description("Optional tag for the snapshot. Defaults to the agent ID prefix and a timestamp.")),
+	)
+	return t
+}
+
+func (t *SnapshotAgentTool) Handle() mcp.Tool { return t.handle }
+
+func (t *SnapshotAgentTool) Handler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	agentID, err := GetStringArg(request, "agent_id")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	tag, _ := GetStringArg(request, "tag")
+
+	snapshot, err := t.manager.SnapshotAgent(ctx, agentID, tag)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	return mcp.NewToolResultText(fmt.Sprintf(
+		"Snapshot %s created from agent %s at iteration %d (image: %s, %d messages).",
+		snapshot.ID, snapshot.AgentID, snapshot.Iteration, snapshot.Image, len(snapshot.Messages),
+	)), nil
+}
+
+// --- DeleteSnapshotTool ---
+
+// DeleteSnapshotTool removes a snapshot and its committed sandbox state.
+type DeleteSnapshotTool struct {
+	handle  mcp.Tool
+	manager *AgentManager
+}
+
+// NewDeleteSnapshotTool creates a new DeleteSnapshotTool.
+func NewDeleteSnapshotTool(manager *AgentManager) core.Tool {
+	t := &DeleteSnapshotTool{manager: manager}
+	t.handle = mcp.NewTool(
+		"delete_snapshot",
+		mcp.WithDescription("Deletes a snapshot and the image or directory holding its container state. Agents already forked from it are not affected."),
+		mcp.WithString("snapshot_id", mcp.Required(), mcp.Description("The ID of the snapshot to delete.")),
+	)
+	return t
+}
+
+func (t *DeleteSnapshotTool) Handle() mcp.Tool { return t.handle }
+
+func (t *DeleteSnapshotTool) Handler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	snapshotID, err := GetStringArg(request, "snapshot_id")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	if err := t.manager.DeleteSnapshot(ctx, snapshotID); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("Snapshot %s deleted.", snapshotID)), nil
+}
+
+// --- ForkAgentTool ---
+
+// ForkAgentTool launches a new agent from a snapshot.
+type ForkAgentTool struct {
+	handle  mcp.Tool
+	manager *AgentManager
+}
+
+// NewForkAgentTool creates a new ForkAgentTool.
+func NewForkAgentTool(manager *AgentManager) core.Tool {
+	t := &ForkAgentTool{manager: manager}
+	t.handle = mcp.NewTool(
+		"fork_agent",
+		mcp.WithDescription("Launches a new agent from a snapshot, restoring its container state and conversation. The original agent is left untouched."),
+		mcp.WithString("snapshot_id", mcp.Required(), mcp.Description("The ID of the snapshot to fork from.")),
+		mcp.WithString("prompt", mcp.Description("Optional instruction for the forked agent. If omitted, the agent waits for instructions.")),
+		mcp.WithNumber("max_iterations", mcp.Description("The maximum number of iterations for the forked agent. Defaults to the snapshot's limit.")),
+	)
+	return t
+}
+
+func (t *ForkAgentTool) Handle() mcp.Tool { return t.handle }
+
+func (t *ForkAgentTool) Handler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	snapshotID, err := GetStringArg(request, "snapshot_id")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	prompt, _ := GetStringArg(request, "prompt")
+
+	maxIterations := 0
+	if iterVal, ok := request.Params.Arguments["max_iterations"]; ok {
+		if iter, isFloat := iterVal.(float64); isFloat {
+			maxIterations = int(iter)
+		} else {
+			return mcp.NewToolResultError("invalid type for 'max_iterations', expected integer"), nil
+		}
+	}
+
+	agent, err := t.manager.ForkAgent(snapshotID, prompt, maxIterations)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	return mcp.NewToolResultText(fmt.Sprintf("Agent forked from snapshot %s with ID: %s (status: %s)", snapshotID, agent.ID, agent.status())), nil
+}
+
+// --- ListAgentTemplatesTool ---
+
+// ListAgentTemplatesTool lists the agent templates in the template directory.
+type ListAgentTemplatesTool struct {
+	handle  mcp.Tool
+	manager *AgentManager
+}
+
+// NewListAgentTemplatesTool creates a new ListAgentTemplatesTool.
+func NewListAgentTemplatesTool(manager *AgentManager) core.Tool {
+	t := &ListAgentTemplatesTool{manager: manager}
+	t.handle = mcp.NewTool(
+		"list_agent_templates",
+		mcp.WithDescription("Lists the agent templates that can be launched with launch_agent_from_template, with their model, limits, tools and output schema."),
+	)
+	return t
+}
+
+func (t *ListAgentTemplatesTool) Handle() mcp.Tool { return t.handle }
+
+func (t *ListAgentTemplatesTool) Handler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	templates, invalid, err := LoadTemplates(t.manager.templateDir)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	var skipped strings.Builder
+	if len(invalid) > 0 {
+		skipped.WriteString("\n\nSkipped invalid templates:")
+		for _, err := range invalid {
+			skipped.WriteString("\n- " + err.Error())
+		}
+	}
+	if len(templates) == 0 {
+		return mcp.NewToolResultText(fmt.Sprintf("No agent templates found in %s.", t.manager.templateDir) + skipped.String()), nil
+	}
+
+	// The system prompt is left out to keep the listing short.
+	type templateInfo struct {
+		*AgentTemplate
+		SystemPrompt string `json:"system_prompt,omitempty"`
+	}
+
+	infos := make([]templateInfo, len(templates))
+	for i, template := range templates {
+		infos[i] = templateInfo{AgentTemplate: template}
+	}
+
+	jsonResult, err := json.MarshalIndent(infos, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError("failed to serialize agent templates"), nil
+	}
+
+	return mcp.NewToolResultText(string(jsonResult) + skipped.String()), nil
+}
+
+// --- LaunchAgentFromTemplateTool ---
+
+// LaunchAgentFromTemplateTool launches an agent configured by a template.
+type LaunchAgentFromTemplateTool struct {
+	handle  mcp.Tool
+	manager *AgentManager
+}
+
+// NewLaunchAgentFromTemplateTool creates a new LaunchAgentFromTemplateTool.
+func NewLaunchAgentFromTemplateTool(manager *AgentManager) core.Tool {
+	t := &LaunchAgentFromTemplateTool{manager: manager}
+	t.handle = mcp.NewTool(
+		"launch_agent_from_template",
+		mcp.WithDescription("Launches a new agent from a named template, which supplies its system prompt, model, temperature, iteration limit, image, tools and output schema."),
+		mcp.WithString("template", mcp.Required(), mcp.Description("The name of the template, as listed by list_agent_templates.")),
+		mcp.WithString("prompt", mcp.Required(), mcp.Description("The initial user prompt or task for the agent.")),
+		mcp.WithNumber("temperature", mcp.Description("Overrides the template's temperature.")),
+		mcp.WithNumber("max_iterations", mcp.Description("Overrides the template's maximum number of iterations.")),
+		mcp.WithString("allowed_domains", mcp.Description("Comma-separated domains the agent may browse. Applied on top of the server's URL policy.")),
+		mcp.WithString("denied_domains", mcp.Description("Comma-separated domains the agent may never browse.")),
+		mcp.WithBoolean("respect_robots", mcp.Description("Deny paths disallowed by a site's robots.txt.")),
+	)
+	return t
+}
+
+func (t *LaunchAgentFromTemplateTool) Handle() mcp.Tool { return t.handle }
+
+func (t *LaunchAgentFromTemplateTool) Handler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	name, err := GetStringArg(request, "template")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	prompt, err := GetStringArg(request, "prompt")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	var temperature *float64
+	if tempVal, ok := request.Params.Arguments["temperature"]; ok {
+		temp, isFloat := tempVal.(float64)
+		if !isFloat {
+			return mcp.NewToolResultError("invalid type for 'temperature', expected number"), nil
+		}
+		temperature = &temp
+	}
+
+	maxIterations := 0
+	if iterVal, ok := request.Params.Arguments["max_iterations"]; ok {
+		if iter, isFloat := iterVal.(float64); isFloat {
+			maxIterations = int(iter)
+		} else {
+			return mcp.NewToolResultError("invalid type for 'max_iterations', expected integer"), nil
+		}
+	}
+
+	agent, err := t.manager.LaunchAgentFromTemplate(name, prompt, temperature, maxIterations, urlPolicyFromRequest(request))
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	if position := t.manager.QueuePosition(agent.ID); position > 0 {
+		return mcp.NewToolResultText(fmt.Sprintf("Agent queued from template %s with ID: %s (position %d in the launch queue)", name, agent.ID, position)), nil
+	}
+
+	return mcp.NewToolResultText(fmt.Sprintf("Agent launched from template %s with ID: %s", name, agent.ID)), nil
+}
+
+// --- ListPendingApprovalsTool ---
+
+// ListPendingApprovalsTool lists agent actions waiting for a human decision.
+type ListPendingApprovalsTool struct {
+	handle  mcp.Tool
+	manager *AgentManager
+}
+
+// NewListPendingApprovalsTool creates a new ListPendingApprovalsTool.
+func NewListPendingApprovalsTool(manager *AgentManager) core.Tool {
+	t := &ListPendingApprovalsTool{manager: manager}
+	t.handle = mcp.NewTool(
+		"list_pending_approvals",
+		mcp.WithDescription("Lists the agent actions waiting for human approval, oldest first, with the tool, its arguments and why approval is needed."),
+		mcp.WithString("agent_id", mcp.Description("Only list the approvals of this agent.")),
+	)
+	return t
+}
+
+func (t *ListPendingApprovalsTool) Handle() mcp.Tool { return t.handle }
+
+func (t *ListPendingApprovalsTool) Handler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	agentID, _ := request.Params.Arguments["agent_id"].(string)
+
+	approvals := t.manager.PendingApprovals(agentID)
+	if len(approvals) == 0 {
+		return mcp.NewToolResultText("No actions are waiting for approval."), nil
+	}
+
+	jsonResult, err := json.MarshalIndent(approvals, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError("failed to serialize pending approvals"), nil
+	}
+
+	return mcp.NewToolResultText(string(jsonResult)), nil
+}
+
+// --- DecideApprovalTool ---
+
+// DecideApprovalTool approves or rejects a pending agent action. The same type backs
+// approve_action and reject_action.
+type DecideApprovalTool struct {
+	handle   mcp.Tool
+	manager  *AgentManager
+	decision ApprovalDecision
+}
+
+// NewApproveActionTool creates the approve_action tool.
+func NewApproveActionTool(manager *AgentManager) core.Tool {
+	t := &DecideApprovalTool{manager: manager, decision: ApprovalApproved}
+	t.handle = mcp.NewTool(
+		"approve_action",
+		mcp.WithDescription("Approves an agent action waiting for approval. The agent resumes and runs the action."),
+		mcp.WithString("approval_id", mcp.Required(), mcp.Description("The ID of the pending approval, as listed by list_pending_approvals.")),
+		mcp.WithString("note", mcp.Description("An optional note passed to the agent.")),
+	)
+	return t
+}
+
+// NewRejectActionTool creates the reject_action tool.
+func NewRejectActionTool(manager *AgentManager) core.Tool {
+	t := &DecideApprovalTool{manager: manager, decision: ApprovalRejected}
+	t.handle = mcp.NewTool(
+		"reject_action",
+		mcp.WithDescription("Rejects an agent action waiting for approval. The action does not run and the agent is told it was rejected."),
+		mcp.WithString("approval_id", mcp.Required(), mcp.Description("The ID of the pending approval, as listed by list_pending_approvals.")),
+		mcp.WithString("reason", mcp.Description("Why the action was rejected, passed to the agent so it can adjust.")),
+	)
+	return t
+}
+
+func (t *DecideApprovalTool) Handle() mcp.Tool { return t.handle }
+
+func (t *DecideApprovalTool) Handler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	approvalID, err := GetStringArg(request, "approval_id")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	note, _ := request.Params.Arguments["note"].(string)
+	if t.decision == ApprovalRejected {
+		note, _ = request.Params.Arguments["reason"].(string)
+	}
+
+	approval, err := t.manager.DecideApproval(approvalID, t.decision, note)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	return mcp.NewToolResultText(fmt.Sprintf("Action %s of agent %s %s.", approval.Tool, approval.AgentID, t.decision)), nil
+}
+
+// --- RunAgentWorkflowTool ---
+
+// RunAgentWorkflowTool runs a DAG of template agents, passing results between steps.
+type RunAgentWorkflowTool struct {
+	handle  mcp.Tool
+	manager *AgentManager
+}
+
+// NewRunAgentWorkflowTool creates a new RunAgentWorkflowTool.
+func NewRunAgentWorkflowTool(manager *AgentManager) core.Tool {
+	t := &RunAgentWorkflowTool{manager: manager}
+	t.handle = mcp.NewTool(
+		"run_agent_workflow",
+		mcp.WithDescription("Runs a workflow of agent steps. Each step launches an agent from a template; steps run as soon as the steps they depend on have completed, independent steps in parallel. Steps after a failed step are skipped. Returns the workflow ID, or the full report when waiting."),
+		mcp.WithString("steps", mcp.Required(), mcp.Description("A JSON array of steps. Each step is an object with 'id', 'template', 'prompt' and optionally 'depends_on' (step IDs), 'temperature', 'max_iterations' and 'timeout_seconds' (default 1800). The prompt may include {{steps.<id>.output}} for a dependency's structured output (or final text), {{steps.<id>.output.<field>}} for one of its fields, and {{steps.<id>.result}} for its final text. A prompt without references gets its dependencies' outputs appended.")),
+		mcp.WithString("name", mcp.Description("A name for the workflow, shown in its report.")),
+		mcp.WithBoolean("keep_agents", mcp.Description("Keep step agents running after their step finishes, for follow-up instructions. By default they are shut down to free their slots.")),
+		mcp.WithNumber("wait_seconds", mcp.Description("Wait up to this many seconds for the workflow to finish and return its report. Defaults to 0, returning immediately.")),
+	)
+	return t
+}
+
+func (t *RunAgentWorkflowTool) Handle() mcp.Tool { return t.handle }
+
+func (t *RunAgentWorkflowTool) Handler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	stepsStr, err := GetStringArg(request, "steps")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	var steps []*WorkflowStep
+	if err := json.Unmarshal([]byte(stepsStr), &steps); err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to parse steps JSON: %v", err)), nil
+	}
+
+	name, _ := request.Params.Arguments["name"].(string)
+	keepAgents, _ := request.Params.Arguments["keep_agents"].(bool)
+
+	wf, err := t.manager.RunWorkflow(name, steps, keepAgents)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	waitSeconds, _ := request.Params.Arguments["wait_seconds"].(float64)
+	if waitSeconds <= 0 || !wf.Wait(time.Duration(waitSeconds)*time.Second) {
+		return mcp.NewToolResultText(fmt.Sprintf("Workflow started with ID: %s (%d steps). Use get_workflow_status to follow it.", wf.ID, len(steps))), nil
+	}
+
+	report, err := wf.Report()
+	if err != nil {
+		return mcp.NewToolResultError("failed to serialize workflow report"), nil
+	}
+	return mcp.NewToolResultText(string(report)), nil
+}
+
+// --- GetWorkflowStatusTool ---
+
+// GetWorkflowStatusTool reports the status and step results of a workflow.
+type GetWorkflowStatusTool struct {
+	handle  mcp.Tool
+	manager *AgentManager
+}
+
+// NewGetWorkflowStatusTool creates a new GetWorkflowStatusTool.
+func NewGetWorkflowStatusTool(manager *AgentManager) core.Tool {
+	t := &GetWorkflowStatusTool{manager: manager}
+	t.handle = mcp.NewTool(
+		"get_workflow_status",
+		mcp.WithDescription("Reports the overall status of a workflow and, per step, its status, agent, structured output, final text and error."),
+		mcp.WithString("workflow_id", mcp.Required(), mcp.Description("The ID returned by run_agent_workflow.")),
+	)
+	return t
+}
+
+func (t *GetWorkflowStatusTool) Handle() mcp.Tool { return t.handle }
+
+func (t *GetWorkflowStatusTool) Handler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	workflowID, err := GetStringArg(request, "workflow_id")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	wf, err := t.manager.GetWorkflow(workflowID)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	report, err := wf.Report()
+	if err != nil {
+		return mcp.NewToolResultError("failed to serialize workflow report"), nil
+	}
+	return mcp.NewToolResultText(string(report)), nil
+}
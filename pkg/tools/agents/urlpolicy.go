@@ -0,0 +1,354 @@
+package agents
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/theapemachine/mcp-server-devops-bridge/pkg/config"
+)
+
+// robotsUserAgent is the user agent token matched against robots.txt groups.
+const robotsUserAgent = "mcp-devops-bridge"
+
+// robotsCacheTTL is how long a fetched robots.txt is reused before it is fetched again.
+const robotsCacheTTL = time.Hour
+
+// URLPolicy restricts which URLs an agent may access. Empty lists place no restriction.
+type URLPolicy struct {
+	// AllowedDomains, when set, are the only domains that may be accessed. A pattern such as
+	// "example.com" matches the domain and its subdomains, "*.example.com" only the subdomains.
+	AllowedDomains []string `json:"allowed_domains,omitempty"`
+	// DeniedDomains may never be accessed, even when they also match an allowed pattern.
+	DeniedDomains []string `json:"denied_domains,omitempty"`
+	// AllowedSchemes are the URL schemes that may be accessed, such as http and https.
+	AllowedSchemes []string `json:"allowed_schemes,omitempty"`
+	// BlockPrivateNetworks denies hosts that are or resolve to loopback, private or link-local
+	// addresses, which covers internal services and cloud metadata endpoints. http_request checks
+	// the address it connects to, but the browser resolves hosts again itself, so a host whose DNS
+	// answer changes between the check and the request (DNS rebinding) can still reach them there.
+	BlockPrivateNetworks bool `json:"block_private_networks,omitempty"`
+	// RespectRobots denies paths disallowed by the site's robots.txt.
+	RespectRobots bool `json:"respect_robots,omitempty"`
+}
+
+// PolicyError reports a URL denied by a URL policy.
+type PolicyError struct {
+	URL    string
+	Reason string
+}
+
+func (e *PolicyError) Error() string {
+	return fmt.Sprintf("access to %s is denied by the URL policy: %s", e.URL, e.Reason)
+}
+
+// GlobalURLPolicy builds the URL policy that applies to every agent from the configuration.
+func GlobalURLPolicy() *URLPolicy {
+	cfg := config.Load()
+	return &URLPolicy{
+		AllowedDomains:       cfg.Agents.URLAllowlist,
+		DeniedDomains:        cfg.Agents.URLDenylist,
+		AllowedSchemes:       cfg.Agents.URLSchemes,
+		BlockPrivateNetworks: !cfg.Agents.URLAllowPrivate,
+		RespectRobots:        cfg.Agents.URLRespectRobots,
+	}
+}
+
+// Check returns a *PolicyError if the URL may not be accessed.
+func (p *URLPolicy) Check(ctx context.Context, rawURL string) error {
+	return p.check(ctx, rawURL, p.RespectRobots)
+}
+
+// CheckSubresource is Check for the scripts, styles, images and other resources a page loads,
+// which robots.txt does not govern.
+func (p *URLPolicy) CheckSubresource(ctx context.Context, rawURL string) error {
+	return p.check(ctx, rawURL, false)
+}
+
+func (p *URLPolicy) check(ctx context.Context, rawURL string, respectRobots bool) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return &PolicyError{URL: rawURL, Reason: "the URL cannot be parsed"}
+	}
+
+	scheme := strings.ToLower(u.Scheme)
+	if len(p.AllowedSchemes) > 0 && !containsFold(p.AllowedSchemes, scheme) {
+		return &PolicyError{URL: rawURL, Reason: fmt.Sprintf("scheme %q is not allowed (allowed: %s)", scheme, strings.Join(p.AllowedSchemes, ", "))}
+	}
+
+	host := strings.ToLower(strings.TrimSuffix(u.Hostname(), "."))
+	if host == "" {
+		if len(p.AllowedDomains) > 0 || p.BlockPrivateNetworks {
+			return &PolicyError{URL: rawURL, Reason: "the URL has no host"}
+		}
+		return nil
+	}
+
+	for _, pattern := range p.DeniedDomains {
+		if matchDomain(pattern, host) {
+			return &PolicyError{URL: rawURL, Reason: fmt.Sprintf("domain %s matches denied pattern %q", host, pattern)}
+		}
+	}
+
+	if len(p.AllowedDomains) > 0 {
+		allowed := false
+		for _, pattern := range p.AllowedDomains {
+			if matchDomain(pattern, host) {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return &PolicyError{URL: rawURL, Reason: fmt.Sprintf("domain %s is not on the allowlist (%s)", host, strings.Join(p.AllowedDomains, ", "))}
+		}
+	}
+
+	if p.BlockPrivateNetworks {
+		if err := checkPublicHost(ctx, host); err != nil {
+			return &PolicyError{URL: rawURL, Reason: err.Error()}
+		}
+	}
+
+	if respectRobots && (scheme == "http" || scheme == "https") {
+		if !robots.allowed(ctx, u) {
+			return &PolicyError{URL: rawURL, Reason: "the path is disallowed by the site's robots.txt"}
+		}
+	}
+
+	return nil
+}
+
+// matchDomain reports whether host matches a domain pattern.
+func matchDomain(pattern, host string) bool {
+	pattern = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(pattern), "."))
+	if pattern == "" {
+		return false
+	}
+	if suffix, ok := strings.CutPrefix(pattern, "*."); ok {
+		return strings.HasSuffix(host, "."+suffix)
+	}
+	return host == pattern || strings.HasSuffix(host, "."+pattern)
+}
+
+// checkPublicHost returns an error when the host is, or resolves to, a non-public address.
+func checkPublicHost(ctx context.Context, host string) error {
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return fmt.Errorf("host %s is a loopback address", host)
+	}
+
+	var ips []net.IP
+	if ip := net.ParseIP(host); ip != nil {
+		ips = []net.IP{ip}
+	} else {
+		ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		defer cancel()
+
+		addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+		if err != nil {
+			return fmt.Errorf("host %s could not be resolved: %v", host, err)
+		}
+		for _, addr := range addrs {
+			ips = append(ips, addr.IP)
+		}
+	}
+
+	for _, ip := range ips {
+		switch {
+		case isPublicIP(ip):
+			continue
+		case ip.String() == host:
+			return fmt.Errorf("%s is a private address", host)
+		default:
+			return fmt.Errorf("host %s resolves to the private address %s", host, ip)
+		}
+	}
+	return nil
+}
+
+// sharedAddressSpace is the carrier-grade NAT range, which is not covered by net.IP.IsPrivate.
+var sharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+func isPublicIP(ip net.IP) bool {
+	return !(ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() ||
+		sharedAddressSpace.Contains(ip))
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(strings.TrimSpace(v), value) {
+			return true
+		}
+	}
+	return false
+}
+
+// robotsCache fetches and caches robots.txt rules per site.
+type robotsCache struct {
+	client *http.Client
+	sites  map[string]*robotsRules
+	mu     sync.Mutex
+}
+
+var robots = &robotsCache{
+	client: &http.Client{Timeout: 10 * time.Second},
+	sites:  make(map[string]*robotsRules),
+}
+
+// robotsRules are the Allow and Disallow rules of the group that applies to us.
+type robotsRules struct {
+	allow     []string
+	disallow  []string
+	fetchedAt time.Time
+}
+
+// allowed reports whether robots.txt permits fetching the URL. Sites whose robots.txt is
+// missing or cannot be fetched are treated as allowing everything.
+func (c *robotsCache) allowed(ctx context.Context, u *url.URL) bool {
+	site := strings.ToLower(u.Scheme + "://" + u.Host)
+
+	c.mu.Lock()
+	rules, cached := c.sites[site]
+	c.mu.Unlock()
+
+	if !cached || time.Since(rules.fetchedAt) > robotsCacheTTL {
+		rules = c.fetch(ctx, site)
+		c.mu.Lock()
+		c.sites[site] = rules
+		c.mu.Unlock()
+	}
+
+	path := u.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	if u.RawQuery != "" {
+		path += "?" + u.RawQuery
+	}
+	return rules.allows(path)
+}
+
+func (c *robotsCache) fetch(ctx context.Context, site string) *robotsRules {
+	rules := &robotsRules{fetchedAt: time.Now()}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, site+"/robots.txt", nil)
+	if err != nil {
+		return rules
+	}
+	req.Header.Set("User-Agent", robotsUserAgent)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return rules
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return rules
+	}
+	return parseRobots(io.LimitReader(resp.Body, 512*1024), rules)
+}
+
+// parseRobots reads the rules of the group for our user agent, falling back to the "*" group.
+func parseRobots(r io.Reader, rules *robotsRules) *robotsRules {
+	var specific, wildcard robotsRules
+	var current []*robotsRules
+	inAgents := false
+	foundSpecific := false
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "user-agent":
+			if !inAgents {
+				current = nil
+			}
+			inAgents = true
+			agent := strings.ToLower(value)
+			if agent == "*" {
+				current = append(current, &wildcard)
+			} else if strings.Contains(robotsUserAgent, agent) {
+				current = append(current, &specific)
+				foundSpecific = true
+			}
+		case "allow", "disallow":
+			inAgents = false
+			if value == "" {
+				continue // An empty Disallow allows everything
+			}
+			for _, group := range current {
+				if key == "allow" {
+					group.allow = append(group.allow, value)
+				} else {
+					group.disallow = append(group.disallow, value)
+				}
+			}
+		default:
+			inAgents = false
+		}
+	}
+
+	group := wildcard
+	if foundSpecific {
+		group = specific
+	}
+	rules.allow = group.allow
+	rules.disallow = group.disallow
+	return rules
+}
+
+// allows applies the most specific matching rule, with Allow winning ties.
+func (r *robotsRules) allows(path string) bool {
+	longestAllow, longestDisallow := -1, -1
+	for _, rule := range r.allow {
+		if robotsMatch(rule, path) && len(rule) > longestAllow {
+			longestAllow = len(rule)
+		}
+	}
+	for _, rule := range r.disallow {
+		if robotsMatch(rule, path) && len(rule) > longestDisallow {
+			longestDisallow = len(rule)
+		}
+	}
+	return longestDisallow < 0 || longestAllow >= longestDisallow
+}
+
+// robotsMatch matches a path against a robots.txt rule, which may contain "*" wildcards and end in "$".
+func robotsMatch(rule, path string) bool {
+	anchored := strings.HasSuffix(rule, "$")
+	rule = strings.TrimSuffix(rule, "$")
+
+	parts := strings.Split(rule, "*")
+	if !strings.HasPrefix(path, parts[0]) {
+		return false
+	}
+	rest := path[len(parts[0]):]
+	for _, part := range parts[1:] {
+		i := strings.Index(rest, part)
+		if i < 0 {
+			return false
+		}
+		rest = rest[i+len(part):]
+	}
+
+	if anchored {
+		last := parts[len(parts)-1]
+		return rest == "" || (len(parts) > 1 && strings.HasSuffix(path, last))
+	}
+	return true
+}
@@ -0,0 +1,187 @@
+package agents
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestURLPolicyCheck(t *testing.T) {
+	Convey("Given a URL policy", t, func() {
+		ctx := context.Background()
+
+		Convey("It should allow everything when it is empty", func() {
+			So((&URLPolicy{}).Check(ctx, "ftp://files.example.com/a"), ShouldBeNil)
+		})
+
+		Convey("It should only allow the listed schemes", func() {
+			policy := &URLPolicy{AllowedSchemes: []string{"https"}}
+
+			So(policy.Check(ctx, "HTTPS://example.com"), ShouldBeNil)
+			So(policy.Check(ctx, "file:///etc/passwd"), ShouldHaveSameTypeAs, &PolicyError{})
+		})
+
+		Convey("It should match allowed domains and their subdomains", func() {
+			policy := &URLPolicy{AllowedDomains: []string{"example.com", "*.internal.org"}}
+
+			So(policy.Check(ctx, "https://example.com/a"), ShouldBeNil)
+			So(policy.Check(ctx, "https://docs.Example.com./a"), ShouldBeNil)
+			So(policy.Check(ctx, "https://wiki.internal.org"), ShouldBeNil)
+			So(policy.Check(ctx, "https://internal.org"), ShouldNotBeNil)
+			So(policy.Check(ctx, "https://notexample.com"), ShouldNotBeNil)
+			So(policy.Check(ctx, "mailto:someone"), ShouldNotBeNil)
+		})
+
+		Convey("It should let denied domains win over allowed ones", func() {
+			policy := &URLPolicy{AllowedDomains: []string{"example.com"}, DeniedDomains: []string{"admin.example.com"}}
+
+			err := policy.Check(ctx, "https://admin.example.com/users")
+
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, `matches denied pattern "admin.example.com"`)
+		})
+
+		Convey("It should block private networks by address", func() {
+			policy := &URLPolicy{BlockPrivateNetworks: true}
+
+			for _, rawURL := range []string{
+				"http://localhost:8080",
+				"http://127.0.0.1",
+				"http://10.1.2.3",
+				"http://169.254.169.254/latest/meta-data",
+				"http://100.64.0.1",
+				"http://[::1]:9222",
+				"http://0.0.0.0",
+			} {
+				So(policy.Check(ctx, rawURL), ShouldNotBeNil)
+			}
+			So(policy.Check(ctx, "http://93.184.216.34"), ShouldBeNil)
+		})
+	})
+}
+
+func TestMatchDomain(t *testing.T) {
+	Convey("Given domain patterns", t, func() {
+		So(matchDomain("example.com", "example.com"), ShouldBeTrue)
+		So(matchDomain("Example.com.", "a.b.example.com"), ShouldBeTrue)
+		So(matchDomain("*.example.com", "example.com"), ShouldBeFalse)
+		So(matchDomain("*.example.com", "a.example.com"), ShouldBeTrue)
+		So(matchDomain("example.com", "badexample.com"), ShouldBeFalse)
+		So(matchDomain(" ", "example.com"), ShouldBeFalse)
+	})
+}
+
+func TestParseRobots(t *testing.T) {
+	Convey("Given a robots.txt with a group for us and a wildcard group", t, func() {
+		rules := parseRobots(strings.NewReader(`
+# Comments are ignored
+User-agent: *
+Disallow: /
+
+User-agent: googlebot
+User-agent: mcp-devops-bridge
+Disallow: /private   # trailing comment
+Allow: /private/public
+Disallow: /*.pdf$
+Disallow:
+`), &robotsRules{})
+
+		Convey("It should use the group naming our user agent", func() {
+			So(rules.allows("/"), ShouldBeTrue)
+			So(rules.allows("/docs"), ShouldBeTrue)
+			So(rules.allows("/private"), ShouldBeFalse)
+			So(rules.allows("/private/keys"), ShouldBeFalse)
+		})
+
+		Convey("It should let the most specific rule win", func() {
+			So(rules.allows("/private/public/index.html"), ShouldBeTrue)
+		})
+
+		Convey("It should honour wildcards and end anchors", func() {
+			So(rules.allows("/reports/q1.pdf"), ShouldBeFalse)
+			So(rules.allows("/reports/q1.pdf?download=1"), ShouldBeTrue)
+		})
+	})
+
+	Convey("Given a robots.txt with only a wildcard group", t, func() {
+		rules := parseRobots(strings.NewReader("User-agent: *\nDisallow: /search\nAllow: /search/about\n"), &robotsRules{})
+
+		Convey("It should fall back to the wildcard group", func() {
+			So(rules.allows("/search?q=x"), ShouldBeFalse)
+			So(rules.allows("/search/about"), ShouldBeTrue)
+			So(rules.allows("/about"), ShouldBeTrue)
+		})
+	})
+
+	Convey("Given a robots.txt without rules", t, func() {
+		rules := parseRobots(strings.NewReader(""), &robotsRules{})
+
+		Convey("It should allow everything", func() {
+			So(rules.allows("/anything"), ShouldBeTrue)
+		})
+	})
+}
+
+func TestRobotsCache(t *testing.T) {
+	Convey("Given a site serving a robots.txt", t, func() {
+		fetches := 0
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/robots.txt" {
+				http.NotFound(w, r)
+				return
+			}
+			fetches++
+			_, _ = w.Write([]byte("User-agent: *\nDisallow: /admin\n"))
+		}))
+		defer server.Close()
+
+		cache := &robotsCache{client: server.Client(), sites: make(map[string]*robotsRules)}
+		check := func(path string) bool {
+			u, err := url.Parse(server.URL + path)
+			So(err, ShouldBeNil)
+			return cache.allowed(context.Background(), u)
+		}
+
+		Convey("It should apply the rules and fetch them once", func() {
+			So(check("/admin/users"), ShouldBeFalse)
+			So(check("/"), ShouldBeTrue)
+			So(fetches, ShouldEqual, 1)
+		})
+	})
+
+	Convey("Given a site without a robots.txt", t, func() {
+		server := httptest.NewServer(http.NotFoundHandler())
+		defer server.Close()
+
+		cache := &robotsCache{client: server.Client(), sites: make(map[string]*robotsRules)}
+		u, _ := url.Parse(server.URL + "/admin")
+
+		Convey("It should allow everything", func() {
+			So(cache.allowed(context.Background(), u), ShouldBeTrue)
+		})
+	})
+}
+
+func TestURLPolicyRobots(t *testing.T) {
+	Convey("Given a policy that respects robots.txt and a site that disallows /assets", t, func() {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte("User-agent: *\nDisallow: /assets\n"))
+		}))
+		defer server.Close()
+
+		saved := robots
+		robots = &robotsCache{client: server.Client(), sites: make(map[string]*robotsRules)}
+		defer func() { robots = saved }()
+		policy := &URLPolicy{RespectRobots: true}
+
+		Convey("It should apply robots.txt to pages but not to the resources they load", func() {
+			So(policy.Check(context.Background(), server.URL+"/assets/page.html"), ShouldNotBeNil)
+			So(policy.CheckSubresource(context.Background(), server.URL+"/assets/app.js"), ShouldBeNil)
+		})
+	})
+}
@@ -0,0 +1,446 @@
+package agents
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/openai/openai-go"
+)
+
+const (
+	// workflowPollInterval is how often a running step's agent is checked for completion.
+	workflowPollInterval = 2 * time.Second
+	// workflowDefaultStepTimeout bounds how long a step's agent may take to complete its task.
+	workflowDefaultStepTimeout = 30 * time.Minute
+)
+
+// WorkflowStatus is the overall status of a workflow.
+type WorkflowStatus string
+
+const (
+	WorkflowRunning   WorkflowStatus = "running"
+	WorkflowCompleted WorkflowStatus = "completed"
+	WorkflowFailed    WorkflowStatus = "failed"
+)
+
+// StepStatus is the status of a single workflow step.
+type StepStatus string
+
+const (
+	StepPending   StepStatus = "pending"
+	StepRunning   StepStatus = "running"
+	StepCompleted StepStatus = "completed"
+	StepFailed    StepStatus = "failed"
+	// StepSkipped is the status of a step that never ran because a step it depends on did not complete.
+	StepSkipped StepStatus = "skipped"
+)
+
+// stepReference matches {{steps.<id>.output}}, {{steps.<id>.output.<path>}} and {{steps.<id>.result}}
+// in step prompts.
+var stepReference = regexp.MustCompile(`\{\{\s*steps\.([A-Za-z0-9_-]+)\.(output|result)((?:\.[A-Za-z0-9_-]+)*)\s*\}\}`)
+
+var stepIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// WorkflowStep is one agent task in a workflow. The prompt may reference the results of the
+// steps it depends on, which are substituted when the step starts.
+type WorkflowStep struct {
+	ID             string   `json:"id"`
+	Template       string   `json:"template"`
+	Prompt         string   `json:"prompt"`
+	DependsOn      []string `json:"depends_on,omitempty"`
+	Temperature    *float64 `json:"temperature,omitempty"`
+	MaxIterations  int      `json:"max_iterations,omitempty"`
+	TimeoutSeconds int      `json:"timeout_seconds,omitempty"`
+}
+
+// StepResult is the outcome of a workflow step.
+type StepResult struct {
+	ID         string          `json:"id"`
+	Status     StepStatus      `json:"status"`
+	AgentID    string          `json:"agent_id,omitempty"`
+	Output     json.RawMessage `json:"output,omitempty"`
+	Result     string          `json:"result,omitempty"`
+	Error      string          `json:"error,omitempty"`
+	StartedAt  *time.Time      `json:"started_at,omitempty"`
+	FinishedAt *time.Time      `json:"finished_at,omitempty"`
+}
+
+// Workflow is a DAG of agent steps. Steps whose dependencies have completed run in parallel.
+type Workflow struct {
+	ID         string         `json:"id"`
+	Name       string         `json:"name,omitempty"`
+	Status     WorkflowStatus `json:"status"`
+	Steps      []*StepResult  `json:"steps"`
+	StartedAt  time.Time      `json:"started_at"`
+	FinishedAt *time.Time     `json:"finished_at,omitempty"`
+	keepAgents bool
+	steps      []*WorkflowStep // In topological order
+	results    map[string]*StepResult
+	done       chan struct{}
+	mu         sync.Mutex
+}
+
+// RunWorkflow validates the steps and starts the workflow in the background. Step agents are
+// shut down once their step finishes, unless keepAgents is set.
+func (m *AgentManager) RunWorkflow(name string, steps []*WorkflowStep, keepAgents bool) (*Workflow, error) {
+	ordered, err := m.validateWorkflow(steps)
+	if err != nil {
+		return nil, err
+	}
+
+	wf := &Workflow{
+		ID:         uuid.New().String(),
+		Name:       name,
+		Status:     WorkflowRunning,
+		StartedAt:  time.Now(),
+		keepAgents: keepAgents,
+		steps:      ordered,
+		results:    make(map[string]*StepResult, len(ordered)),
+		done:       make(chan struct{}),
+	}
+	for _, step := range steps {
+		result := &StepResult{ID: step.ID, Status: StepPending}
+		wf.Steps = append(wf.Steps, result)
+		wf.results[step.ID] = result
+	}
+
+	m.workflowsMu.Lock()
+	m.workflows[wf.ID] = wf
+	m.workflowsMu.Unlock()
+
+	go m.runWorkflow(wf)
+	return wf, nil
+}
+
+// GetWorkflow returns the workflow with the given ID.
+func (m *AgentManager) GetWorkflow(id string) (*Workflow, error) {
+	m.workflowsMu.Lock()
+	defer m.workflowsMu.Unlock()
+
+	wf, exists := m.workflows[id]
+	if !exists {
+		return nil, fmt.Errorf("workflow with ID %s not found", id)
+	}
+	return wf, nil
+}
+
+// Wait blocks until the workflow finishes or the timeout passes, and reports whether it finished.
+func (wf *Workflow) Wait(timeout time.Duration) bool {
+	select {
+	case <-wf.done:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+// Report renders the workflow's status and step results as JSON.
+func (wf *Workflow) Report() ([]byte, error) {
+	wf.mu.Lock()
+	defer wf.mu.Unlock()
+	return json.MarshalIndent(wf, "", "  ")
+}
+
+// validateWorkflow checks step IDs, dependencies, templates and prompt references, and returns the
+// steps in topological order.
+func (m *AgentManager) validateWorkflow(steps []*WorkflowStep) ([]*WorkflowStep, error) {
+	if len(steps) == 0 {
+		return nil, errors.New("a workflow needs at least one step")
+	}
+
+	byID := make(map[string]*WorkflowStep, len(steps))
+	for _, step := range steps {
+		if !stepIDPattern.MatchString(step.ID) {
+			return nil, fmt.Errorf("step ID %q must be non-empty and contain only letters, digits, '-' and '_'", step.ID)
+		}
+		if _, duplicate := byID[step.ID]; duplicate {
+			return nil, fmt.Errorf("duplicate step ID %s", step.ID)
+		}
+		if step.Template == "" || strings.TrimSpace(step.Prompt) == "" {
+			return nil, fmt.Errorf("step %s: 'template' and 'prompt' are required", step.ID)
+		}
+		if _, err := LoadTemplate(m.templateDir, step.Template); err != nil {
+			return nil, fmt.Errorf("step %s: %w", step.ID, err)
+		}
+		byID[step.ID] = step
+	}
+
+	for _, step := range steps {
+		// Repeated dependencies are dropped, so each one is counted once below.
+		distinct := make([]string, 0, len(step.DependsOn))
+		for _, dep := range step.DependsOn {
+			if _, exists := byID[dep]; !exists {
+				return nil, fmt.Errorf("step %s depends on unknown step %s", step.ID, dep)
+			}
+			if !containsString(distinct, dep) {
+				distinct = append(distinct, dep)
+			}
+		}
+		step.DependsOn = distinct
+		for _, match := range stepReference.FindAllStringSubmatch(step.Prompt, -1) {
+			if !containsString(step.DependsOn, match[1]) {
+				return nil, fmt.Errorf("step %s references %s, which is not in its depends_on", step.ID, match[0])
+			}
+		}
+	}
+
+	// Kahn's algorithm, keeping the given order among steps that are ready at the same time.
+	remaining := make(map[string]int, len(steps))
+	for _, step := range steps {
+		remaining[step.ID] = len(step.DependsOn)
+	}
+	ordered := make([]*WorkflowStep, 0, len(steps))
+	for len(ordered) < len(steps) {
+		progress := false
+		for _, step := range steps {
+			if remaining[step.ID] != 0 {
+				continue
+			}
+			remaining[step.ID] = -1
+			ordered = append(ordered, step)
+			progress = true
+			for _, other := range steps {
+				if containsString(other.DependsOn, step.ID) {
+					remaining[other.ID]--
+				}
+			}
+		}
+		if !progress {
+			var cyclic []string
+			for id, count := range remaining {
+				if count > 0 {
+					cyclic = append(cyclic, id)
+				}
+			}
+			sort.Strings(cyclic)
+			return nil, fmt.Errorf("the workflow has a dependency cycle; these steps can never start: %s", strings.Join(cyclic, ", "))
+		}
+	}
+	return ordered, nil
+}
+
+// runWorkflow starts every step whose dependencies have completed, skips steps whose
+// dependencies did not, and finishes once no step is left running.
+func (m *AgentManager) runWorkflow(wf *Workflow) {
+	finished := make(chan struct{})
+	running := 0
+
+	for {
+		wf.mu.Lock()
+		for _, step := range wf.steps {
+			result := wf.results[step.ID]
+			if result.Status != StepPending {
+				continue
+			}
+
+			ready := true
+			for _, dep := range step.DependsOn {
+				switch wf.results[dep].Status {
+				case StepCompleted:
+				case StepFailed, StepSkipped:
+					ready = false
+					result.Status = StepSkipped
+					result.Error = fmt.Sprintf("step %s did not complete", dep)
+				default:
+					ready = false
+				}
+				if result.Status == StepSkipped {
+					break
+				}
+			}
+			if !ready {
+				continue
+			}
+
+			now := time.Now()
+			result.Status = StepRunning
+			result.StartedAt = &now
+			prompt := wf.stepPrompt(step)
+			running++
+
+			go func(step *WorkflowStep, result *StepResult, prompt string) {
+				m.runWorkflowStep(wf, step, result, prompt)
+				finished <- struct{}{}
+			}(step, result, prompt)
+		}
+		wf.mu.Unlock()
+
+		if running == 0 {
+			break
+		}
+		<-finished
+		running--
+	}
+
+	wf.mu.Lock()
+	wf.Status = WorkflowCompleted
+	for _, result := range wf.Steps {
+		if result.Status != StepCompleted {
+			wf.Status = WorkflowFailed
+		}
+	}
+	now := time.Now()
+	wf.FinishedAt = &now
+	wf.mu.Unlock()
+	close(wf.done)
+}
+
+// runWorkflowStep launches the step's agent and waits for it to finish its task.
+func (m *AgentManager) runWorkflowStep(wf *Workflow, step *WorkflowStep, result *StepResult, prompt string) {
+	fail := func(err error) {
+		wf.mu.Lock()
+		defer wf.mu.Unlock()
+		now := time.Now()
+		result.Status = StepFailed
+		result.Error = err.Error()
+		result.FinishedAt = &now
+	}
+
+	agent, err := m.LaunchAgentFromTemplate(step.Template, prompt, step.Temperature, step.MaxIterations, nil)
+	if err != nil {
+		fail(fmt.Errorf("failed to launch agent: %w", err))
+		return
+	}
+
+	wf.mu.Lock()
+	result.AgentID = agent.ID
+	wf.mu.Unlock()
+
+	if !wf.keepAgents {
+		defer m.ShutdownAgent(agent.ID)
+	}
+
+	timeout := workflowDefaultStepTimeout
+	if step.TimeoutSeconds > 0 {
+		timeout = time.Duration(step.TimeoutSeconds) * time.Second
+	}
+	deadline := time.Now().Add(timeout)
+
+	ticker := time.NewTicker(workflowPollInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if _, err := m.GetAgentStatus(agent.ID); err != nil {
+			fail(errors.New("the agent was shut down before completing its task"))
+			return
+		}
+
+		state := agent.state()
+		status, err := stepOutcome(state, agent.outputSchema != nil)
+		switch status {
+		case StepCompleted:
+			wf.mu.Lock()
+			now := time.Now()
+			result.Status = StepCompleted
+			result.Output = state.Output
+			result.Result = lastAssistantText(state.Messages)
+			result.FinishedAt = &now
+			wf.mu.Unlock()
+			return
+		case StepFailed:
+			fail(err)
+			return
+		}
+
+		if time.Now().After(deadline) {
+			fail(fmt.Errorf("the agent did not complete its task within %s (status: %s)", timeout, state.Status))
+			return
+		}
+	}
+}
+
+// stepOutcome decides from the state of a step's agent whether the step has finished. Nobody
+// answers a step agent that waits for input, so waiting counts as finishing with its last text,
+// unless the step needs structured output, which only complete_task provides.
+func stepOutcome(state agentState, structured bool) (StepStatus, error) {
+	switch state.Status {
+	case StatusCompleted:
+		return StepCompleted, nil
+	case StatusFailed:
+		return StepFailed, fmt.Errorf("the agent failed: %s", state.Result)
+	case StatusWaiting:
+		if structured {
+			return StepFailed, errors.New("the agent stopped to wait for input without completing its task with the required output")
+		}
+		return StepCompleted, nil
+	}
+	return StepRunning, nil
+}
+
+// stepPrompt substitutes the step references in the step's prompt. When the prompt references no
+// step, the results of its dependencies are appended instead. The caller must hold wf.mu.
+func (wf *Workflow) stepPrompt(step *WorkflowStep) string {
+	if !stepReference.MatchString(step.Prompt) {
+		if len(step.DependsOn) == 0 {
+			return step.Prompt
+		}
+
+		var b strings.Builder
+		b.WriteString(step.Prompt)
+		b.WriteString("\n\nResults of the steps this task builds on:")
+		for _, dep := range step.DependsOn {
+			fmt.Fprintf(&b, "\n\n## %s\n%s", dep, stepValue(wf.results[dep], "output", ""))
+		}
+		return b.String()
+	}
+
+	return stepReference.ReplaceAllStringFunc(step.Prompt, func(reference string) string {
+		match := stepReference.FindStringSubmatch(reference)
+		return stepValue(wf.results[match[1]], match[2], strings.TrimPrefix(match[3], "."))
+	})
+}
+
+// stepValue renders a step's result for a prompt. The output of a step without structured output
+// is its final text; a path selects a nested field of the structured output.
+func stepValue(result *StepResult, kind, path string) string {
+	if kind == "result" || len(result.Output) == 0 {
+		return result.Result
+	}
+	if path == "" {
+		return string(result.Output)
+	}
+
+	var value interface{}
+	if err := json.Unmarshal(result.Output, &value); err != nil {
+		return ""
+	}
+	for _, key := range strings.Split(path, ".") {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return ""
+		}
+		value = object[key]
+	}
+
+	if text, ok := value.(string); ok {
+		return text
+	}
+	data, _ := json.Marshal(value)
+	return string(data)
+}
+
+// lastAssistantText returns the last non-empty text the agent's model produced.
+func lastAssistantText(messages []openai.ChatCompletionMessageParamUnion) string {
+	for i := len(messages) - 1; i >= 0; i-- {
+		if assistant := messages[i].OfAssistant; assistant != nil && assistant.Content.OfString.Value != "" {
+			return assistant.Content.OfString.Value
+		}
+	}
+	return ""
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,149 @@
+package agents
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/openai/openai-go"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestValidateWorkflow(t *testing.T) {
+	Convey("Given a manager with a template", t, func() {
+		dir := t.TempDir()
+		So(os.WriteFile(filepath.Join(dir, "worker.yaml"), []byte("system_prompt: You work.\n"), 0o644), ShouldBeNil)
+		m := &AgentManager{templateDir: dir}
+
+		step := func(id, prompt string, dependsOn ...string) *WorkflowStep {
+			return &WorkflowStep{ID: id, Template: "worker", Prompt: prompt, DependsOn: dependsOn}
+		}
+
+		Convey("It should order the steps topologically, keeping the given order among ready steps", func() {
+			ordered, err := m.validateWorkflow([]*WorkflowStep{
+				step("report", "Summarize {{steps.research.output}} and {{ steps.review.result }}", "research", "review"),
+				step("research", "Research"),
+				step("review", "Review {{steps.research.output.findings}}", "research"),
+				step("notes", "Take notes"),
+			})
+
+			So(err, ShouldBeNil)
+			var ids []string
+			for _, s := range ordered {
+				ids = append(ids, s.ID)
+			}
+			So(ids, ShouldResemble, []string{"research", "review", "notes", "report"})
+		})
+
+		Convey("It should count a repeated dependency once", func() {
+			ordered, err := m.validateWorkflow([]*WorkflowStep{step("b", "Use {{steps.a.output}}", "a", "a"), step("a", "x")})
+
+			So(err, ShouldBeNil)
+			So(ordered, ShouldHaveLength, 2)
+			So(ordered[0].ID, ShouldEqual, "a")
+			So(ordered[1].DependsOn, ShouldResemble, []string{"a"})
+		})
+
+		Convey("It should reject invalid workflows", func() {
+			for message, steps := range map[string][]*WorkflowStep{
+				"at least one step":                    nil,
+				"must be non-empty":                    {step("bad id", "x")},
+				"duplicate step ID a":                  {step("a", "x"), step("a", "y")},
+				"'template' and 'prompt' are required": {step("a", " ")},
+				"agent template missing not found":     {{ID: "a", Template: "missing", Prompt: "x"}},
+				"depends on unknown step b":            {step("a", "x", "b")},
+				"which is not in its depends_on":       {step("a", "x"), step("b", "Use {{steps.a.result}}")},
+				"these steps can never start: a, b":    {step("a", "x", "b"), step("b", "y", "a"), step("c", "z")},
+			} {
+				_, err := m.validateWorkflow(steps)
+
+				So(err, ShouldNotBeNil)
+				So(err.Error(), ShouldContainSubstring, message)
+			}
+		})
+	})
+}
+
+func TestStepPrompt(t *testing.T) {
+	Convey("Given a workflow whose first steps have finished", t, func() {
+		wf := &Workflow{results: map[string]*StepResult{
+			"research": {ID: "research", Output: json.RawMessage(`{"findings":{"count":3,"summary":"three bugs"}}`), Result: "Found three bugs."},
+			"notes":    {ID: "notes", Result: "Some notes."},
+		}}
+
+		Convey("It should substitute output, output paths and results", func() {
+			prompt := wf.stepPrompt(&WorkflowStep{
+				Prompt:    "Fix {{steps.research.output.findings.summary}} ({{ steps.research.output.findings.count }}), see {{steps.notes.output}}. Raw: {{steps.research.result}}. Missing: [{{steps.research.output.nope.deeper}}]",
+				DependsOn: []string{"research", "notes"},
+			})
+
+			So(prompt, ShouldEqual, "Fix three bugs (3), see Some notes.. Raw: Found three bugs.. Missing: []")
+		})
+
+		Convey("It should substitute the whole structured output", func() {
+			prompt := wf.stepPrompt(&WorkflowStep{Prompt: "Use {{steps.research.output}}", DependsOn: []string{"research"}})
+
+			So(prompt, ShouldEqual, `Use {"findings":{"count":3,"summary":"three bugs"}}`)
+		})
+
+		Convey("It should append the dependencies' results when the prompt references none", func() {
+			prompt := wf.stepPrompt(&WorkflowStep{Prompt: "Write the report.", DependsOn: []string{"notes"}})
+
+			So(prompt, ShouldEqual, "Write the report.\n\nResults of the steps this task builds on:\n\n## notes\nSome notes.")
+		})
+
+		Convey("It should leave the prompt of a step without dependencies alone", func() {
+			So(wf.stepPrompt(&WorkflowStep{Prompt: "Start."}), ShouldEqual, "Start.")
+		})
+	})
+}
+
+func TestStepOutcome(t *testing.T) {
+	Convey("Given the state of a step's agent", t, func() {
+		Convey("It should keep waiting while the agent works", func() {
+			for _, status := range []Status{StatusInitializing, StatusRunning, StatusAwaitingApproval} {
+				outcome, err := stepOutcome(agentState{Status: status}, false)
+				So(outcome, ShouldEqual, StepRunning)
+				So(err, ShouldBeNil)
+			}
+		})
+
+		Convey("It should complete when the agent completes", func() {
+			outcome, _ := stepOutcome(agentState{Status: StatusCompleted}, true)
+			So(outcome, ShouldEqual, StepCompleted)
+		})
+
+		Convey("It should fail with the agent's result when the agent fails", func() {
+			outcome, err := stepOutcome(agentState{Status: StatusFailed, Result: "out of iterations"}, false)
+			So(outcome, ShouldEqual, StepFailed)
+			So(err.Error(), ShouldEqual, "the agent failed: out of iterations")
+		})
+
+		Convey("It should finish when the agent waits for input", func() {
+			outcome, err := stepOutcome(agentState{Status: StatusWaiting}, false)
+			So(outcome, ShouldEqual, StepCompleted)
+			So(err, ShouldBeNil)
+
+			outcome, err = stepOutcome(agentState{Status: StatusWaiting}, true)
+			So(outcome, ShouldEqual, StepFailed)
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+func TestLastAssistantText(t *testing.T) {
+	Convey("Given a conversation", t, func() {
+		messages := []openai.ChatCompletionMessageParamUnion{
+			openai.UserMessage("Do it."),
+			openai.AssistantMessage("Done, the answer is 42."),
+			openai.AssistantMessage(""),
+			openai.ToolMessage("Task marked as complete.", "call-1"),
+		}
+
+		Convey("It should return the last non-empty assistant text", func() {
+			So(lastAssistantText(messages), ShouldEqual, "Done, the answer is 42.")
+			So(lastAssistantText(messages[:1]), ShouldBeEmpty)
+		})
+	})
+}
@@ -2,6 +2,7 @@ package azure
 
 import (
 	"os"
+	"strconv"
 
 	"github.com/microsoft/azure-devops-go-api/azuredevops/v7"
 	"github.com/theapemachine/mcp-server-devops-bridge/core"
@@ -13,6 +14,7 @@ type AzureDevOpsConfig struct {
 	PersonalAccessToken string
 	Project             string
 	Team                string
+	AllowDestroy        bool
 }
 
 type AzureProvider struct {
@@ -26,6 +28,7 @@ func NewAzureProvider() *AzureProvider {
 	pat := os.Getenv("AZDO_PAT")
 	project := os.Getenv("AZURE_DEVOPS_PROJECT")
 	team := os.Getenv("AZURE_DEVOPS_TEAM")
+	allowDestroy, _ := strconv.ParseBool(os.Getenv("AZURE_DEVOPS_ALLOW_DESTROY"))
 
 	if orgName == "" || pat == "" || project == "" || team == "" {
 		return &AzureProvider{
@@ -38,6 +41,7 @@ func NewAzureProvider() *AzureProvider {
 		PersonalAccessToken: pat,
 		Project:             project,
 		Team:                team,
+		AllowDestroy:        allowDestroy,
 	}
 
 	conn := azuredevops.NewPatConnection(config.OrganizationURL, config.PersonalAccessToken)
@@ -52,6 +56,7 @@ func NewAzureProvider() *AzureProvider {
 		PersonalAccessToken: config.PersonalAccessToken,
 		Project:             config.Project,
 		Team:                config.Team,
+		AllowDestroy:        config.AllowDestroy,
 	}
 
 	/*
@@ -66,17 +71,29 @@ func NewAzureProvider() *AzureProvider {
 		- `execute_wiql`: Execute a WIQL query on Azure DevOps, returning the results.
 	*/
 
+	// The tools that write rich text share one converter, so mentions are resolved once.
+	markdown := tools.NewMarkdownConverter(conn, toolsConfig)
+
 	// Initialize new modular tools
 	provider.registerTool(tools.NewAzureGetSprintsTool(conn, toolsConfig))
 	provider.registerTool(tools.NewAzureCreateSprintTool(conn, toolsConfig))
 	provider.registerTool(tools.NewAzureSprintItemsTool(conn, toolsConfig))
 	provider.registerTool(tools.NewAzureSprintOverviewTool(conn, toolsConfig))
 	provider.registerTool(tools.NewAzureGetWorkItemsTool(conn, toolsConfig))
-	provider.registerTool(tools.NewAzureCreateWorkItemsTool(conn, toolsConfig))
-	provider.registerTool(tools.NewAzureUpdateWorkItemsTool(conn, toolsConfig))
+	provider.registerTool(tools.NewAzureCreateWorkItemsTool(conn, toolsConfig, markdown))
+	provider.registerTool(tools.NewAzureUpdateWorkItemsTool(conn, toolsConfig, markdown))
+	provider.registerTool(tools.NewAzureBulkUpdateByQueryTool(conn, toolsConfig, markdown))
+	provider.registerTool(tools.NewAzureDeleteWorkItemsTool(conn, toolsConfig))
+	provider.registerTool(tools.NewAzureRecycleBinTool(conn, toolsConfig))
+	provider.registerTool(tools.NewAzureRestoreWorkItemsTool(conn, toolsConfig))
+	provider.registerTool(tools.NewAzureCloneWorkItemsTool(conn, toolsConfig))
 	provider.registerTool(tools.NewAzureExecuteWiqlTool(conn, toolsConfig))
 	provider.registerTool(tools.NewAzureSearchWorkItemsTool(conn, toolsConfig))
 	provider.registerTool(tools.NewAzureEnrichWorkItemTool(conn, toolsConfig))
+	provider.registerTool(tools.NewAzureWorkItemHistoryTool(conn, toolsConfig))
+	provider.registerTool(tools.NewAzureWorkItemTreeTool(conn, toolsConfig))
+	provider.registerTool(tools.NewAzureAnalyzeDependenciesTool(conn, toolsConfig))
+	provider.registerTool(tools.NewAzureFlowMetricsTool(conn, toolsConfig))
 	provider.registerTool(tools.NewAzureGetGitHubFileContentTool())
 
 	wikiTool := NewWikiTool(conn, config)
@@ -0,0 +1,631 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/microsoft/azure-devops-go-api/azuredevops/v7"
+	"github.com/microsoft/azure-devops-go-api/azuredevops/v7/workitemtracking"
+	"github.com/theapemachine/mcp-server-devops-bridge/core"
+)
+
+// defaultDoneStates are the states in which a work item no longer blocks its successors.
+var defaultDoneStates = []string{"DONE", "Done", "Closed", "Resolved", "Completed", "Removed"}
+
+// DependencyNode is a work item in the dependency graph. Predecessors have to finish before the
+// item can; successors wait for it.
+type DependencyNode struct {
+	ID            int     `json:"id"`
+	Title         string  `json:"title"`
+	Type          string  `json:"type"`
+	State         string  `json:"state"`
+	AssignedTo    string  `json:"assigned_to,omitempty"`
+	URL           string  `json:"url"`
+	RemainingWork float64 `json:"remaining_work,omitempty"`
+	Done          bool    `json:"done"`
+	External      bool    `json:"external,omitempty"` // Linked from the analyzed items, but not one of them
+	Predecessors  []int   `json:"predecessors,omitempty"`
+	Successors    []int   `json:"successors,omitempty"`
+}
+
+// BlockedItem is an unfinished work item waiting for unfinished predecessors.
+type BlockedItem struct {
+	ID        int    `json:"id"`
+	Title     string `json:"title"`
+	BlockedBy []int  `json:"blocked_by"`
+}
+
+// DependencyAnalysis is the result of analyzing a dependency graph.
+type DependencyAnalysis struct {
+	Items                     []*DependencyNode `json:"items"`
+	Dependencies              int               `json:"dependencies"`
+	Cycles                    [][]int           `json:"cycles"`
+	Blocked                   []BlockedItem     `json:"blocked"`
+	CriticalPath              []int             `json:"critical_path"`
+	CriticalPathRemainingWork float64           `json:"critical_path_remaining_work"`
+	Graph                     string            `json:"graph,omitempty"`
+}
+
+// AzureAnalyzeDependenciesTool analyzes the predecessor/successor links between work items.
+type AzureAnalyzeDependenciesTool struct {
+	handle mcp.Tool
+	client workitemtracking.Client
+	config AzureDevOpsConfig
+}
+
+// NewAzureAnalyzeDependenciesTool creates a new tool instance for analyzing work item dependencies.
+func NewAzureAnalyzeDependenciesTool(conn *azuredevops.Connection, config AzureDevOpsConfig) core.Tool {
+	client, err := workitemtracking.NewClient(context.Background(), conn)
+	if err != nil {
+		return nil
+	}
+
+	tool := &AzureAnalyzeDependenciesTool{
+		client: client,
+		config: config,
+	}
+
+	tool.handle = mcp.NewTool(
+		"azure_analyze_dependencies",
+		mcp.WithDescription("Build the dependency graph from the predecessor/successor links of work items, detect cycles, list items blocked by unfinished predecessors, compute the critical path by remaining work, and optionally export the graph as Mermaid or DOT."),
+		mcp.WithString(
+			"ids",
+			mcp.Description("Comma-separated list of work item IDs to analyze. One of ids, iteration_path or query is required."),
+		),
+		mcp.WithString(
+			"iteration_path",
+			mcp.Description("Analyze all work items in a sprint, by iteration path or @CurrentIteration"),
+		),
+		mcp.WithString(
+			"query",
+			mcp.Description("WIQL query selecting the work items to analyze"),
+		),
+		mcp.WithString(
+			"done_states",
+			mcp.Description(fmt.Sprintf("Comma-separated list of states in which an item no longer blocks its successors (default: %s)", strings.Join(defaultDoneStates, ","))),
+		),
+		mcp.WithString(
+			"graph_format",
+			mcp.Description("Also export the graph as a Mermaid flowchart or a Graphviz DOT digraph"),
+			mcp.Enum("mermaid", "dot"),
+		),
+		mcp.WithString("format", mcp.Description("Response format: 'text' (default) or 'json'")),
+	)
+
+	return tool
+}
+
+func (tool *AzureAnalyzeDependenciesTool) Handle() mcp.Tool {
+	return tool.handle
+}
+
+func (tool *AzureAnalyzeDependenciesTool) Handler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	ids, err := tool.selectIDs(ctx, request)
+	if err != nil {
+		return HandleError(err, "Failed to select the work items to analyze"), nil
+	}
+	if len(ids) == 0 {
+		return mcp.NewToolResultText("No work items found."), nil
+	}
+
+	doneStates := defaultDoneStates
+	if doneStr, _ := GetStringArg(request, "done_states"); doneStr != "" {
+		doneStates = strings.Split(doneStr, ",")
+	}
+
+	nodes, err := tool.buildGraph(ctx, ids, doneStates)
+	if err != nil {
+		return HandleError(err, "Failed to build the dependency graph"), nil
+	}
+
+	analysis := analyzeDependencies(nodes)
+
+	graphFormat, _ := GetStringArg(request, "graph_format")
+	switch strings.ToLower(graphFormat) {
+	case "mermaid":
+		analysis.Graph = dependencyMermaid(analysis)
+	case "dot":
+		analysis.Graph = dependencyDOT(analysis)
+	}
+
+	format, _ := GetStringArg(request, "format")
+	if strings.ToLower(format) == "json" {
+		jsonData, err := json.MarshalIndent(analysis, "", "  ")
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to serialize JSON response: %v", err)), nil
+		}
+		return mcp.NewToolResultText(string(jsonData)), nil
+	}
+
+	return mcp.NewToolResultText(formatDependencyAnalysisText(analysis, strings.ToLower(graphFormat))), nil
+}
+
+// selectIDs returns the IDs of the work items to analyze, given as ids, a sprint or a query.
+func (tool *AzureAnalyzeDependenciesTool) selectIDs(ctx context.Context, request mcp.CallToolRequest) ([]int, error) {
+	if idsStr, _ := GetStringArg(request, "ids"); idsStr != "" {
+		return ParseIDs(idsStr)
+	}
+
+	query, _ := GetStringArg(request, "query")
+	if iterationPath, _ := GetStringArg(request, "iteration_path"); query == "" && iterationPath != "" {
+		iteration := "@CurrentIteration"
+		if !strings.EqualFold(iterationPath, iteration) {
+			iteration = wiqlString(iterationPath)
+		}
+		query = "SELECT [System.Id] FROM WorkItems WHERE [System.TeamProject] = @project AND [System.IterationPath] = " + iteration
+	}
+	if query == "" {
+		return nil, fmt.Errorf("provide ids, an iteration_path or a WIQL query")
+	}
+
+	result, err := tool.client.QueryByWiql(ctx, workitemtracking.QueryByWiqlArgs{
+		Wiql:    &workitemtracking.Wiql{Query: &query},
+		Project: &tool.config.Project,
+		Team:    &tool.config.Team,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []int
+	if result.WorkItems != nil {
+		for _, ref := range *result.WorkItems {
+			ids = append(ids, *ref.Id)
+		}
+	}
+	return ids, nil
+}
+
+// buildGraph fetches the work items with their dependency links. Predecessors and successors
+// outside the set are fetched too, so their state is known, and marked as external.
+func (tool *AzureAnalyzeDependenciesTool) buildGraph(ctx context.Context, ids []int, doneStates []string) (map[int]*DependencyNode, error) {
+	workItems, err := fetchWorkItems(ctx, tool.client, tool.config.Project, ids, true)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := make(map[int]*DependencyNode, len(workItems))
+	var links [][2]int // Predecessor, successor
+	for _, workItem := range workItems {
+		node := dependencyNode(workItem, tool.config.OrganizationURL, doneStates)
+		nodes[node.ID] = node
+
+		if workItem.Relations == nil {
+			continue
+		}
+		for _, relation := range *workItem.Relations {
+			if relation.Rel == nil || relation.Url == nil {
+				continue
+			}
+			otherID, err := ExtractWorkItemIDFromURL(*relation.Url)
+			if err != nil {
+				continue
+			}
+			switch *relation.Rel {
+			case "System.LinkTypes.Dependency-Forward":
+				links = append(links, [2]int{node.ID, otherID})
+			case "System.LinkTypes.Dependency-Reverse":
+				links = append(links, [2]int{otherID, node.ID})
+			}
+		}
+	}
+
+	var externalIDs []int
+	for _, link := range links {
+		for _, id := range link {
+			if _, ok := nodes[id]; !ok && !slices.Contains(externalIDs, id) {
+				externalIDs = append(externalIDs, id)
+			}
+		}
+	}
+	if len(externalIDs) > 0 {
+		externals, err := fetchWorkItems(ctx, tool.client, tool.config.Project, externalIDs, false)
+		if err != nil {
+			return nil, err
+		}
+		for _, workItem := range externals {
+			node := dependencyNode(workItem, tool.config.OrganizationURL, doneStates)
+			node.External = true
+			nodes[node.ID] = node
+		}
+	}
+
+	for _, link := range links {
+		addDependency(nodes, link[0], link[1])
+	}
+	return nodes, nil
+}
+
+// dependencyNode extracts the fields of a work item needed for the dependency analysis.
+func dependencyNode(workItem workitemtracking.WorkItem, orgURL string, doneStates []string) *DependencyNode {
+	item := treeNode(workItem, orgURL)
+	node := &DependencyNode{
+		ID:            item.ID,
+		Title:         item.Title,
+		Type:          item.Type,
+		State:         item.State,
+		AssignedTo:    item.AssignedTo,
+		URL:           item.URL,
+		RemainingWork: item.RemainingWork,
+	}
+	for _, state := range doneStates {
+		if strings.EqualFold(strings.TrimSpace(state), node.State) {
+			node.Done = true
+		}
+	}
+	return node
+}
+
+// addDependency records that successor waits for predecessor. Both ends of a link report it, so
+// duplicates are ignored, as are links to items that could not be read.
+func addDependency(nodes map[int]*DependencyNode, predecessor, successor int) {
+	from, to := nodes[predecessor], nodes[successor]
+	if from == nil || to == nil || slices.Contains(from.Successors, successor) {
+		return
+	}
+	from.Successors = append(from.Successors, successor)
+	to.Predecessors = append(to.Predecessors, predecessor)
+}
+
+// analyzeDependencies finds the cycles, blocked items and critical path of a dependency graph.
+func analyzeDependencies(nodes map[int]*DependencyNode) *DependencyAnalysis {
+	analysis := &DependencyAnalysis{
+		Cycles:       [][]int{},
+		Blocked:      []BlockedItem{},
+		CriticalPath: []int{},
+	}
+
+	ids := make([]int, 0, len(nodes))
+	for id, node := range nodes {
+		ids = append(ids, id)
+		slices.Sort(node.Predecessors)
+		slices.Sort(node.Successors)
+		analysis.Dependencies += len(node.Successors)
+	}
+	slices.Sort(ids)
+	for _, id := range ids {
+		analysis.Items = append(analysis.Items, nodes[id])
+	}
+
+	analysis.Cycles = dependencyCycles(nodes, ids)
+
+	for _, id := range ids {
+		node := nodes[id]
+		if node.Done || node.External {
+			continue
+		}
+		var blockedBy []int
+		for _, predecessor := range node.Predecessors {
+			if !nodes[predecessor].Done {
+				blockedBy = append(blockedBy, predecessor)
+			}
+		}
+		if len(blockedBy) > 0 {
+			analysis.Blocked = append(analysis.Blocked, BlockedItem{ID: id, Title: node.Title, BlockedBy: blockedBy})
+		}
+	}
+
+	analysis.CriticalPath, analysis.CriticalPathRemainingWork = criticalPath(nodes, ids, analysis.Cycles)
+	return analysis
+}
+
+// dependencyCycles returns the strongly connected components with more than one item, found with
+// Tarjan's algorithm. Every item in such a component waits, indirectly, for itself.
+func dependencyCycles(nodes map[int]*DependencyNode, ids []int) [][]int {
+	cycles := [][]int{}
+	index := make(map[int]int)
+	lowLink := make(map[int]int)
+	onStack := make(map[int]bool)
+	var stack []int
+
+	var visit func(id int)
+	visit = func(id int) {
+		index[id] = len(index)
+		lowLink[id] = index[id]
+		stack = append(stack, id)
+		onStack[id] = true
+
+		for _, successor := range nodes[id].Successors {
+			if _, visited := index[successor]; !visited {
+				visit(successor)
+				lowLink[id] = min(lowLink[id], lowLink[successor])
+			} else if onStack[successor] {
+				lowLink[id] = min(lowLink[id], index[successor])
+			}
+		}
+
+		if lowLink[id] != index[id] {
+			return
+		}
+		var component []int
+		for {
+			top := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[top] = false
+			component = append(component, top)
+			if top == id {
+				break
+			}
+		}
+		if len(component) > 1 || slices.Contains(nodes[id].Successors, id) {
+			slices.Sort(component)
+			cycles = append(cycles, component)
+		}
+	}
+
+	for _, id := range ids {
+		if _, visited := index[id]; !visited {
+			visit(id)
+		}
+	}
+	slices.SortFunc(cycles, func(a, b []int) int { return a[0] - b[0] })
+	return cycles
+}
+
+// criticalPath returns the chain of unfinished items with the most remaining work, and that work.
+// Finished items no longer hold anything up, and links within a cycle are left out because a
+// cycle has no order.
+func criticalPath(nodes map[int]*DependencyNode, ids []int, cycles [][]int) ([]int, float64) {
+	cycleOf := make(map[int]int)
+	for i, cycle := range cycles {
+		for _, id := range cycle {
+			cycleOf[id] = i + 1
+		}
+	}
+	counts := func(from, to int) bool {
+		return !nodes[from].Done && !nodes[to].Done && (cycleOf[from] == 0 || cycleOf[from] != cycleOf[to])
+	}
+
+	// Kahn's algorithm visits the items in dependency order, so the longest path to each item is
+	// known before its successors are visited.
+	inDegree := make(map[int]int)
+	for _, id := range ids {
+		for _, successor := range nodes[id].Successors {
+			if counts(id, successor) {
+				inDegree[successor]++
+			}
+		}
+	}
+	var queue []int
+	for _, id := range ids {
+		if !nodes[id].Done && inDegree[id] == 0 {
+			queue = append(queue, id)
+		}
+	}
+
+	work := make(map[int]float64)
+	length := make(map[int]int)
+	previous := make(map[int]int)
+	end := 0
+
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		work[id] += nodes[id].RemainingWork
+		length[id]++
+
+		if end == 0 || work[id] > work[end] || (work[id] == work[end] && length[id] > length[end]) {
+			end = id
+		}
+
+		for _, successor := range nodes[id].Successors {
+			if !counts(id, successor) {
+				continue
+			}
+			if _, ok := previous[successor]; !ok || work[id] > work[successor] || (work[id] == work[successor] && length[id] > length[successor]) {
+				work[successor] = work[id]
+				length[successor] = length[id]
+				previous[successor] = id
+			}
+			if inDegree[successor]--; inDegree[successor] == 0 {
+				queue = append(queue, successor)
+			}
+		}
+	}
+
+	path := []int{}
+	if end == 0 {
+		return path, 0
+	}
+	for id := end; ; {
+		path = append([]int{id}, path...)
+		predecessor, ok := previous[id]
+		if !ok {
+			break
+		}
+		id = predecessor
+	}
+	return path, work[end]
+}
+
+// formatHours renders an amount of work without trailing zeros.
+func formatHours(hours float64) string {
+	return strconv.FormatFloat(hours, 'f', -1, 64) + "h"
+}
+
+// dependencyLabel describes a work item in a graph node.
+func dependencyLabel(node *DependencyNode) string {
+	label := fmt.Sprintf("#%d %s (%s", node.ID, node.Title, node.State)
+	if node.RemainingWork > 0 && !node.Done {
+		label += ", " + formatHours(node.RemainingWork)
+	}
+	return label + ")"
+}
+
+// dependencyMermaid renders the graph as a Mermaid flowchart, with the blocked, finished, external
+// and critical items styled.
+func dependencyMermaid(analysis *DependencyAnalysis) string {
+	var sb strings.Builder
+	sb.WriteString("flowchart LR\n")
+	for _, node := range analysis.Items {
+		label := strings.ReplaceAll(dependencyLabel(node), `"`, "#quot;")
+		fmt.Fprintf(&sb, "    wi%d[\"%s\"]\n", node.ID, label)
+	}
+
+	critical := criticalEdges(analysis.CriticalPath)
+	edge := 0
+	var criticalLinks []string
+	for _, node := range analysis.Items {
+		for _, successor := range node.Successors {
+			fmt.Fprintf(&sb, "    wi%d --> wi%d\n", node.ID, successor)
+			if critical[[2]int{node.ID, successor}] {
+				criticalLinks = append(criticalLinks, strconv.Itoa(edge))
+			}
+			edge++
+		}
+	}
+
+	sb.WriteString("    classDef done fill:#e6ffe6,stroke:#2e7d32\n")
+	sb.WriteString("    classDef blocked fill:#ffe6e6,stroke:#c62828\n")
+	sb.WriteString("    classDef external stroke-dasharray:4 4\n")
+	sb.WriteString("    classDef critical stroke:#c62828,stroke-width:3px\n")
+	classes := dependencyClasses(analysis)
+	for _, class := range []string{"done", "blocked", "external", "critical"} {
+		ids := classes[class]
+		if len(ids) == 0 {
+			continue
+		}
+		names := make([]string, len(ids))
+		for i, id := range ids {
+			names[i] = fmt.Sprintf("wi%d", id)
+		}
+		fmt.Fprintf(&sb, "    class %s %s\n", strings.Join(names, ","), class)
+	}
+	if len(criticalLinks) > 0 {
+		fmt.Fprintf(&sb, "    linkStyle %s stroke:#c62828,stroke-width:3px\n", strings.Join(criticalLinks, ","))
+	}
+	return sb.String()
+}
+
+// dependencyDOT renders the graph as a Graphviz digraph, styled like the Mermaid flowchart.
+func dependencyDOT(analysis *DependencyAnalysis) string {
+	blocked := make(map[int]bool)
+	for _, item := range analysis.Blocked {
+		blocked[item.ID] = true
+	}
+	onPath := make(map[int]bool)
+	for _, id := range analysis.CriticalPath {
+		onPath[id] = true
+	}
+
+	var sb strings.Builder
+	sb.WriteString("digraph dependencies {\n    rankdir=LR;\n    node [shape=box];\n")
+	for _, node := range analysis.Items {
+		attributes := []string{"label=" + strconv.Quote(dependencyLabel(node))}
+		var styles []string
+		switch {
+		case node.Done:
+			styles = append(styles, "filled")
+			attributes = append(attributes, `fillcolor="#e6ffe6"`)
+		case blocked[node.ID]:
+			styles = append(styles, "filled")
+			attributes = append(attributes, `fillcolor="#ffe6e6"`)
+		}
+		if node.External {
+			styles = append(styles, "dashed")
+		}
+		if len(styles) > 0 {
+			attributes = append(attributes, "style="+strconv.Quote(strings.Join(styles, ",")))
+		}
+		if onPath[node.ID] {
+			attributes = append(attributes, `color="#c62828"`, `penwidth=3`)
+		}
+		fmt.Fprintf(&sb, "    wi%d [%s];\n", node.ID, strings.Join(attributes, ", "))
+	}
+
+	critical := criticalEdges(analysis.CriticalPath)
+	for _, node := range analysis.Items {
+		for _, successor := range node.Successors {
+			if critical[[2]int{node.ID, successor}] {
+				fmt.Fprintf(&sb, "    wi%d -> wi%d [color=\"#c62828\", penwidth=3];\n", node.ID, successor)
+				continue
+			}
+			fmt.Fprintf(&sb, "    wi%d -> wi%d;\n", node.ID, successor)
+		}
+	}
+	sb.WriteString("}\n")
+	return sb.String()
+}
+
+// criticalEdges returns the links between consecutive items on the critical path.
+func criticalEdges(path []int) map[[2]int]bool {
+	edges := make(map[[2]int]bool)
+	for i := 1; i < len(path); i++ {
+		edges[[2]int{path[i-1], path[i]}] = true
+	}
+	return edges
+}
+
+// dependencyClasses groups the item IDs by the Mermaid class they are styled with.
+func dependencyClasses(analysis *DependencyAnalysis) map[string][]int {
+	classes := map[string][]int{"critical": analysis.CriticalPath}
+	for _, item := range analysis.Blocked {
+		classes["blocked"] = append(classes["blocked"], item.ID)
+	}
+	for _, node := range analysis.Items {
+		if node.Done {
+			classes["done"] = append(classes["done"], node.ID)
+		}
+		if node.External {
+			classes["external"] = append(classes["external"], node.ID)
+		}
+	}
+	return classes
+}
+
+// formatDependencyAnalysisText summarizes the analysis, with the graph in a fenced block.
+func formatDependencyAnalysisText(analysis *DependencyAnalysis, graphFormat string) string {
+	nodes := make(map[int]*DependencyNode, len(analysis.Items))
+	for _, node := range analysis.Items {
+		nodes[node.ID] = node
+	}
+	describe := func(id int) string {
+		node := nodes[id]
+		return fmt.Sprintf("#%d %s (%s)", id, node.Title, node.State)
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "## Dependency Analysis (%d work items, %d dependencies)\n", len(analysis.Items), analysis.Dependencies)
+
+	sb.WriteString("\n### Cycles\n")
+	if len(analysis.Cycles) == 0 {
+		sb.WriteString("No cycles found.\n")
+	}
+	for _, cycle := range analysis.Cycles {
+		names := make([]string, len(cycle))
+		for i, id := range cycle {
+			names[i] = describe(id)
+		}
+		fmt.Fprintf(&sb, "- %s depend on each other\n", strings.Join(names, ", "))
+	}
+
+	sb.WriteString("\n### Blocked Items\n")
+	if len(analysis.Blocked) == 0 {
+		sb.WriteString("No items are waiting for unfinished predecessors.\n")
+	}
+	for _, item := range analysis.Blocked {
+		names := make([]string, len(item.BlockedBy))
+		for i, id := range item.BlockedBy {
+			names[i] = describe(id)
+		}
+		fmt.Fprintf(&sb, "- %s is blocked by %s\n", describe(item.ID), strings.Join(names, ", "))
+	}
+
+	fmt.Fprintf(&sb, "\n### Critical Path (%s remaining)\n", formatHours(analysis.CriticalPathRemainingWork))
+	if len(analysis.CriticalPath) == 0 {
+		sb.WriteString("All items are finished.\n")
+	}
+	for i, id := range analysis.CriticalPath {
+		fmt.Fprintf(&sb, "%d. %s, %s remaining\n", i+1, describe(id), formatHours(nodes[id].RemainingWork))
+	}
+
+	if analysis.Graph != "" {
+		fmt.Fprintf(&sb, "\n### Graph\n```%s\n%s```\n", graphFormat, analysis.Graph)
+	}
+	return sb.String()
+}
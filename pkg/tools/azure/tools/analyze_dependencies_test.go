@@ -0,0 +1,77 @@
+package tools
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func dependencyGraph(items []*DependencyNode, links [][2]int) map[int]*DependencyNode {
+	nodes := make(map[int]*DependencyNode, len(items))
+	for _, item := range items {
+		nodes[item.ID] = item
+	}
+	for _, link := range links {
+		addDependency(nodes, link[0], link[1])
+	}
+	return nodes
+}
+
+func TestAnalyzeDependencies(t *testing.T) {
+	Convey("Given a dependency graph", t, func() {
+		nodes := dependencyGraph([]*DependencyNode{
+			{ID: 1, Title: "Schema", State: "DONE", Done: true, RemainingWork: 8},
+			{ID: 2, Title: "API", State: "DOING", RemainingWork: 5},
+			{ID: 3, Title: "UI", State: "TODO", RemainingWork: 3},
+			{ID: 4, Title: "Docs", State: "TODO", RemainingWork: 1},
+			{ID: 5, Title: "Release", State: "TODO", RemainingWork: 2},
+			{ID: 6, Title: "Infra", State: "TODO", RemainingWork: 4, External: true},
+		}, [][2]int{{1, 2}, {2, 3}, {2, 3}, {3, 5}, {4, 5}, {6, 4}})
+
+		Convey("Duplicate links are recorded once", func() {
+			So(nodes[2].Successors, ShouldResemble, []int{3})
+			So(nodes[5].Predecessors, ShouldResemble, []int{3, 4})
+		})
+
+		Convey("Without cycles", func() {
+			analysis := analyzeDependencies(nodes)
+
+			So(analysis.Dependencies, ShouldEqual, 5)
+			So(analysis.Cycles, ShouldBeEmpty)
+
+			Convey("Items waiting for unfinished predecessors are blocked", func() {
+				So(analysis.Blocked, ShouldResemble, []BlockedItem{
+					{ID: 3, Title: "UI", BlockedBy: []int{2}},
+					{ID: 4, Title: "Docs", BlockedBy: []int{6}},
+					{ID: 5, Title: "Release", BlockedBy: []int{3, 4}},
+				})
+			})
+
+			Convey("The critical path follows the most remaining work and skips finished items", func() {
+				So(analysis.CriticalPath, ShouldResemble, []int{2, 3, 5})
+				So(analysis.CriticalPathRemainingWork, ShouldEqual, 10)
+			})
+
+			Convey("The graph is exported with the critical path highlighted", func() {
+				mermaid := dependencyMermaid(analysis)
+				So(mermaid, ShouldContainSubstring, "    wi2[\"#2 API (DOING, 5h)\"]\n")
+				So(mermaid, ShouldContainSubstring, "    wi1 --> wi2\n")
+				So(mermaid, ShouldContainSubstring, "    class wi2,wi3,wi5 critical\n")
+				So(mermaid, ShouldContainSubstring, "    linkStyle 1,2 stroke:#c62828,stroke-width:3px\n")
+
+				dot := dependencyDOT(analysis)
+				So(dot, ShouldContainSubstring, "    wi2 -> wi3 [color=\"#c62828\", penwidth=3];\n")
+				So(dot, ShouldContainSubstring, "    wi4 -> wi5;\n")
+				So(dot, ShouldContainSubstring, `wi6 [label="#6 Infra (TODO, 4h)", style="dashed"];`)
+			})
+		})
+
+		Convey("With a cycle", func() {
+			addDependency(nodes, 5, 2)
+			analysis := analyzeDependencies(nodes)
+
+			So(analysis.Cycles, ShouldResemble, [][]int{{2, 3, 5}})
+			So(analysis.CriticalPath, ShouldResemble, []int{6, 4, 5})
+		})
+	})
+}
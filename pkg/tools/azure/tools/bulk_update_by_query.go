@@ -0,0 +1,309 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/microsoft/azure-devops-go-api/azuredevops/v7"
+	"github.com/microsoft/azure-devops-go-api/azuredevops/v7/workitemtracking"
+	"github.com/theapemachine/mcp-server-devops-bridge/core"
+)
+
+const (
+	defaultBulkMaxItems  = 100
+	defaultBulkBatchSize = 20
+	maxBulkBatchSize     = 200 // The most work items GetWorkItems returns in one call
+)
+
+// BulkUpdateFilter is a structured alternative to a WIQL query. All conditions must match.
+type BulkUpdateFilter struct {
+	States     []string `json:"states,omitempty"`
+	Types      []string `json:"types,omitempty"`
+	Iteration  string   `json:"iteration,omitempty"` // Iteration path or @CurrentIteration
+	Area       string   `json:"area,omitempty"`      // Matches the area and everything under it
+	AssignedTo string   `json:"assigned_to,omitempty"`
+	Tags       []string `json:"tags,omitempty"`
+}
+
+// AzureBulkUpdateByQueryTool applies one change set to every work item matching a query.
+type AzureBulkUpdateByQueryTool struct {
+	handle  mcp.Tool
+	client  workitemtracking.Client
+	config  AzureDevOpsConfig
+	updater *AzureUpdateWorkItemsTool
+}
+
+// NewAzureBulkUpdateByQueryTool creates a new tool instance for query-driven bulk updates.
+func NewAzureBulkUpdateByQueryTool(conn *azuredevops.Connection, config AzureDevOpsConfig, markdown *MarkdownConverter) core.Tool {
+	client, err := workitemtracking.NewClient(context.Background(), conn)
+	if err != nil {
+		return nil
+	}
+
+	tool := &AzureBulkUpdateByQueryTool{
+		client:  client,
+		config:  config,
+		updater: &AzureUpdateWorkItemsTool{client: client, config: config, markdown: markdown},
+	}
+
+	tool.handle = mcp.NewTool(
+		"azure_bulk_update_by_query",
+		mcp.WithDescription("Apply the same field, relation and comment changes to every work item matching a WIQL query or a structured filter, in batches, with a safety cap on the number of items."),
+		mcp.WithString(
+			"query",
+			mcp.Description("WIQL query selecting the work items to update, e.g. SELECT [System.Id] FROM WorkItems WHERE [System.State] = 'DOING' AND [System.IterationPath] = @CurrentIteration. Either query or filter_json is required."),
+		),
+		mcp.WithString(
+			"filter_json",
+			mcp.Description("Structured filter instead of a query, as a JSON object with optional 'states' and 'types' (arrays), 'iteration' (path or @CurrentIteration), 'area' (matches everything under it), 'assigned_to' and 'tags' (array, all must be present)."),
+		),
+		mcp.WithString(
+			"changes_json",
+			mcp.Required(),
+			mcp.Description("The change set as a JSON object, in the same shape as an item of azure_update_work_items without 'id': 'fields_to_update' (map of field names to new values), and optionally 'comment' and 'add_relations'."),
+		),
+		mcp.WithNumber(
+			"max_items",
+			mcp.Description(fmt.Sprintf("Safety cap: if more work items match, nothing is updated (default: %d)", defaultBulkMaxItems)),
+		),
+		mcp.WithNumber(
+			"batch_size",
+			mcp.Description(fmt.Sprintf("Number of work items updated concurrently per batch (default: %d, max: %d)", defaultBulkBatchSize, maxBulkBatchSize)),
+		),
+		mcp.WithString(
+			"content_format",
+			mcp.Description(contentFormatDescription),
+			mcp.Enum(ContentFormatHTML, ContentFormatMarkdown),
+		),
+		mcp.WithString(
+			"dry_run",
+			mcp.Description("Validate the changes against every matching item without saving anything"),
+			mcp.Enum("true", "false"),
+		),
+		mcp.WithString("format", mcp.Description("Response format: 'text' (default) or 'json'")),
+	)
+
+	return tool
+}
+
+func (tool *AzureBulkUpdateByQueryTool) Handle() mcp.Tool {
+	return tool.handle
+}
+
+func (tool *AzureBulkUpdateByQueryTool) Handler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	changesJSON, err := GetStringArg(request, "changes_json")
+	if err != nil {
+		return mcp.NewToolResultError("Missing required parameter: changes_json"), nil
+	}
+
+	var changes WorkItemUpdateDefinition
+	if err := json.Unmarshal([]byte(changesJSON), &changes); err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Invalid JSON for changes_json: %v. Expected an object with 'fields_to_update', 'comment' and/or 'add_relations'.", err)), nil
+	}
+	if len(changes.FieldsToUpdate) == 0 && changes.Comment == "" && len(changes.AddRelations) == 0 {
+		return mcp.NewToolResultError("changes_json contains no changes: provide 'fields_to_update', 'comment' or 'add_relations'."), nil
+	}
+
+	contentFormat, _ := GetStringArg(request, "content_format")
+	tool.updater.convertContent(ctx, &changes, contentFormat)
+
+	query, _ := GetStringArg(request, "query")
+	if query == "" {
+		filterJSON, _ := GetStringArg(request, "filter_json")
+		if filterJSON == "" {
+			return mcp.NewToolResultError("Provide either a WIQL query or filter_json to select the work items to update."), nil
+		}
+		var filter BulkUpdateFilter
+		if err := json.Unmarshal([]byte(filterJSON), &filter); err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Invalid JSON for filter_json: %v", err)), nil
+		}
+		if query, err = filterQuery(filter); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+	}
+
+	maxItems := defaultBulkMaxItems
+	if value, err := GetIntArg(request, "max_items"); err == nil && value > 0 {
+		maxItems = value
+	}
+	batchSize := defaultBulkBatchSize
+	if value, err := GetIntArg(request, "batch_size"); err == nil && value > 0 {
+		batchSize = min(value, maxBulkBatchSize)
+	}
+	dryRunStr, _ := GetStringArg(request, "dry_run")
+	dryRun := strings.ToLower(dryRunStr) == "true"
+	format, _ := GetStringArg(request, "format")
+
+	ids, exceeded, err := queryIDsCapped(ctx, tool.client, tool.config, query, maxItems)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to query work items: %v\n\nQuery: %s", err, query)), nil
+	}
+	if len(ids) == 0 {
+		return mcp.NewToolResultText(fmt.Sprintf("No work items match, nothing was updated.\n\nQuery: %s", query)), nil
+	}
+	if exceeded {
+		return mcp.NewToolResultError(fmt.Sprintf("More than %d work items match, so nothing was updated. Narrow the query or raise max_items.\n\nQuery: %s", maxItems, query)), nil
+	}
+
+	results := make([]map[string]any, 0, len(ids))
+	texts := make([]string, 0, len(ids))
+	for start := 0; start < len(ids); start += batchSize {
+		batch := ids[start:min(start+batchSize, len(ids))]
+		batchResults, batchTexts := tool.updateBatch(ctx, batch, changes, dryRun)
+		results = append(results, batchResults...)
+		texts = append(texts, batchTexts...)
+	}
+
+	counts := make(map[string]int)
+	for _, result := range results {
+		counts[bulkOutcome(result)]++
+	}
+
+	var summary string
+	if dryRun {
+		summary = fmt.Sprintf("Dry run on %d matching work items: %d valid, %d invalid, %d conflicts, %d failed. Nothing was changed.",
+			len(ids), counts["valid"], counts["invalid"], counts["conflict"], counts["failed"])
+	} else {
+		summary = fmt.Sprintf("Bulk update of %d matching work items: %d updated, %d conflicts, %d skipped, %d failed.",
+			len(ids), counts["updated"], counts["conflict"], counts["skipped"], counts["failed"])
+	}
+
+	if strings.ToLower(format) == "json" {
+		jsonData, err := json.MarshalIndent(map[string]any{
+			"query":   query,
+			"matched": len(ids),
+			"dry_run": dryRun,
+			"counts":  counts,
+			"items":   results,
+		}, "", "  ")
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to serialize JSON response: %v", err)), nil
+		}
+		return mcp.NewToolResultText(string(jsonData)), nil
+	}
+
+	return mcp.NewToolResultText(summary + "\n\n" + strings.Join(texts, "\n---\n")), nil
+}
+
+// updateBatch applies the change set to a batch of work items concurrently. Each update expects
+// the revision the item had when the batch was read, so an item changed in the meantime is
+// reported as a conflict instead of being overwritten.
+func (tool *AzureBulkUpdateByQueryTool) updateBatch(ctx context.Context, ids []int, changes WorkItemUpdateDefinition, dryRun bool) ([]map[string]any, []string) {
+	results := make([]map[string]any, len(ids))
+	texts := make([]string, len(ids))
+
+	revs := make(map[int]int, len(ids))
+	workItems, err := tool.client.GetWorkItems(ctx, workitemtracking.GetWorkItemsArgs{
+		Ids:         &ids,
+		Project:     &tool.config.Project,
+		Fields:      &[]string{"System.Id"},
+		ErrorPolicy: &workitemtracking.WorkItemErrorPolicyValues.Omit,
+	})
+	if err != nil {
+		for i, id := range ids {
+			errMsg := fmt.Sprintf("Failed to read work item #%d before updating: %v", id, err)
+			results[i], texts[i] = map[string]any{"id": id, "error": errMsg}, errMsg
+		}
+		return results, texts
+	}
+	for _, workItem := range *workItems {
+		if workItem.Id != nil && workItem.Rev != nil {
+			revs[*workItem.Id] = *workItem.Rev
+		}
+	}
+
+	var wg sync.WaitGroup
+	for i, id := range ids {
+		rev, found := revs[id]
+		if !found {
+			errMsg := fmt.Sprintf("Work item #%d no longer exists or is not accessible.", id)
+			results[i], texts[i] = map[string]any{"id": id, "error": errMsg}, errMsg
+			continue
+		}
+
+		itemDef := changes
+		itemDef.ID = id
+		itemDef.Rev = rev
+
+		wg.Add(1)
+		go func(i int, itemDef WorkItemUpdateDefinition) {
+			defer wg.Done()
+			results[i], texts[i] = tool.updater.updateItem(ctx, itemDef, dryRun)
+		}(i, itemDef)
+	}
+	wg.Wait()
+
+	return results, texts
+}
+
+// bulkOutcome classifies the result of updating one work item.
+func bulkOutcome(result map[string]any) string {
+	switch {
+	case result["error"] != nil:
+		return "failed"
+	case result["status"] == "conflict":
+		return "conflict"
+	case result["status"] == "skipped":
+		return "skipped"
+	case result["dry_run"] == true && result["conflict"] != nil:
+		return "conflict"
+	case result["dry_run"] == true && result["valid"] == true:
+		return "valid"
+	case result["dry_run"] == true:
+		return "invalid"
+	}
+	return "updated"
+}
+
+// filterQuery builds the WIQL query for a structured filter.
+func filterQuery(filter BulkUpdateFilter) (string, error) {
+	var conditions []string
+
+	if len(filter.States) > 0 {
+		conditions = append(conditions, fmt.Sprintf("[System.State] IN (%s)", wiqlList(filter.States)))
+	}
+	if len(filter.Types) > 0 {
+		conditions = append(conditions, fmt.Sprintf("[System.WorkItemType] IN (%s)", wiqlList(filter.Types)))
+	}
+	if filter.Iteration != "" {
+		if strings.HasPrefix(filter.Iteration, "@") {
+			conditions = append(conditions, "[System.IterationPath] = "+filter.Iteration)
+		} else {
+			conditions = append(conditions, "[System.IterationPath] = "+wiqlString(filter.Iteration))
+		}
+	}
+	if filter.Area != "" {
+		conditions = append(conditions, "[System.AreaPath] UNDER "+wiqlString(filter.Area))
+	}
+	if filter.AssignedTo != "" {
+		conditions = append(conditions, "[System.AssignedTo] = "+wiqlString(filter.AssignedTo))
+	}
+	for _, tag := range filter.Tags {
+		conditions = append(conditions, "[System.Tags] CONTAINS "+wiqlString(tag))
+	}
+
+	if len(conditions) == 0 {
+		return "", fmt.Errorf("filter_json has no conditions; refusing to update every work item in the project")
+	}
+
+	conditions = append([]string{"[System.TeamProject] = @project"}, conditions...)
+	return "SELECT [System.Id] FROM WorkItems WHERE " + strings.Join(conditions, " AND ") + " ORDER BY [System.Id]", nil
+}
+
+// wiqlString quotes a value as a WIQL string literal.
+func wiqlString(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+}
+
+// wiqlList quotes values as a comma-separated list of WIQL string literals.
+func wiqlList(values []string) string {
+	quoted := make([]string, len(values))
+	for i, value := range values {
+		quoted[i] = wiqlString(strings.TrimSpace(value))
+	}
+	return strings.Join(quoted, ", ")
+}
@@ -0,0 +1,42 @@
+package tools
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestFilterQuery(t *testing.T) {
+	Convey("Given a structured filter", t, func() {
+		Convey("Every condition is combined and values are quoted", func() {
+			query, err := filterQuery(BulkUpdateFilter{
+				States:    []string{"DOING", " REVIEW"},
+				Iteration: "@CurrentIteration",
+				Area:      "Project\\Team's Area",
+				Tags:      []string{"frontend"},
+			})
+			So(err, ShouldBeNil)
+			So(query, ShouldEqual, "SELECT [System.Id] FROM WorkItems WHERE [System.TeamProject] = @project"+
+				" AND [System.State] IN ('DOING', 'REVIEW')"+
+				" AND [System.IterationPath] = @CurrentIteration"+
+				" AND [System.AreaPath] UNDER 'Project\\Team''s Area'"+
+				" AND [System.Tags] CONTAINS 'frontend'"+
+				" ORDER BY [System.Id]")
+		})
+
+		Convey("An empty filter is refused rather than matching every work item", func() {
+			_, err := filterQuery(BulkUpdateFilter{})
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+func TestBulkOutcome(t *testing.T) {
+	Convey("Given the results of updating work items", t, func() {
+		So(bulkOutcome(map[string]any{"id": 1, "rev": 4}), ShouldEqual, "updated")
+		So(bulkOutcome(map[string]any{"id": 1, "error": "boom"}), ShouldEqual, "failed")
+		So(bulkOutcome(map[string]any{"id": 1, "status": "conflict"}), ShouldEqual, "conflict")
+		So(bulkOutcome(dryRunResult(map[string]any{"id": 1}, nil)), ShouldEqual, "valid")
+		So(bulkOutcome(dryRunResult(map[string]any{"id": 1}, []string{"TF401320"})), ShouldEqual, "invalid")
+	})
+}
@@ -0,0 +1,435 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/microsoft/azure-devops-go-api/azuredevops/v7"
+	"github.com/microsoft/azure-devops-go-api/azuredevops/v7/webapi"
+	"github.com/microsoft/azure-devops-go-api/azuredevops/v7/workitemtracking"
+	"github.com/theapemachine/mcp-server-devops-bridge/core"
+)
+
+// clonePageSize is the number of work items fetched per call while walking a hierarchy.
+const clonePageSize = 200
+
+// clonedFields are the fields copied to a clone, besides any custom fields. State, assignment
+// and history are left out, so clones start fresh.
+var clonedFields = []string{
+	"System.Title",
+	"System.Description",
+	"System.Tags",
+	"System.AreaPath",
+	"System.IterationPath",
+	"Microsoft.VSTS.Common.Priority",
+	"Microsoft.VSTS.Common.AcceptanceCriteria",
+	"Microsoft.VSTS.Common.BusinessValue",
+	"Microsoft.VSTS.Common.Risk",
+	"Microsoft.VSTS.Common.ValueArea",
+	"Microsoft.VSTS.Scheduling.Effort",
+	"Microsoft.VSTS.Scheduling.OriginalEstimate",
+	"Microsoft.VSTS.Scheduling.StoryPoints",
+	"Microsoft.VSTS.TCM.ReproSteps",
+}
+
+// cloneOptions control how the fields of a clone differ from its source.
+type cloneOptions struct {
+	titlePrefix   string
+	iterationFrom string
+	iterationTo   string
+	areaFrom      string
+	areaTo        string
+}
+
+// cloneNode is a work item to clone, in the order clones are created: parents before children.
+type cloneNode struct {
+	source   workitemtracking.WorkItem
+	depth    int
+	parentID int // Source ID of the parent within the clone set, zero for roots
+}
+
+// ClonedWorkItemOutput is the outcome of cloning one work item.
+type ClonedWorkItemOutput struct {
+	SourceID      int    `json:"source_id"`
+	CloneID       int    `json:"clone_id,omitempty"`
+	Type          string `json:"type"`
+	Title         string `json:"title"`
+	AreaPath      string `json:"area_path,omitempty"`
+	IterationPath string `json:"iteration_path,omitempty"`
+	ParentID      int    `json:"parent_source_id,omitempty"`
+	URL           string `json:"url,omitempty"`
+	Error         string `json:"error,omitempty"`
+	depth         int
+}
+
+// AzureCloneWorkItemsTool copies work items, optionally with their whole hierarchy.
+type AzureCloneWorkItemsTool struct {
+	handle mcp.Tool
+	client workitemtracking.Client
+	config AzureDevOpsConfig
+}
+
+// NewAzureCloneWorkItemsTool creates a new tool instance for cloning work items.
+func NewAzureCloneWorkItemsTool(conn *azuredevops.Connection, config AzureDevOpsConfig) core.Tool {
+	client, err := workitemtracking.NewClient(context.Background(), conn)
+	if err != nil {
+		return nil
+	}
+
+	tool := &AzureCloneWorkItemsTool{
+		client: client,
+		config: config,
+	}
+
+	tool.handle = mcp.NewTool(
+		"azure_clone_work_items",
+		mcp.WithDescription("Deep clone work items in Azure DevOps, optionally with all their descendants. Parent/child and other links between the cloned items are remapped to the clones, iteration and area paths can be rewritten, and every clone gets a related link to the item it was copied from."),
+		mcp.WithString(
+			"ids",
+			mcp.Required(),
+			mcp.Description("Comma-separated list of work item IDs to clone"),
+		),
+		mcp.WithString(
+			"include_descendants",
+			mcp.Description("Also clone the children, grandchildren, etc. of the work items (default: false)"),
+			mcp.Enum("true", "false"),
+		),
+		mcp.WithString("title_prefix", mcp.Description("Text prepended to the title of every clone, e.g. '[Q3] '")),
+		mcp.WithString("iteration_from", mcp.Description("Iteration path prefix to replace with iteration_to, e.g. 'Project\\2024 Q2'. If omitted, every clone is moved to iteration_to.")),
+		mcp.WithString("iteration_to", mcp.Description("New iteration path (prefix) for the clones")),
+		mcp.WithString("area_from", mcp.Description("Area path prefix to replace with area_to. If omitted, every clone is moved to area_to.")),
+		mcp.WithString("area_to", mcp.Description("New area path (prefix) for the clones")),
+		mcp.WithString(
+			"dry_run",
+			mcp.Description("Show which work items would be cloned and how, and have Azure DevOps validate each clone, without creating anything"),
+			mcp.Enum("true", "false"),
+		),
+		mcp.WithString("format", mcp.Description("Response format: 'text' (default) or 'json'")),
+	)
+
+	return tool
+}
+
+func (tool *AzureCloneWorkItemsTool) Handle() mcp.Tool {
+	return tool.handle
+}
+
+func (tool *AzureCloneWorkItemsTool) Handler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	idsStr, err := GetStringArg(request, "ids")
+	if err != nil {
+		return mcp.NewToolResultError("Missing required parameter: ids"), nil
+	}
+	ids, err := ParseIDs(idsStr)
+	if err != nil {
+		return HandleError(err, "Invalid work item IDs"), nil
+	}
+
+	includeDescendantsStr, _ := GetStringArg(request, "include_descendants")
+	dryRunStr, _ := GetStringArg(request, "dry_run")
+	format, _ := GetStringArg(request, "format")
+
+	var options cloneOptions
+	options.titlePrefix, _ = GetStringArg(request, "title_prefix")
+	options.iterationFrom, _ = GetStringArg(request, "iteration_from")
+	options.iterationTo, _ = GetStringArg(request, "iteration_to")
+	options.areaFrom, _ = GetStringArg(request, "area_from")
+	options.areaTo, _ = GetStringArg(request, "area_to")
+
+	nodes, skipped, err := tool.collect(ctx, ids, strings.ToLower(includeDescendantsStr) == "true")
+	if err != nil {
+		return HandleError(err, "Failed to get the work items to clone"), nil
+	}
+
+	dryRun := strings.ToLower(dryRunStr) == "true"
+	results := tool.cloneAll(ctx, nodes, options, dryRun)
+
+	if strings.ToLower(format) == "json" {
+		response := map[string]any{"dry_run": dryRun, "items": results}
+		if len(skipped) > 0 {
+			response["skipped_ids"] = skipped
+		}
+		jsonData, err := json.MarshalIndent(response, "", "  ")
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to serialize JSON response: %v", err)), nil
+		}
+		return mcp.NewToolResultText(string(jsonData)), nil
+	}
+
+	return mcp.NewToolResultText(formatCloneResultsText(results, skipped, dryRun)), nil
+}
+
+// collect fetches the work items to clone, level by level, so that every parent comes before its
+// children. A work item reachable more than once is cloned once. Work items that do not exist or
+// cannot be read are skipped, together with their descendants, and their IDs returned.
+func (tool *AzureCloneWorkItemsTool) collect(ctx context.Context, ids []int, includeDescendants bool) ([]cloneNode, []int, error) {
+	var nodes []cloneNode
+	var skipped []int
+	seen := make(map[int]bool)
+
+	level := make([]cloneNode, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			level = append(level, cloneNode{source: workitemtracking.WorkItem{Id: &id}})
+		}
+	}
+
+	for depth := 0; len(level) > 0; depth++ {
+		var next []cloneNode
+		for start := 0; start < len(level); start += clonePageSize {
+			page := level[start:min(start+clonePageSize, len(level))]
+			pageIDs := make([]int, len(page))
+			for i, node := range page {
+				pageIDs[i] = *node.source.Id
+			}
+
+			workItems, err := tool.client.GetWorkItems(ctx, workitemtracking.GetWorkItemsArgs{
+				Ids:         &pageIDs,
+				Project:     &tool.config.Project,
+				Expand:      &workitemtracking.WorkItemExpandValues.Relations,
+				ErrorPolicy: &workitemtracking.WorkItemErrorPolicyValues.Omit,
+			})
+			if err != nil {
+				return nil, nil, err
+			}
+
+			// Omitted work items come back as empty entries in their place.
+			for i, workItem := range *workItems {
+				if i >= len(page) {
+					break
+				}
+				if workItem.Id == nil || workItem.Fields == nil {
+					skipped = append(skipped, pageIDs[i])
+					continue
+				}
+				node := cloneNode{source: workItem, depth: depth, parentID: page[i].parentID}
+				nodes = append(nodes, node)
+
+				if !includeDescendants || workItem.Relations == nil {
+					continue
+				}
+				for _, relation := range *workItem.Relations {
+					if relation.Rel == nil || *relation.Rel != "System.LinkTypes.Hierarchy-Forward" || relation.Url == nil {
+						continue
+					}
+					childID, err := ExtractWorkItemIDFromURL(*relation.Url)
+					if err != nil || seen[childID] {
+						continue
+					}
+					seen[childID] = true
+					next = append(next, cloneNode{source: workitemtracking.WorkItem{Id: &childID}, parentID: *workItem.Id})
+				}
+			}
+		}
+		level = next
+	}
+
+	return nodes, skipped, nil
+}
+
+// cloneAll creates the clones in order. Links between cloned items are added by whichever clone is
+// created last, when both ends exist; Azure DevOps adds the reverse link itself. A root keeps its
+// original parent. Descendants of a work item that failed to clone are not cloned. A dry run has
+// Azure DevOps validate every clone without creating it, so links to other clones are left out.
+func (tool *AzureCloneWorkItemsTool) cloneAll(ctx context.Context, nodes []cloneNode, options cloneOptions, dryRun bool) []ClonedWorkItemOutput {
+	inSet := make(map[int]bool, len(nodes))
+	for _, node := range nodes {
+		inSet[*node.source.Id] = true
+	}
+
+	clones := make(map[int]int) // Source ID to clone ID
+	failed := make(map[int]bool)
+	results := make([]ClonedWorkItemOutput, 0, len(nodes))
+
+	for _, node := range nodes {
+		sourceID := *node.source.Id
+		var fields map[string]any
+		if node.source.Fields != nil {
+			fields = *node.source.Fields
+		}
+		workItemType, _ := fields["System.WorkItemType"].(string)
+
+		document := cloneDocument(fields, options)
+		result := ClonedWorkItemOutput{
+			SourceID: sourceID,
+			Type:     workItemType,
+			ParentID: node.parentID,
+			depth:    node.depth,
+		}
+		for _, operation := range document {
+			switch *operation.Path {
+			case "/fields/System.Title":
+				result.Title, _ = operation.Value.(string)
+			case "/fields/System.AreaPath":
+				result.AreaPath, _ = operation.Value.(string)
+			case "/fields/System.IterationPath":
+				result.IterationPath, _ = operation.Value.(string)
+			}
+		}
+
+		if failed[node.parentID] {
+			failed[sourceID] = true
+			result.Error = fmt.Sprintf("not cloned because its parent #%d could not be cloned", node.parentID)
+			results = append(results, result)
+			continue
+		}
+
+		document = append(document, relationOperation("System.LinkTypes.Related", tool.workItemAPIURL(sourceID), fmt.Sprintf("Copied from #%d", sourceID)))
+		if node.source.Relations != nil {
+			for _, relation := range *node.source.Relations {
+				if relation.Rel == nil || relation.Url == nil || !strings.Contains(*relation.Rel, "LinkTypes") {
+					continue
+				}
+				targetID, err := ExtractWorkItemIDFromURL(*relation.Url)
+				if err != nil {
+					continue
+				}
+				switch {
+				case clones[targetID] != 0:
+					document = append(document, relationOperation(*relation.Rel, tool.workItemAPIURL(clones[targetID]), ""))
+				case node.parentID == 0 && !inSet[targetID] && *relation.Rel == "System.LinkTypes.Hierarchy-Reverse":
+					document = append(document, relationOperation(*relation.Rel, *relation.Url, ""))
+				}
+			}
+		}
+
+		args := workitemtracking.CreateWorkItemArgs{
+			Type:     &workItemType,
+			Project:  &tool.config.Project,
+			Document: &document,
+		}
+		if dryRun {
+			args.ValidateOnly = BoolPtr(true)
+		}
+
+		created, err := tool.client.CreateWorkItem(ctx, args)
+		if err != nil {
+			failed[sourceID] = true
+			result.Error = err.Error()
+			results = append(results, result)
+			continue
+		}
+		if dryRun {
+			results = append(results, result)
+			continue
+		}
+
+		clones[sourceID] = *created.Id
+		result.CloneID = *created.Id
+		result.URL = GetWorkItemURL(tool.config.OrganizationURL, *created.Id)
+		results = append(results, result)
+	}
+
+	return results
+}
+
+func (tool *AzureCloneWorkItemsTool) workItemAPIURL(id int) string {
+	return fmt.Sprintf("%s/_apis/wit/workItems/%d", tool.config.OrganizationURL, id)
+}
+
+// cloneDocument builds the fields of a clone from the fields of its source.
+func cloneDocument(fields map[string]any, options cloneOptions) []webapi.JsonPatchOperation {
+	var document []webapi.JsonPatchOperation
+	for _, field := range clonedFields {
+		value, ok := fields[field]
+		if !ok || value == nil {
+			continue
+		}
+		switch field {
+		case "System.Title":
+			value = options.titlePrefix + fmt.Sprint(value)
+		case "System.AreaPath":
+			value = rewritePath(fmt.Sprint(value), options.areaFrom, options.areaTo)
+		case "System.IterationPath":
+			value = rewritePath(fmt.Sprint(value), options.iterationFrom, options.iterationTo)
+		}
+		document = append(document, AddOperation(field, value))
+	}
+
+	var customFields []string
+	for field := range fields {
+		if strings.HasPrefix(field, "Custom.") {
+			customFields = append(customFields, field)
+		}
+	}
+	slices.Sort(customFields)
+	for _, field := range customFields {
+		if fields[field] != nil {
+			document = append(document, AddOperation(field, fields[field]))
+		}
+	}
+
+	return document
+}
+
+// rewritePath replaces the prefix from of a classification path with to. Without from, every path
+// becomes to; without to, paths are kept.
+func rewritePath(path, from, to string) string {
+	switch {
+	case to == "":
+		return path
+	case from == "":
+		return to
+	case strings.EqualFold(path, from):
+		return to
+	case len(path) > len(from) && strings.EqualFold(path[:len(from)], from) && path[len(from)] == '\\':
+		return to + path[len(from):]
+	}
+	return path
+}
+
+// relationOperation adds a link to a work item.
+func relationOperation(rel, targetURL, comment string) webapi.JsonPatchOperation {
+	value := map[string]any{"rel": rel, "url": targetURL}
+	if comment != "" {
+		value["attributes"] = map[string]any{"comment": comment}
+	}
+	return webapi.JsonPatchOperation{
+		Op:    &webapi.OperationValues.Add,
+		Path:  StringPtr("/relations/-"),
+		Value: value,
+	}
+}
+
+func formatCloneResultsText(results []ClonedWorkItemOutput, skipped []int, dryRun bool) string {
+	var sb strings.Builder
+	cloned, failed := 0, 0
+	for _, result := range results {
+		if result.Error != "" {
+			failed++
+		} else {
+			cloned++
+		}
+	}
+
+	if dryRun {
+		fmt.Fprintf(&sb, "Dry run: %d work items would be cloned, %d would fail, nothing was created.\n", cloned, failed)
+	} else {
+		fmt.Fprintf(&sb, "Cloned %d work items, %d failed.\n", cloned, failed)
+	}
+	if len(skipped) > 0 {
+		skippedIDs := make([]string, len(skipped))
+		for i, id := range skipped {
+			skippedIDs[i] = fmt.Sprintf("#%d", id)
+		}
+		fmt.Fprintf(&sb, "Skipped %d work items that do not exist or cannot be read: %s\n", len(skipped), strings.Join(skippedIDs, ", "))
+	}
+	sb.WriteString("\n")
+
+	for _, result := range results {
+		indent := strings.Repeat("  ", result.depth)
+		switch {
+		case result.Error != "":
+			fmt.Fprintf(&sb, "%s- #%d %s: %s (failed: %s)\n", indent, result.SourceID, result.Type, result.Title, result.Error)
+		case dryRun:
+			fmt.Fprintf(&sb, "%s- #%d %s: %s [%s | %s]\n", indent, result.SourceID, result.Type, result.Title, result.AreaPath, result.IterationPath)
+		default:
+			fmt.Fprintf(&sb, "%s- #%d -> #%d %s: %s [%s | %s] %s\n", indent, result.SourceID, result.CloneID, result.Type, result.Title, result.AreaPath, result.IterationPath, result.URL)
+		}
+	}
+	return sb.String()
+}
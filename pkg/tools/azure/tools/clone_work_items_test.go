@@ -0,0 +1,96 @@
+package tools
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestRewritePath(t *testing.T) {
+	Convey("Given a classification path", t, func() {
+		path := "Project\\2024 Q2\\Sprint 3"
+
+		Convey("A matching prefix is replaced", func() {
+			So(rewritePath(path, "project\\2024 Q2", "Project\\2024 Q3"), ShouldEqual, "Project\\2024 Q3\\Sprint 3")
+			So(rewritePath("Project\\2024 Q2", "Project\\2024 Q2", "Project\\2024 Q3"), ShouldEqual, "Project\\2024 Q3")
+		})
+
+		Convey("A prefix that only matches part of a node is not replaced", func() {
+			So(rewritePath("Project\\2024 Q22", "Project\\2024 Q2", "Project\\2024 Q3"), ShouldEqual, "Project\\2024 Q22")
+		})
+
+		Convey("Without a prefix every path is replaced, without a target none is", func() {
+			So(rewritePath(path, "", "Project\\Backlog"), ShouldEqual, "Project\\Backlog")
+			So(rewritePath(path, "Project", ""), ShouldEqual, path)
+		})
+	})
+}
+
+func TestCloneDocument(t *testing.T) {
+	Convey("Given the fields of a work item", t, func() {
+		fields := map[string]any{
+			"System.Title":         "Onboarding",
+			"System.State":         "Active",
+			"System.AssignedTo":    map[string]any{"displayName": "Ada"},
+			"System.IterationPath": "Project\\2024 Q2",
+			"Custom.Customer":      "Acme",
+		}
+
+		document := cloneDocument(fields, cloneOptions{titlePrefix: "[Q3] ", iterationFrom: "Project\\2024 Q2", iterationTo: "Project\\2024 Q3"})
+
+		Convey("The copied fields are rewritten and state and assignment are left out", func() {
+			values := make(map[string]any)
+			for _, operation := range document {
+				values[*operation.Path] = operation.Value
+			}
+			So(values, ShouldResemble, map[string]any{
+				"/fields/System.Title":         "[Q3] Onboarding",
+				"/fields/System.IterationPath": "Project\\2024 Q3",
+				"/fields/Custom.Customer":      "Acme",
+			})
+		})
+	})
+}
+
+func TestCloneWorkItems(t *testing.T) {
+	Convey("Given a work item and an ID that cannot be read", t, func() {
+		client := newFakeWorkItemClient()
+		client.add(1, 1, map[string]any{"System.Title": "Fix login", "System.WorkItemType": "Bug", "System.AreaPath": "Project"})
+		tool := &AzureCloneWorkItemsTool{client: client, config: AzureDevOpsConfig{Project: "Project", OrganizationURL: "https://dev.azure.com/org"}}
+
+		clone := func(dryRun string) map[string]any {
+			var response map[string]any
+			So(json.Unmarshal([]byte(callTool(tool.Handler, map[string]any{"ids": "1,99", "dry_run": dryRun, "format": "json"})), &response), ShouldBeNil)
+			return response
+		}
+
+		Convey("The unreadable item is skipped and reported", func() {
+			response := clone("false")
+
+			So(response["skipped_ids"], ShouldResemble, []any{float64(99)})
+			So(response["items"], ShouldHaveLength, 1)
+			So(client.items, ShouldHaveLength, 2)
+		})
+
+		Convey("A dry run validates every clone without creating it", func() {
+			response := clone("true")
+
+			So(response["items"].([]any)[0].(map[string]any)["error"], ShouldBeNil)
+			So(client.creates, ShouldHaveLength, 1)
+			So(*client.creates[0].ValidateOnly, ShouldBeTrue)
+			So(client.items, ShouldHaveLength, 1)
+		})
+
+		Convey("A dry run reports why a clone would fail", func() {
+			client.invalid = errors.New("TF401320: Rule Error for field Area Path")
+
+			text := callTool(tool.Handler, map[string]any{"ids": "1,99", "dry_run": "true"})
+
+			So(text, ShouldStartWith, "Dry run: 0 work items would be cloned, 1 would fail, nothing was created.\n"+
+				"Skipped 1 work items that do not exist or cannot be read: #99\n")
+			So(text, ShouldContainSubstring, "(failed: TF401320: Rule Error for field Area Path)")
+		})
+	})
+}
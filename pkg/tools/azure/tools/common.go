@@ -2,6 +2,7 @@ package tools
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net/url"
 	"strconv"
@@ -11,6 +12,7 @@ import (
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/microsoft/azure-devops-go-api/azuredevops/v7/webapi"
 	"github.com/microsoft/azure-devops-go-api/azuredevops/v7/work"
+	"github.com/microsoft/azure-devops-go-api/azuredevops/v7/workitemtracking"
 )
 
 // AzureDevOpsConfig contains configuration for Azure DevOps integration
@@ -19,6 +21,7 @@ type AzureDevOpsConfig struct {
 	PersonalAccessToken string
 	Project             string
 	Team                string
+	AllowDestroy        bool // Permits permanently destroying work items instead of only moving them to the recycle bin
 }
 
 // Helper to extract a string argument.
@@ -111,6 +114,29 @@ func FormatStringList(items []string) string {
 	return strings.Join(quoted, ", ")
 }
 
+// queryIDsCapped runs a WIQL query and returns the IDs of the matching work items. It asks for one
+// more than maxItems, so exceeding the cap is noticed without fetching every match; in that case
+// exceeded is true and the IDs are incomplete.
+func queryIDsCapped(ctx context.Context, client workitemtracking.Client, config AzureDevOpsConfig, query string, maxItems int) (ids []int, exceeded bool, err error) {
+	top := maxItems + 1
+	result, err := client.QueryByWiql(ctx, workitemtracking.QueryByWiqlArgs{
+		Wiql:    &workitemtracking.Wiql{Query: &query},
+		Project: &config.Project,
+		Team:    &config.Team,
+		Top:     &top,
+	})
+	if err != nil {
+		return nil, false, err
+	}
+
+	if result.WorkItems != nil {
+		for _, ref := range *result.WorkItems {
+			ids = append(ids, *ref.Id)
+		}
+	}
+	return ids, len(ids) > maxItems, nil
+}
+
 // Helper function for min value
 func Min(a, b int) int {
 	if a < b {
@@ -124,6 +150,11 @@ func StringPtr(s string) *string {
 	return &s
 }
 
+// Helper function to create a bool pointer
+func BoolPtr(b bool) *bool {
+	return &b
+}
+
 // Helper function to add an operation
 func AddOperation(field string, value any) webapi.JsonPatchOperation {
 	return webapi.JsonPatchOperation{
@@ -133,6 +164,48 @@ func AddOperation(field string, value any) webapi.JsonPatchOperation {
 	}
 }
 
+// dryRunResult marks a dry-run preview as valid, or invalid with the problems found.
+func dryRunResult(preview map[string]any, problems []string) map[string]any {
+	preview["dry_run"] = true
+	preview["valid"] = len(problems) == 0
+	if len(problems) > 0 {
+		preview["errors"] = problems
+	}
+	return preview
+}
+
+// formatPreviewText renders a dry-run preview: whether the item is valid, the problems found and
+// the JSON Patch documents that would be sent.
+func formatPreviewText(subject string, preview map[string]any) string {
+	var sb strings.Builder
+	if preview["valid"] == true {
+		fmt.Fprintf(&sb, "Dry run: %s is valid, nothing was changed.\n", subject)
+	} else {
+		fmt.Fprintf(&sb, "Dry run: %s would fail, nothing was changed.\n", subject)
+		if problems, ok := preview["errors"].([]string); ok {
+			for _, problem := range problems {
+				fmt.Fprintf(&sb, "- %s\n", problem)
+			}
+		}
+	}
+
+	documents := []map[string]any{{"document": preview["document"]}}
+	if docs, ok := preview["documents"].([]map[string]any); ok {
+		documents = docs
+	}
+	for _, doc := range documents {
+		if operation, ok := doc["operation"].(string); ok {
+			fmt.Fprintf(&sb, "\n%s:\n", operation)
+		} else {
+			sb.WriteString("\nJSON Patch:\n")
+		}
+		patch, _ := json.MarshalIndent(doc["document"], "", "  ")
+		sb.Write(patch)
+		sb.WriteString("\n")
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
 // Relation type mapping
 var RelationTypeMap = map[string]string{
 	"parent":   "System.LinkTypes.Hierarchy-Reverse",
@@ -4,6 +4,8 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"maps"
+	"slices"
 	"strconv"
 	"strings"
 
@@ -16,9 +18,10 @@ import (
 
 // AzureCreateWorkItemsTool provides functionality to create new work items in bulk with custom fields.
 type AzureCreateWorkItemsTool struct {
-	handle mcp.Tool
-	client workitemtracking.Client
-	config AzureDevOpsConfig
+	handle   mcp.Tool
+	client   workitemtracking.Client
+	config   AzureDevOpsConfig
+	markdown *MarkdownConverter
 }
 
 // WorkItemDefinition is used to parse the JSON input for each work item to be created.
@@ -37,15 +40,16 @@ type WorkItemDefinition struct {
 }
 
 // NewAzureCreateWorkItemsTool creates a new tool instance for creating work items.
-func NewAzureCreateWorkItemsTool(conn *azuredevops.Connection, config AzureDevOpsConfig) core.Tool {
+func NewAzureCreateWorkItemsTool(conn *azuredevops.Connection, config AzureDevOpsConfig, markdown *MarkdownConverter) core.Tool {
 	client, err := workitemtracking.NewClient(context.Background(), conn)
 	if err != nil {
 		return nil
 	}
 
 	tool := &AzureCreateWorkItemsTool{
-		client: client,
-		config: config,
+		client:   client,
+		config:   config,
+		markdown: markdown,
 	}
 
 	tool.handle = mcp.NewTool(
@@ -54,7 +58,17 @@ func NewAzureCreateWorkItemsTool(conn *azuredevops.Connection, config AzureDevOp
 		mcp.WithString(
 			"items_json",
 			mcp.Required(),
-			mcp.Description("A JSON string representing an array of work items to create. Each item object should define 'type', 'title', and optionally 'description' (using HTML, not Markdown), 'state', 'priority', 'parent_id', 'assigned_to', 'iteration', 'area', 'tags', and 'custom_fields' (as a map)."),
+			mcp.Description("A JSON string representing an array of work items to create. Each item object should define 'type', 'title', and optionally 'description' (HTML, or Markdown with content_format 'markdown'), 'state', 'priority', 'parent_id', 'assigned_to', 'iteration', 'area', 'tags', and 'custom_fields' (as a map)."),
+		),
+		mcp.WithString(
+			"content_format",
+			mcp.Description(contentFormatDescription),
+			mcp.Enum(ContentFormatHTML, ContentFormatMarkdown),
+		),
+		mcp.WithString(
+			"dry_run",
+			mcp.Description("Validate the items without creating anything and return the JSON Patch documents that would be sent"),
+			mcp.Enum("true", "false"),
 		),
 		mcp.WithString("format", mcp.Description("Response format: 'text' (default) or 'json'")),
 	)
@@ -72,6 +86,9 @@ func (tool *AzureCreateWorkItemsTool) Handler(ctx context.Context, request mcp.C
 		return mcp.NewToolResultError("Missing required parameter: items_json."), nil
 	}
 	format, _ := GetStringArg(request, "format")
+	dryRunStr, _ := GetStringArg(request, "dry_run")
+	dryRun := strings.ToLower(dryRunStr) == "true"
+	contentFormat, _ := GetStringArg(request, "content_format")
 
 	var itemsToCreate []WorkItemDefinition
 	if err := json.Unmarshal([]byte(itemsJSON), &itemsToCreate); err != nil {
@@ -98,7 +115,7 @@ func (tool *AzureCreateWorkItemsTool) Handler(ctx context.Context, request mcp.C
 		}
 
 		if itemDef.Description != "" {
-			document = append(document, AddOperation("System.Description", itemDef.Description))
+			document = append(document, AddOperation("System.Description", tool.markdown.ConvertContent(ctx, itemDef.Description, contentFormat)))
 		}
 		if itemDef.State != "" {
 			document = append(document, AddOperation("System.State", itemDef.State))
@@ -121,11 +138,18 @@ func (tool *AzureCreateWorkItemsTool) Handler(ctx context.Context, request mcp.C
 			document = append(document, AddOperation("System.Tags", itemDef.Tags))
 		}
 
-		// Add custom fields
-		for fieldName, fieldValue := range itemDef.CustomFields {
+		// Add custom fields, in a stable order so a dry run shows the document that is sent
+		for _, fieldName := range slices.Sorted(maps.Keys(itemDef.CustomFields)) {
 			// Custom fields might need their full reference name e.g., "Custom.MyField"
 			// For now, assume the user provides the correct reference name.
-			document = append(document, AddOperation(fieldName, fieldValue))
+			document = append(document, AddOperation(fieldName, itemDef.CustomFields[fieldName]))
+		}
+
+		if dryRun {
+			preview := tool.previewCreate(ctx, itemDef, document)
+			results = append(results, preview)
+			textResults = append(textResults, formatPreviewText(fmt.Sprintf("%s '%s'", itemDef.Type, itemDef.Title), preview))
+			continue
 		}
 
 		createArgs := workitemtracking.CreateWorkItemArgs{
@@ -151,19 +175,7 @@ func (tool *AzureCreateWorkItemsTool) Handler(ctx context.Context, request mcp.C
 			if convErr != nil {
 				parentLinkMsg = fmt.Sprintf("Work item #%d created, but failed to link to parent: Invalid parent ID format '%s'", workItemID, itemDef.ParentID)
 			} else {
-				relationOps := []webapi.JsonPatchOperation{
-					{
-						Op:   &webapi.OperationValues.Add,
-						Path: StringPtr("/relations/-"),
-						Value: map[string]any{
-							"rel": "System.LinkTypes.Hierarchy-Reverse", // Parent link
-							"url": fmt.Sprintf("%s/_apis/wit/workItems/%d", tool.config.OrganizationURL, parentIDInt),
-							"attributes": map[string]any{
-								"comment": "Linked during creation by MCP",
-							},
-						},
-					},
-				}
+				relationOps := tool.parentLinkDocument(parentIDInt)
 				updateArgs := workitemtracking.UpdateWorkItemArgs{
 					Id:       &workItemID,
 					Project:  &tool.config.Project,
@@ -223,4 +235,55 @@ func (tool *AzureCreateWorkItemsTool) Handler(ctx context.Context, request mcp.C
 	return mcp.NewToolResultText(strings.Join(textResults, "\n---\n")), nil
 }
 
-// GetStringArg, AddOperation, StringPtr would typically be in tools/common.go
+// parentLinkDocument is the patch that links a newly created work item to its parent.
+func (tool *AzureCreateWorkItemsTool) parentLinkDocument(parentID int) []webapi.JsonPatchOperation {
+	return []webapi.JsonPatchOperation{
+		{
+			Op:   &webapi.OperationValues.Add,
+			Path: StringPtr("/relations/-"),
+			Value: map[string]any{
+				"rel": "System.LinkTypes.Hierarchy-Reverse", // Parent link
+				"url": fmt.Sprintf("%s/_apis/wit/workItems/%d", tool.config.OrganizationURL, parentID),
+				"attributes": map[string]any{
+					"comment": "Linked during creation by MCP",
+				},
+			},
+		},
+	}
+}
+
+// previewCreate validates a work item without creating it. Azure DevOps checks the fields, their
+// allowed values and the initial state; the parent is checked by looking it up, since the link is
+// only added once the item exists.
+func (tool *AzureCreateWorkItemsTool) previewCreate(ctx context.Context, itemDef WorkItemDefinition, document []webapi.JsonPatchOperation) map[string]any {
+	var problems []string
+	documents := []map[string]any{{"operation": "create", "document": document}}
+
+	_, err := tool.client.CreateWorkItem(ctx, workitemtracking.CreateWorkItemArgs{
+		Type:         &itemDef.Type,
+		Project:      &tool.config.Project,
+		Document:     &document,
+		ValidateOnly: BoolPtr(true),
+	})
+	if err != nil {
+		problems = append(problems, err.Error())
+	}
+
+	if itemDef.ParentID != "" {
+		parentID, convErr := strconv.Atoi(itemDef.ParentID)
+		if convErr != nil {
+			problems = append(problems, fmt.Sprintf("invalid parent ID format '%s'", itemDef.ParentID))
+		} else {
+			if _, err := tool.client.GetWorkItem(ctx, workitemtracking.GetWorkItemArgs{
+				Id:      &parentID,
+				Project: &tool.config.Project,
+				Fields:  &[]string{"System.Id"},
+			}); err != nil {
+				problems = append(problems, fmt.Sprintf("parent #%d: %v", parentID, err))
+			}
+			documents = append(documents, map[string]any{"operation": "link_parent", "parent_id": parentID, "document": tool.parentLinkDocument(parentID)})
+		}
+	}
+
+	return dryRunResult(map[string]any{"title": itemDef.Title, "type": itemDef.Type, "documents": documents}, problems)
+}
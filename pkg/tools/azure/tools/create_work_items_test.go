@@ -0,0 +1,43 @@
+package tools
+
+import (
+	"encoding/json"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestCreateWorkItemsDryRun(t *testing.T) {
+	Convey("Given a parent work item", t, func() {
+		client := newFakeWorkItemClient()
+		client.add(7, 1, map[string]any{"System.Title": "Epic"})
+		tool := &AzureCreateWorkItemsTool{client: client, config: AzureDevOpsConfig{Project: "Project", OrganizationURL: "https://dev.azure.com/org"}}
+
+		create := func(items string) []map[string]any {
+			var results []map[string]any
+			So(json.Unmarshal([]byte(callTool(tool.Handler, map[string]any{"items_json": items, "dry_run": "true", "format": "json"})), &results), ShouldBeNil)
+			return results
+		}
+
+		Convey("A dry run validates the item and its parent without creating anything", func() {
+			results := create(`[{"type": "Task", "title": "Write docs", "parent_id": "7"}]`)
+
+			So(results[0]["valid"], ShouldEqual, true)
+			So(client.creates, ShouldHaveLength, 1)
+			So(*client.creates[0].ValidateOnly, ShouldBeTrue)
+			So(client.items, ShouldHaveLength, 1)
+
+			documents := results[0]["documents"].([]any)
+			So(documents, ShouldHaveLength, 2)
+			So(documents[0].(map[string]any)["operation"], ShouldEqual, "create")
+			So(documents[1].(map[string]any)["operation"], ShouldEqual, "link_parent")
+		})
+
+		Convey("A dry run reports a missing parent", func() {
+			results := create(`[{"type": "Task", "title": "Write docs", "parent_id": "8"}]`)
+
+			So(results[0]["valid"], ShouldEqual, false)
+			So(results[0]["errors"], ShouldResemble, []any{"parent #8: TF401232: Work item 8 does not exist"})
+		})
+	})
+}
@@ -0,0 +1,157 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/microsoft/azure-devops-go-api/azuredevops/v7"
+	"github.com/microsoft/azure-devops-go-api/azuredevops/v7/workitemtracking"
+	"github.com/theapemachine/mcp-server-devops-bridge/core"
+)
+
+// AzureDeleteWorkItemsTool deletes work items, moving them to the recycle bin unless they are destroyed.
+type AzureDeleteWorkItemsTool struct {
+	handle mcp.Tool
+	client workitemtracking.Client
+	config AzureDevOpsConfig
+}
+
+// NewAzureDeleteWorkItemsTool creates a new tool instance for deleting work items.
+func NewAzureDeleteWorkItemsTool(conn *azuredevops.Connection, config AzureDevOpsConfig) core.Tool {
+	client, err := workitemtracking.NewClient(context.Background(), conn)
+	if err != nil {
+		return nil
+	}
+
+	tool := &AzureDeleteWorkItemsTool{
+		client: client,
+		config: config,
+	}
+
+	tool.handle = mcp.NewTool(
+		"azure_delete_work_items",
+		mcp.WithDescription("Delete work items in Azure DevOps. Deleted items go to the recycle bin and can be restored with azure_restore_work_items."),
+		mcp.WithString(
+			"ids",
+			mcp.Required(),
+			mcp.Description("Comma-separated list of work item IDs to delete"),
+		),
+		mcp.WithString(
+			"destroy",
+			mcp.Description("Permanently destroy the work items instead of moving them to the recycle bin. This cannot be undone and must be enabled in the server configuration."),
+			mcp.Enum("true", "false"),
+		),
+		mcp.WithString("format", mcp.Description("Response format: 'text' (default) or 'json'")),
+	)
+
+	return tool
+}
+
+func (tool *AzureDeleteWorkItemsTool) Handle() mcp.Tool {
+	return tool.handle
+}
+
+func (tool *AzureDeleteWorkItemsTool) Handler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	idsStr, err := GetStringArg(request, "ids")
+	if err != nil {
+		return mcp.NewToolResultError("Missing required parameter: ids"), nil
+	}
+	ids, err := ParseIDs(idsStr)
+	if err != nil {
+		return HandleError(err, "Invalid work item IDs"), nil
+	}
+
+	destroyStr, _ := GetStringArg(request, "destroy")
+	destroy := strings.ToLower(destroyStr) == "true"
+	if destroy && !tool.config.AllowDestroy {
+		return mcp.NewToolResultError("Permanently destroying work items is disabled. Delete them without 'destroy' to move them to the recycle bin, or set AZURE_DEVOPS_ALLOW_DESTROY=true on the server."), nil
+	}
+	format, _ := GetStringArg(request, "format")
+
+	// Destroying returns no details of the deleted items, so their titles and types are looked up first.
+	known := make(map[int]*WorkItemTreeNode, len(ids))
+	if workItems, err := fetchWorkItems(ctx, tool.client, tool.config.Project, ids, false); err == nil {
+		for _, workItem := range workItems {
+			node := treeNode(workItem, tool.config.OrganizationURL)
+			known[node.ID] = node
+		}
+	}
+
+	var results []map[string]any
+	var textResults []string
+
+	for _, id := range ids {
+		result, message := tool.deleteItem(ctx, id, destroy, known[id])
+		results = append(results, result)
+		textResults = append(textResults, message)
+	}
+
+	if strings.ToLower(format) == "json" {
+		jsonData, err := json.MarshalIndent(results, "", "  ")
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to serialize JSON response: %v", err)), nil
+		}
+		return mcp.NewToolResultText(string(jsonData)), nil
+	}
+
+	return mcp.NewToolResultText(strings.Join(textResults, "\n")), nil
+}
+
+// deleteItem deletes one work item and returns the result for the JSON response together with its
+// text rendering. The item is the one looked up before deleting, nil if it could not be read.
+func (tool *AzureDeleteWorkItemsTool) deleteItem(ctx context.Context, id int, destroy bool, item *WorkItemTreeNode) (map[string]any, string) {
+	deleted, err := tool.client.DeleteWorkItem(ctx, workitemtracking.DeleteWorkItemArgs{
+		Id:      &id,
+		Project: &tool.config.Project,
+		Destroy: &destroy,
+	})
+	if err != nil && !(destroy && emptyResponse(err)) {
+		errMsg := fmt.Sprintf("Failed to delete work item #%d: %v", id, err)
+		return map[string]any{"id": id, "error": errMsg}, errMsg
+	}
+
+	var title, itemType, deletedBy, deletedDate string
+	if item != nil {
+		title, itemType = item.Title, item.Type
+	}
+	if deleted != nil {
+		if deleted.Name != nil {
+			title = *deleted.Name
+		}
+		if deleted.Type != nil {
+			itemType = *deleted.Type
+		}
+		deletedBy, deletedDate = SafeString(deleted.DeletedBy), SafeString(deleted.DeletedDate)
+	}
+	if itemType == "" {
+		itemType = "work item"
+	}
+
+	var message string
+	if destroy {
+		message = fmt.Sprintf("Destroyed %s #%d: %s. This cannot be undone.", itemType, id, title)
+	} else {
+		message = fmt.Sprintf("Moved %s #%d: %s to the recycle bin. Restore it with azure_restore_work_items.", itemType, id, title)
+	}
+	return map[string]any{
+		"id":           id,
+		"title":        title,
+		"type":         itemType,
+		"destroyed":    destroy,
+		"deleted_by":   deletedBy,
+		"deleted_date": deletedDate,
+		"message":      message,
+	}, message
+}
+
+// emptyResponse reports whether an error only means the response had no body to decode. Azure
+// DevOps answers a destroy with 204 No Content, which the SDK still tries to decode.
+func emptyResponse(err error) bool {
+	var syntaxErr *json.SyntaxError
+	return errors.Is(err, io.EOF) || (errors.As(err, &syntaxErr) && syntaxErr.Offset == 0)
+}
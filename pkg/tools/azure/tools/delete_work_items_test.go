@@ -0,0 +1,68 @@
+package tools
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestDeleteWorkItems(t *testing.T) {
+	Convey("Given work items", t, func() {
+		client := newFakeWorkItemClient()
+		client.add(1, 1, map[string]any{"System.Title": "Fix login", "System.WorkItemType": "Bug"})
+		client.add(2, 1, map[string]any{"System.Title": "Write docs", "System.WorkItemType": "Task"})
+		tool := &AzureDeleteWorkItemsTool{client: client, config: AzureDevOpsConfig{Project: "Project"}}
+
+		del := func(arguments map[string]any) []map[string]any {
+			arguments["format"] = "json"
+			var results []map[string]any
+			So(json.Unmarshal([]byte(callTool(tool.Handler, arguments)), &results), ShouldBeNil)
+			return results
+		}
+
+		Convey("Deleting moves them to the recycle bin and reports each outcome", func() {
+			results := del(map[string]any{"ids": "1,3"})
+
+			So(results, ShouldHaveLength, 2)
+			So(results[0]["message"], ShouldEqual, "Moved Bug #1: Fix login to the recycle bin. Restore it with azure_restore_work_items.")
+			So(results[0]["deleted_by"], ShouldEqual, "Ada Lovelace")
+			So(results[1]["error"], ShouldEqual, "Failed to delete work item #3: TF401232: Work item 3 does not exist")
+			So(client.items, ShouldContainKey, 2)
+		})
+
+		Convey("Destroying is refused unless the server allows it", func() {
+			request := map[string]any{"ids": "1", "destroy": "true"}
+
+			So(callTool(tool.Handler, request), ShouldStartWith, "Permanently destroying work items is disabled.")
+			So(client.items, ShouldContainKey, 1)
+		})
+
+		Convey("Destroying, when allowed, treats the empty response as success", func() {
+			tool.config.AllowDestroy = true
+
+			results := del(map[string]any{"ids": "1,2", "destroy": "true"})
+
+			So(results[0]["error"], ShouldBeNil)
+			So(results[0]["destroyed"], ShouldEqual, true)
+			So(results[0]["title"], ShouldEqual, "Fix login")
+			So(results[0]["type"], ShouldEqual, "Bug")
+			So(results[1]["message"], ShouldEqual, "Destroyed Task #2: Write docs. This cannot be undone.")
+			So(client.items, ShouldBeEmpty)
+		})
+	})
+}
+
+func TestEmptyResponse(t *testing.T) {
+	Convey("Given errors from decoding a response", t, func() {
+		var v struct{}
+
+		So(emptyResponse(json.Unmarshal(nil, &v)), ShouldBeTrue)
+		So(emptyResponse(io.EOF), ShouldBeTrue)
+		So(emptyResponse(json.Unmarshal([]byte(`{"id": `), &v)), ShouldBeFalse)
+		So(emptyResponse(json.Unmarshal([]byte(`nope`), &v)), ShouldBeFalse)
+		So(emptyResponse(errors.New("TF401232: Work item 1 does not exist")), ShouldBeFalse)
+	})
+}
@@ -0,0 +1,513 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/microsoft/azure-devops-go-api/azuredevops/v7"
+	"github.com/microsoft/azure-devops-go-api/azuredevops/v7/workitemtracking"
+	"github.com/theapemachine/mcp-server-devops-bridge/core"
+)
+
+const (
+	defaultFlowMaxItems = 200
+	flowBatchSize       = 10 // Work items whose revisions are fetched concurrently
+)
+
+// defaultBacklogStates are the states in which work on an item has not started yet.
+var defaultBacklogStates = []string{"TODO", "New", "Proposed", "To Do", "Approved"}
+
+// defaultFlowDoneStates are the states in which an item is finished. Unlike the done states of the
+// dependency analysis, they leave out Removed: a removed item was abandoned, not delivered.
+var defaultFlowDoneStates = []string{"DONE", "Done", "Closed", "Resolved", "Completed"}
+
+// removedState is the state of abandoned items, which count neither as finished nor in progress.
+const removedState = "Removed"
+
+// noColumn groups the time an item spent off the board, or before it had a board column.
+const noColumn = "(no column)"
+
+// flowRevision is the part of a work item revision that matters for flow metrics.
+type flowRevision struct {
+	Changed time.Time
+	State   string
+	Column  string
+}
+
+// flowOptions decide how states are interpreted.
+type flowOptions struct {
+	backlogStates []string
+	doneStates    []string
+	byColumn      bool // Time in state is measured per board column instead of per state
+}
+
+// FlowItem is the flow of one work item. Times are in days.
+type FlowItem struct {
+	ID          int                `json:"id"`
+	Title       string             `json:"title"`
+	Type        string             `json:"type"`
+	State       string             `json:"state"`
+	Created     time.Time          `json:"created"`
+	Started     *time.Time         `json:"started,omitempty"`
+	Completed   *time.Time         `json:"completed,omitempty"`
+	LeadTime    *float64           `json:"lead_time_days,omitempty"`
+	CycleTime   *float64           `json:"cycle_time_days,omitempty"`
+	Age         *float64           `json:"age_days,omitempty"` // Days since work started, for unfinished items
+	Removed     bool               `json:"removed,omitempty"`
+	TimeInState map[string]float64 `json:"time_in_state_days"`
+
+	stateOrder []string
+}
+
+// FlowStats summarizes a distribution of durations in days.
+type FlowStats struct {
+	Count   int     `json:"count"`
+	Average float64 `json:"average"`
+	P50     float64 `json:"p50"`
+	P85     float64 `json:"p85"`
+	P95     float64 `json:"p95"`
+	Max     float64 `json:"max"`
+}
+
+// StateFlowStats is the time spent in one state or board column.
+type StateFlowStats struct {
+	State string `json:"state"`
+	FlowStats
+}
+
+// WeeklyThroughput is the number of items finished in the week starting on Monday WeekStart.
+type WeeklyThroughput struct {
+	WeekStart string `json:"week_start"`
+	Completed int    `json:"completed"`
+}
+
+// FlowMetricsOutput holds the flow metrics of a population of work items.
+type FlowMetricsOutput struct {
+	Items       int                `json:"items"`
+	Completed   int                `json:"completed"`
+	InProgress  int                `json:"in_progress"`
+	Removed     int                `json:"removed"`
+	LeadTime    FlowStats          `json:"lead_time_days"`
+	CycleTime   FlowStats          `json:"cycle_time_days"`
+	Age         FlowStats          `json:"age_days"`
+	TimeInState []StateFlowStats   `json:"time_in_state_days"`
+	Throughput  []WeeklyThroughput `json:"throughput"`
+	WorkItems   []FlowItem         `json:"work_items"`
+}
+
+// AzureFlowMetricsTool computes lead time, cycle time and other flow metrics from work item revisions.
+type AzureFlowMetricsTool struct {
+	handle mcp.Tool
+	client workitemtracking.Client
+	config AzureDevOpsConfig
+}
+
+// NewAzureFlowMetricsTool creates a new tool instance for computing flow metrics.
+func NewAzureFlowMetricsTool(conn *azuredevops.Connection, config AzureDevOpsConfig) core.Tool {
+	client, err := workitemtracking.NewClient(context.Background(), conn)
+	if err != nil {
+		return nil
+	}
+
+	tool := &AzureFlowMetricsTool{
+		client: client,
+		config: config,
+	}
+
+	tool.handle = mcp.NewTool(
+		"azure_flow_metrics",
+		mcp.WithDescription("Compute flow metrics for the work items matching a WIQL query from their revision history: lead time, cycle time, time in each state or board column, weekly throughput and the age of unfinished items, with percentiles."),
+		mcp.WithString(
+			"query",
+			mcp.Required(),
+			mcp.Description("WIQL query selecting the work items, e.g. SELECT [System.Id] FROM WorkItems WHERE [System.WorkItemType] = 'User Story' AND [System.ChangedDate] >= @Today - 90"),
+		),
+		mcp.WithString(
+			"backlog_states",
+			mcp.Description(fmt.Sprintf("Comma-separated list of states before work starts; cycle time starts when an item first leaves them (default: %s)", strings.Join(defaultBacklogStates, ","))),
+		),
+		mcp.WithString(
+			"done_states",
+			mcp.Description(fmt.Sprintf("Comma-separated list of states in which an item is finished (default: %s)", strings.Join(defaultFlowDoneStates, ","))),
+		),
+		mcp.WithString(
+			"group_by",
+			mcp.Description("Measure time in each 'state' (default) or board 'column'"),
+			mcp.Enum("state", "column"),
+		),
+		mcp.WithString(
+			"from_date",
+			mcp.Description("Only count items finished on or after this date (YYYY-MM-DD or RFC 3339) in lead time, cycle time and throughput"),
+		),
+		mcp.WithString(
+			"to_date",
+			mcp.Description("Only count items finished on or before this date (YYYY-MM-DD or RFC 3339) in lead time, cycle time and throughput"),
+		),
+		mcp.WithNumber(
+			"max_items",
+			mcp.Description(fmt.Sprintf("Maximum number of work items to analyze; the tool refuses to run if more match (default: %d)", defaultFlowMaxItems)),
+		),
+		mcp.WithString("format", mcp.Description("Response format: 'text' (default) or 'json'")),
+	)
+
+	return tool
+}
+
+func (tool *AzureFlowMetricsTool) Handle() mcp.Tool {
+	return tool.handle
+}
+
+func (tool *AzureFlowMetricsTool) Handler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	query, err := GetStringArg(request, "query")
+	if err != nil {
+		return mcp.NewToolResultError("Missing required parameter: query"), nil
+	}
+
+	options := flowOptions{backlogStates: defaultBacklogStates, doneStates: defaultFlowDoneStates}
+	if value, _ := GetStringArg(request, "backlog_states"); value != "" {
+		options.backlogStates = strings.Split(value, ",")
+	}
+	if value, _ := GetStringArg(request, "done_states"); value != "" {
+		options.doneStates = strings.Split(value, ",")
+	}
+	groupBy, _ := GetStringArg(request, "group_by")
+	options.byColumn = strings.ToLower(groupBy) == "column"
+
+	var from, to time.Time
+	if value, _ := GetStringArg(request, "from_date"); value != "" {
+		if from, err = parseHistoryDate(value, false); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+	}
+	if value, _ := GetStringArg(request, "to_date"); value != "" {
+		if to, err = parseHistoryDate(value, true); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+	}
+
+	maxItems := defaultFlowMaxItems
+	if value, err := GetIntArg(request, "max_items"); err == nil && value > 0 {
+		maxItems = value
+	}
+
+	ids, exceeded, err := queryIDsCapped(ctx, tool.client, tool.config, query, maxItems)
+	if err != nil {
+		return HandleError(err, "Failed to execute WIQL query"), nil
+	}
+	if len(ids) == 0 {
+		return mcp.NewToolResultText("No work items found."), nil
+	}
+	if exceeded {
+		return mcp.NewToolResultError(fmt.Sprintf("More than %d work items match. Narrow the query or raise max_items.\n\nQuery: %s", maxItems, query)), nil
+	}
+
+	now := time.Now().UTC()
+	items := make([]FlowItem, len(ids))
+	errs := make([]error, len(ids))
+	for start := 0; start < len(ids); start += flowBatchSize {
+		var wg sync.WaitGroup
+		for i := start; i < min(start+flowBatchSize, len(ids)); i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				items[i], errs[i] = tool.itemFlow(ctx, ids[i], options, now)
+			}(i)
+		}
+		wg.Wait()
+	}
+	for i, err := range errs {
+		if err != nil {
+			return HandleError(err, fmt.Sprintf("Failed to get the revisions of work item #%d", ids[i])), nil
+		}
+	}
+
+	metrics := summarizeFlow(items, from, to)
+
+	format, _ := GetStringArg(request, "format")
+	if strings.ToLower(format) == "json" {
+		jsonData, err := json.MarshalIndent(metrics, "", "  ")
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to serialize JSON response: %v", err)), nil
+		}
+		return mcp.NewToolResultText(string(jsonData)), nil
+	}
+
+	return mcp.NewToolResultText(formatFlowMetricsText(metrics, options.byColumn)), nil
+}
+
+// itemFlow reconstructs the flow of a work item from its revisions.
+func (tool *AzureFlowMetricsTool) itemFlow(ctx context.Context, id int, options flowOptions, now time.Time) (FlowItem, error) {
+	revisions, err := fetchRevisions(ctx, tool.client, tool.config.Project, id)
+	if err != nil {
+		return FlowItem{}, err
+	}
+
+	var history []flowRevision
+	var created time.Time
+	var title, workItemType string
+	for _, revision := range revisions {
+		if revision.Fields == nil {
+			continue
+		}
+		fields := *revision.Fields
+		changed, err := time.Parse(time.RFC3339, fmt.Sprint(fields["System.ChangedDate"]))
+		if err != nil {
+			continue
+		}
+		if created.IsZero() {
+			if created, err = time.Parse(time.RFC3339, fmt.Sprint(fields["System.CreatedDate"])); err != nil {
+				created = changed
+			}
+		}
+		title, _ = fields["System.Title"].(string)
+		workItemType, _ = fields["System.WorkItemType"].(string)
+
+		entry := flowRevision{Changed: changed}
+		entry.State, _ = fields["System.State"].(string)
+		entry.Column, _ = fields["System.BoardColumn"].(string)
+		history = append(history, entry)
+	}
+
+	item := computeItemFlow(history, created, options, now)
+	item.ID, item.Title, item.Type = id, title, workItemType
+	return item, nil
+}
+
+// computeItemFlow derives when an item started and finished and how long it spent in each state
+// from its revisions, oldest first. An item is finished when its last state is done, at the
+// moment it last entered a done state; time in done states is not counted. A removed item is
+// neither finished nor in progress, and the time since it was removed is not counted either.
+func computeItemFlow(history []flowRevision, created time.Time, options flowOptions, now time.Time) FlowItem {
+	item := FlowItem{Created: created, TimeInState: make(map[string]float64)}
+	if len(history) == 0 {
+		return item
+	}
+
+	inStates := func(state string, states []string) bool {
+		return slices.ContainsFunc(states, func(candidate string) bool {
+			return strings.EqualFold(strings.TrimSpace(candidate), state)
+		})
+	}
+	key := func(revision flowRevision) string {
+		switch {
+		case !options.byColumn:
+			return revision.State
+		case revision.Column == "":
+			return noColumn
+		}
+		return revision.Column
+	}
+
+	var completed time.Time
+	wasDone := false
+	for i, revision := range history {
+		done := inStates(revision.State, options.doneStates)
+		if item.Started == nil && !inStates(revision.State, options.backlogStates) {
+			started := revision.Changed
+			item.Started = &started
+		}
+		if done && !wasDone {
+			completed = revision.Changed
+		}
+		wasDone = done
+		item.State = revision.State
+
+		if done || strings.EqualFold(revision.State, removedState) {
+			continue
+		}
+		end := now
+		if i+1 < len(history) {
+			end = history[i+1].Changed
+		}
+		group := key(revision)
+		if _, seen := item.TimeInState[group]; !seen {
+			item.stateOrder = append(item.stateOrder, group)
+		}
+		item.TimeInState[group] += days(end.Sub(revision.Changed))
+	}
+
+	switch {
+	case strings.EqualFold(item.State, removedState):
+		item.Removed = true
+	case wasDone:
+		item.Completed = &completed
+		leadTime := days(completed.Sub(created))
+		item.LeadTime = &leadTime
+		if item.Started != nil {
+			cycleTime := days(completed.Sub(*item.Started))
+			item.CycleTime = &cycleTime
+		}
+	case item.Started != nil:
+		age := days(now.Sub(*item.Started))
+		item.Age = &age
+	}
+	return item
+}
+
+// summarizeFlow aggregates the flow of the items. Only items finished between from and to,
+// when given, count toward lead time, cycle time and throughput.
+func summarizeFlow(items []FlowItem, from, to time.Time) FlowMetricsOutput {
+	metrics := FlowMetricsOutput{Items: len(items), WorkItems: items, TimeInState: []StateFlowStats{}, Throughput: []WeeklyThroughput{}}
+
+	var leadTimes, cycleTimes, ages []float64
+	weekly := make(map[string]int)
+	var firstWeek, lastWeek time.Time
+	timeInState := make(map[string][]float64)
+	var stateOrder []string
+
+	for _, item := range items {
+		for _, state := range item.stateOrder {
+			if _, seen := timeInState[state]; !seen {
+				stateOrder = append(stateOrder, state)
+			}
+			timeInState[state] = append(timeInState[state], item.TimeInState[state])
+		}
+
+		if item.Removed {
+			metrics.Removed++
+		}
+		if item.Age != nil {
+			metrics.InProgress++
+			ages = append(ages, *item.Age)
+		}
+		if item.Completed == nil {
+			continue
+		}
+		if (!from.IsZero() && item.Completed.Before(from)) || (!to.IsZero() && item.Completed.After(to)) {
+			continue
+		}
+
+		metrics.Completed++
+		leadTimes = append(leadTimes, *item.LeadTime)
+		if item.CycleTime != nil {
+			cycleTimes = append(cycleTimes, *item.CycleTime)
+		}
+
+		week := weekStart(*item.Completed)
+		weekly[week.Format("2006-01-02")]++
+		if firstWeek.IsZero() || week.Before(firstWeek) {
+			firstWeek = week
+		}
+		if week.After(lastWeek) {
+			lastWeek = week
+		}
+	}
+
+	metrics.LeadTime = flowStats(leadTimes)
+	metrics.CycleTime = flowStats(cycleTimes)
+	metrics.Age = flowStats(ages)
+	for _, state := range stateOrder {
+		metrics.TimeInState = append(metrics.TimeInState, StateFlowStats{State: state, FlowStats: flowStats(timeInState[state])})
+	}
+
+	// Weeks without finished items are listed too, so the throughput can be read as a time series.
+	if !firstWeek.IsZero() {
+		for week := firstWeek; !week.After(lastWeek); week = week.AddDate(0, 0, 7) {
+			key := week.Format("2006-01-02")
+			metrics.Throughput = append(metrics.Throughput, WeeklyThroughput{WeekStart: key, Completed: weekly[key]})
+		}
+	}
+	return metrics
+}
+
+// flowStats computes the average, maximum and nearest-rank percentiles of durations in days.
+func flowStats(values []float64) FlowStats {
+	if len(values) == 0 {
+		return FlowStats{}
+	}
+	sorted := slices.Sorted(slices.Values(values))
+
+	total := 0.0
+	for _, value := range sorted {
+		total += value
+	}
+	percentile := func(p float64) float64 {
+		rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+		return sorted[max(rank-1, 0)]
+	}
+
+	return FlowStats{
+		Count:   len(sorted),
+		Average: roundDays(total / float64(len(sorted))),
+		P50:     percentile(50),
+		P85:     percentile(85),
+		P95:     percentile(95),
+		Max:     sorted[len(sorted)-1],
+	}
+}
+
+// days converts a duration to days, rounded to a tenth.
+func days(duration time.Duration) float64 {
+	return roundDays(duration.Hours() / 24)
+}
+
+func roundDays(value float64) float64 {
+	return math.Round(value*10) / 10
+}
+
+// weekStart returns midnight UTC on the Monday of the week containing moment.
+func weekStart(moment time.Time) time.Time {
+	day := time.Date(moment.Year(), moment.Month(), moment.Day(), 0, 0, 0, 0, time.UTC)
+	return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+}
+
+// formatFlowMetricsText renders the flow metrics as a report.
+func formatFlowMetricsText(metrics FlowMetricsOutput, byColumn bool) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "## Flow Metrics (%d work items, %d finished, %d in progress, %d removed)\n\n", metrics.Items, metrics.Completed, metrics.InProgress, metrics.Removed)
+
+	statsLine := func(name string, stats FlowStats) {
+		if stats.Count == 0 {
+			fmt.Fprintf(&sb, "- %s: no data\n", name)
+			return
+		}
+		fmt.Fprintf(&sb, "- %s (%d items): average %.1f, p50 %.1f, p85 %.1f, p95 %.1f, max %.1f days\n",
+			name, stats.Count, stats.Average, stats.P50, stats.P85, stats.P95, stats.Max)
+	}
+
+	statsLine("Lead time", metrics.LeadTime)
+	statsLine("Cycle time", metrics.CycleTime)
+	statsLine("Work item age", metrics.Age)
+
+	if byColumn {
+		sb.WriteString("\n### Time in Column\n")
+	} else {
+		sb.WriteString("\n### Time in State\n")
+	}
+	if len(metrics.TimeInState) == 0 {
+		sb.WriteString("No data.\n")
+	}
+	for _, state := range metrics.TimeInState {
+		statsLine(state.State, state.FlowStats)
+	}
+
+	sb.WriteString("\n### Throughput per Week\n")
+	if len(metrics.Throughput) == 0 {
+		sb.WriteString("No items finished.\n")
+	}
+	for _, week := range metrics.Throughput {
+		fmt.Fprintf(&sb, "- Week of %s: %d\n", week.WeekStart, week.Completed)
+	}
+
+	var oldest []FlowItem
+	for _, item := range metrics.WorkItems {
+		if item.Age != nil {
+			oldest = append(oldest, item)
+		}
+	}
+	if len(oldest) > 0 {
+		slices.SortFunc(oldest, func(a, b FlowItem) int { return int(math.Round((*b.Age - *a.Age) * 10)) })
+		sb.WriteString("\n### Oldest Items in Progress\n")
+		for _, item := range oldest[:min(len(oldest), 10)] {
+			fmt.Fprintf(&sb, "- #%d [%s] %s (%s): %.1f days\n", item.ID, item.Type, item.Title, item.State, *item.Age)
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,121 @@
+package tools
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestComputeItemFlow(t *testing.T) {
+	Convey("Given the revisions of a work item", t, func() {
+		day := func(d int) time.Time { return time.Date(2024, 3, d, 0, 0, 0, 0, time.UTC) }
+		options := flowOptions{backlogStates: defaultBacklogStates, doneStates: defaultFlowDoneStates}
+		history := []flowRevision{
+			{Changed: day(1), State: "TODO", Column: "Backlog"},
+			{Changed: day(3), State: "DOING", Column: "Develop"},
+			{Changed: day(5), State: "DOING", Column: "Review"},
+			{Changed: day(6), State: "DONE", Column: "Done"},
+		}
+
+		Convey("A finished item has a lead and cycle time and no age", func() {
+			item := computeItemFlow(history, day(1), options, day(20))
+
+			So(*item.Started, ShouldEqual, day(3))
+			So(*item.Completed, ShouldEqual, day(6))
+			So(*item.LeadTime, ShouldEqual, 5)
+			So(*item.CycleTime, ShouldEqual, 3)
+			So(item.Age, ShouldBeNil)
+			So(item.TimeInState, ShouldResemble, map[string]float64{"TODO": 2, "DOING": 3})
+		})
+
+		Convey("Time can be measured per board column", func() {
+			options.byColumn = true
+			item := computeItemFlow(history, day(1), options, day(20))
+
+			So(item.TimeInState, ShouldResemble, map[string]float64{"Backlog": 2, "Develop": 2, "Review": 1})
+		})
+
+		Convey("Revisions without a board column are grouped apart when measuring per column", func() {
+			options.byColumn = true
+			offBoard := append([]flowRevision{{Changed: day(1), State: "TODO"}}, history[1:]...)
+			item := computeItemFlow(offBoard, day(1), options, day(20))
+
+			So(item.TimeInState, ShouldResemble, map[string]float64{"(no column)": 2, "Develop": 2, "Review": 1})
+		})
+
+		Convey("A removed item is neither finished nor in progress", func() {
+			removed := append(history[:3:3], flowRevision{Changed: day(6), State: "Removed", Column: "Done"})
+			item := computeItemFlow(removed, day(1), options, day(20))
+
+			So(item.Removed, ShouldBeTrue)
+			So(item.Completed, ShouldBeNil)
+			So(item.Age, ShouldBeNil)
+			So(item.TimeInState, ShouldResemble, map[string]float64{"TODO": 2, "DOING": 3})
+		})
+
+		Convey("A reopened item is finished when it was last done", func() {
+			reopened := append(history, flowRevision{Changed: day(8), State: "DOING"}, flowRevision{Changed: day(9), State: "DONE"})
+			item := computeItemFlow(reopened, day(1), options, day(20))
+
+			So(*item.Completed, ShouldEqual, day(9))
+			So(*item.CycleTime, ShouldEqual, 6)
+			So(item.TimeInState["DOING"], ShouldEqual, 4)
+		})
+
+		Convey("An unfinished item has an age since it started", func() {
+			item := computeItemFlow(history[:3], day(1), options, day(10))
+
+			So(item.Completed, ShouldBeNil)
+			So(*item.Age, ShouldEqual, 7)
+			So(item.TimeInState["DOING"], ShouldEqual, 7)
+		})
+	})
+}
+
+func TestSummarizeFlow(t *testing.T) {
+	Convey("Given the flow of several items", t, func() {
+		day := func(d int) time.Time { return time.Date(2024, 3, d, 0, 0, 0, 0, time.UTC) }
+		finished := func(id, completed int, leadTime, cycleTime float64) FlowItem {
+			moment := day(completed)
+			return FlowItem{ID: id, Completed: &moment, LeadTime: &leadTime, CycleTime: &cycleTime}
+		}
+		age := 4.0
+		items := []FlowItem{
+			finished(1, 4, 10, 2),  // Monday March 4
+			finished(2, 10, 6, 3),  // Sunday, same week
+			finished(3, 19, 3, 1),  // Two weeks later
+			finished(4, 25, 20, 8), // Next Monday
+			{ID: 5, Age: &age},
+			{ID: 6, Removed: true},
+		}
+
+		Convey("Percentiles use the nearest rank", func() {
+			metrics := summarizeFlow(items, time.Time{}, time.Time{})
+
+			So(metrics.Completed, ShouldEqual, 4)
+			So(metrics.InProgress, ShouldEqual, 1)
+			So(metrics.Removed, ShouldEqual, 1)
+			So(metrics.LeadTime, ShouldResemble, FlowStats{Count: 4, Average: 9.8, P50: 6, P85: 20, P95: 20, Max: 20})
+			So(metrics.CycleTime.P50, ShouldEqual, 2)
+		})
+
+		Convey("Throughput lists every week, including weeks without finished items", func() {
+			metrics := summarizeFlow(items, time.Time{}, time.Time{})
+
+			So(metrics.Throughput, ShouldResemble, []WeeklyThroughput{
+				{WeekStart: "2024-03-04", Completed: 2},
+				{WeekStart: "2024-03-11", Completed: 0},
+				{WeekStart: "2024-03-18", Completed: 1},
+				{WeekStart: "2024-03-25", Completed: 1},
+			})
+		})
+
+		Convey("Only items finished in the date range are counted", func() {
+			metrics := summarizeFlow(items, day(5), day(20))
+
+			So(metrics.Completed, ShouldEqual, 2)
+			So(metrics.Throughput, ShouldHaveLength, 3)
+		})
+	})
+}
@@ -78,6 +78,11 @@ func NewAzureGetWorkItemsTool(conn *azuredevops.Connection, config AzureDevOpsCo
 			mcp.Description("Whether to include comments. Default: true"),
 			mcp.Enum("true", "false"),
 		),
+		mcp.WithString(
+			"content_format",
+			mcp.Description("Format of descriptions, other rich text fields and comments: 'html' (default, as stored) or 'markdown'."),
+			mcp.Enum(ContentFormatHTML, ContentFormatMarkdown),
+		),
 		mcp.WithString(
 			"format",
 			mcp.Description("Response format: 'text' (default) or 'json'."),
@@ -176,6 +181,8 @@ func (tool *AzureGetWorkItemsTool) Handler(ctx context.Context, request mcp.Call
 	includeRelationsStr, _ := GetStringArg(request, "include_relations")
 	includeCommentsStr, _ := GetStringArg(request, "include_comments")
 	format, _ := GetStringArg(request, "format")
+	contentFormat, _ := GetStringArg(request, "content_format")
+	asMarkdown := strings.EqualFold(contentFormat, ContentFormatMarkdown)
 
 	includeRelations := true // Default to true as per new description
 	if includeRelationsStr == "false" {
@@ -251,7 +258,11 @@ func (tool *AzureGetWorkItemsTool) Handler(ctx context.Context, request mcp.Call
 				if comment.CreatedDate != nil {
 					dateStr = comment.CreatedDate.Time.Format("2006-01-02 15:04")
 				}
-				formattedComment := fmt.Sprintf("Author: %s | Date: %s\n%s", author, dateStr, *comment.Text)
+				text := *comment.Text
+				if asMarkdown {
+					text = HTMLToMarkdown(text)
+				}
+				formattedComment := fmt.Sprintf("Author: %s | Date: %s\n%s", author, dateStr, text)
 				commentsMap[workItemID] = append(commentsMap[workItemID], formattedComment)
 			}
 		}
@@ -265,6 +276,14 @@ func (tool *AzureGetWorkItemsTool) Handler(ctx context.Context, request mcp.Call
 		fields := *item.Fields
 		id := *item.Id
 
+		if asMarkdown {
+			for field, value := range fields {
+				if text, ok := value.(string); ok && htmlFields[field] {
+					fields[field] = HTMLToMarkdown(text)
+				}
+			}
+		}
+
 		outputItem := DetailedWorkItemOutput{
 			ID:     id,
 			URL:    GetWorkItemURL(tool.config.OrganizationURL, id), // Use common GetWorkItemURL
@@ -281,7 +300,7 @@ func (tool *AzureGetWorkItemsTool) Handler(ctx context.Context, request mcp.Call
 			outputItem.State = st
 		}
 		if desc, ok := fields["System.Description"].(string); ok {
-			outputItem.Description = desc // Keep raw HTML, text formatting can strip/simplify if needed
+			outputItem.Description = desc // Raw HTML unless content_format is markdown
 		}
 		if assignedToMap, ok := fields["System.AssignedTo"].(map[string]any); ok {
 			if displayName, ok := assignedToMap["displayName"].(string); ok {
@@ -0,0 +1,472 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/microsoft/azure-devops-go-api/azuredevops/v7"
+	"github.com/microsoft/azure-devops-go-api/azuredevops/v7/identity"
+	"github.com/theapemachine/mcp-server-devops-bridge/pkg/tools/htmlmd"
+	nethtml "golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Content formats of rich text parameters.
+const (
+	ContentFormatHTML     = "html"
+	ContentFormatMarkdown = "markdown"
+)
+
+// contentFormatDescription documents the content_format parameter of tools that write rich text.
+const contentFormatDescription = "Format of descriptions and comments: 'html' (default) or 'markdown', which is converted to HTML. Markdown supports tables, code blocks, check lists ('- [ ]'), mentions ('@[Display Name]' or '@user@example.com') and work item links ('#123')."
+
+// htmlFields are the rich text fields whose values are converted from Markdown.
+var htmlFields = map[string]bool{
+	"System.Description":                       true,
+	"System.History":                           true,
+	"Microsoft.VSTS.Common.AcceptanceCriteria": true,
+	"Microsoft.VSTS.Common.Resolution":         true,
+	"Microsoft.VSTS.TCM.ReproSteps":            true,
+	"Microsoft.VSTS.TCM.SystemInfo":            true,
+}
+
+var (
+	fenceRe     = regexp.MustCompile("^\\s*(```+|~~~+)\\s*([\\w+#.-]*)\\s*$")
+	headingRe   = regexp.MustCompile(`^\s{0,3}(#{1,6})\s+(.*?)(?:\s+#+)?\s*$`)
+	ruleRe      = regexp.MustCompile(`^\s{0,3}(?:(?:-\s*){3,}|(?:\*\s*){3,}|(?:_\s*){3,})$`)
+	quoteRe     = regexp.MustCompile(`^\s{0,3}>\s?(.*)$`)
+	listItemRe  = regexp.MustCompile(`^(\s*)([-*+]|\d{1,9}[.)])\s+(.*)$`)
+	checkItemRe = regexp.MustCompile(`^\[([ xX])\]\s+(.*)$`)
+	tableSepRe  = regexp.MustCompile(`^\s*\|?\s*:?-+:?\s*(\|\s*:?-+:?\s*)*\|?\s*$`)
+
+	// inlineRe matches the inline constructs that are rendered as a whole: code spans, images,
+	// links, autolinks, mentions and work item references.
+	inlineRe = regexp.MustCompile("``(.+?)``|`([^`]+)`" +
+		`|!\[([^\]]*)\]\(([^)\s]+)(?:\s+"[^"]*")?\)` +
+		`|\[([^\]]+)\]\(([^)\s]+)(?:\s+"[^"]*")?\)` +
+		`|<(https?://[^>\s]+)>` +
+		`|(https?://[^\s<]*[^\s<.,;:!?)\]'"])` +
+		`|@\[([^\]]+)\]` +
+		`|@([A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,})` +
+		`|\B#(\d+)\b`)
+
+	strongRe      = regexp.MustCompile(`\*\*(\S(?:.*?\S)?)\*\*|__(\S(?:.*?\S)?)__`)
+	emphasisRe    = regexp.MustCompile(`\*(\S(?:.*?\S)?)\*`)
+	underscoreRe  = regexp.MustCompile(`(^|[^\w])_(\S(?:.*?\S)?)_([^\w]|$)`)
+	strikeRe      = regexp.MustCompile(`~~(\S(?:.*?\S)?)~~`)
+	placeholderRe = regexp.MustCompile("\x00(\\d+)\x00")
+)
+
+// MarkdownConverter renders Markdown as the HTML Azure DevOps stores in rich text fields.
+// Mentions of people are resolved to identities, so they notify like mentions made in the web UI.
+type MarkdownConverter struct {
+	orgURL string
+	// resolve looks up the identity ID and display name for a mention.
+	resolve func(ctx context.Context, name string) (id, displayName string, ok bool)
+
+	mu       sync.Mutex
+	mentions map[string][2]string
+}
+
+// NewMarkdownConverter creates a converter that resolves mentions with the identity API. If the
+// identity client cannot be created, mentions are left as plain text.
+// Create one and share it between tools, so they share the identity client and mention cache.
+func NewMarkdownConverter(conn *azuredevops.Connection, config AzureDevOpsConfig) *MarkdownConverter {
+	converter := &MarkdownConverter{orgURL: config.OrganizationURL, mentions: make(map[string][2]string)}
+
+	client, err := identity.NewClient(context.Background(), conn)
+	if err != nil {
+		converter.resolve = func(context.Context, string) (string, string, bool) { return "", "", false }
+		return converter
+	}
+
+	converter.resolve = func(ctx context.Context, name string) (string, string, bool) {
+		identities, err := client.ReadIdentities(ctx, identity.ReadIdentitiesArgs{
+			SearchFilter: StringPtr("General"),
+			FilterValue:  &name,
+		})
+		// An ambiguous name is not guessed at.
+		if err != nil || identities == nil || len(*identities) != 1 || (*identities)[0].Id == nil {
+			return "", "", false
+		}
+		found := (*identities)[0]
+		displayName := name
+		if found.ProviderDisplayName != nil {
+			displayName = *found.ProviderDisplayName
+		}
+		return found.Id.String(), displayName, true
+	}
+	return converter
+}
+
+// ConvertContent converts a rich text value to HTML if it is Markdown.
+func (c *MarkdownConverter) ConvertContent(ctx context.Context, content, contentFormat string) string {
+	if !strings.EqualFold(contentFormat, ContentFormatMarkdown) || content == "" {
+		return content
+	}
+	return c.ToHTML(ctx, content)
+}
+
+// ConvertFields converts the Markdown string values of rich text fields to HTML, in place.
+func (c *MarkdownConverter) ConvertFields(ctx context.Context, fields map[string]any, contentFormat string) {
+	for field, value := range fields {
+		if text, ok := value.(string); ok && htmlFields[fieldReferenceName(field)] {
+			fields[field] = c.ConvertContent(ctx, text, contentFormat)
+		}
+	}
+}
+
+// ToHTML converts Markdown to HTML. Headings, paragraphs, emphasis, code, links, images, block
+// quotes, ordered, unordered and check lists and tables are supported. "@[Display Name]" and
+// "@user@example.com" become mentions and "#123" a link to the work item. Raw HTML is escaped.
+// NUL bytes, which delimit the placeholders of inline, are replaced as HTML parsers do.
+func (c *MarkdownConverter) ToHTML(ctx context.Context, markdown string) string {
+	markdown = strings.ReplaceAll(markdown, "\x00", "\uFFFD")
+	markdown = strings.ReplaceAll(strings.ReplaceAll(markdown, "\r\n", "\n"), "\t", "    ")
+	return c.blocks(ctx, strings.Split(markdown, "\n"))
+}
+
+// blocks renders a sequence of lines as block-level HTML.
+func (c *MarkdownConverter) blocks(ctx context.Context, lines []string) string {
+	var out strings.Builder
+
+	for i := 0; i < len(lines); {
+		line := lines[i]
+
+		switch {
+		case strings.TrimSpace(line) == "":
+			i++
+
+		case fenceRe.MatchString(line):
+			match := fenceRe.FindStringSubmatch(line)
+			fence := match[1]
+			var code []string
+			for i++; i < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i]), fence); i++ {
+				code = append(code, lines[i])
+			}
+			i++ // Closing fence
+			class := ""
+			if match[2] != "" {
+				class = fmt.Sprintf(` class="language-%s"`, html.EscapeString(match[2]))
+			}
+			fmt.Fprintf(&out, "<pre><code%s>%s</code></pre>", class, html.EscapeString(strings.Join(code, "\n")))
+
+		case headingRe.MatchString(line):
+			match := headingRe.FindStringSubmatch(line)
+			level := len(match[1])
+			fmt.Fprintf(&out, "<h%d>%s</h%d>", level, c.inline(ctx, match[2]), level)
+			i++
+
+		case ruleRe.MatchString(line):
+			out.WriteString("<hr>")
+			i++
+
+		case quoteRe.MatchString(line):
+			var quoted []string
+			for ; i < len(lines) && quoteRe.MatchString(lines[i]); i++ {
+				quoted = append(quoted, quoteRe.FindStringSubmatch(lines[i])[1])
+			}
+			fmt.Fprintf(&out, "<blockquote>%s</blockquote>", c.blocks(ctx, quoted))
+
+		case startsTable(lines, i):
+			var table string
+			table, i = c.table(ctx, lines, i)
+			out.WriteString(table)
+
+		case listItemRe.MatchString(line):
+			var list string
+			list, i = c.list(ctx, lines, i)
+			out.WriteString(list)
+
+		default:
+			var paragraph strings.Builder
+			for start := i; i < len(lines) && strings.TrimSpace(lines[i]) != "" && (i == start || !startsBlock(lines[i]) && !startsTable(lines, i)); i++ {
+				text := strings.TrimSpace(lines[i])
+				hardBreak := strings.HasSuffix(lines[i], "  ") || strings.HasSuffix(text, `\`)
+				paragraph.WriteString(c.inline(ctx, strings.TrimSuffix(text, `\`)))
+				if i+1 < len(lines) && strings.TrimSpace(lines[i+1]) != "" && !startsBlock(lines[i+1]) && !startsTable(lines, i+1) {
+					if hardBreak {
+						paragraph.WriteString("<br>")
+					} else {
+						paragraph.WriteString(" ")
+					}
+				}
+			}
+			fmt.Fprintf(&out, "<p>%s</p>", paragraph.String())
+		}
+	}
+
+	return out.String()
+}
+
+// startsBlock reports whether a line ends a paragraph by starting another block.
+func startsBlock(line string) bool {
+	return fenceRe.MatchString(line) || headingRe.MatchString(line) || ruleRe.MatchString(line) ||
+		quoteRe.MatchString(line) || listItemRe.MatchString(line)
+}
+
+// startsTable reports whether a table header and separator row start at line i.
+func startsTable(lines []string, i int) bool {
+	return i+1 < len(lines) && strings.Contains(lines[i], "|") && strings.Contains(lines[i+1], "|") && tableSepRe.MatchString(lines[i+1])
+}
+
+// list renders the list starting at line i and returns the index of the first line after it.
+// Lines indented deeper than the list markers belong to the item above them, which is how nested
+// lists are written.
+func (c *MarkdownConverter) list(ctx context.Context, lines []string, i int) (string, int) {
+	first := listItemRe.FindStringSubmatch(lines[i])
+	indent := len(first[1])
+	ordered := first[2][0] >= '0' && first[2][0] <= '9'
+
+	var items [][]string
+	for ; i < len(lines); i++ {
+		line := lines[i]
+		if strings.TrimSpace(line) == "" {
+			// A blank line only continues the list if more of it follows.
+			next := i + 1
+			for next < len(lines) && strings.TrimSpace(lines[next]) == "" {
+				next++
+			}
+			if next < len(lines) && (leadingSpaces(lines[next]) > indent || c.sameList(lines[next], indent, ordered)) {
+				continue
+			}
+			break
+		}
+
+		if c.sameList(line, indent, ordered) {
+			items = append(items, []string{listItemRe.FindStringSubmatch(line)[3]})
+			continue
+		}
+		if leadingSpaces(line) > indent || !startsBlock(line) {
+			items[len(items)-1] = append(items[len(items)-1], line[min(leadingSpaces(line), indent+2):])
+			continue
+		}
+		break
+	}
+
+	tag, attributes := "ul", ""
+	if ordered {
+		tag = "ol"
+		if start, _ := strconv.Atoi(strings.TrimRight(first[2], ".)")); start > 1 {
+			attributes = fmt.Sprintf(` start="%d"`, start)
+		}
+	}
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "<%s%s>", tag, attributes)
+	for _, item := range items {
+		text := item[0]
+		box := ""
+		if match := checkItemRe.FindStringSubmatch(text); match != nil {
+			box = htmlmd.UncheckedBox + " "
+			if match[1] != " " {
+				box = htmlmd.CheckedBox + " "
+			}
+			text = match[2]
+		}
+
+		// Continuation lines that are not blocks of their own belong to the item's first paragraph.
+		rest := item[1:]
+		for len(rest) > 0 && strings.TrimSpace(rest[0]) != "" && !startsBlock(rest[0]) {
+			text += " " + strings.TrimSpace(rest[0])
+			rest = rest[1:]
+		}
+
+		fmt.Fprintf(&out, "<li>%s%s%s</li>", box, c.inline(ctx, text), c.blocks(ctx, rest))
+	}
+	fmt.Fprintf(&out, "</%s>", tag)
+	return out.String(), i
+}
+
+// sameList reports whether the line is an item of the list with the given indent and kind.
+func (c *MarkdownConverter) sameList(line string, indent int, ordered bool) bool {
+	match := listItemRe.FindStringSubmatch(line)
+	if match == nil || len(match[1]) != indent || ruleRe.MatchString(line) {
+		return false
+	}
+	return (match[2][0] >= '0' && match[2][0] <= '9') == ordered
+}
+
+// table renders the GitHub-flavored Markdown table starting at line i and returns the index of the
+// first line after it.
+func (c *MarkdownConverter) table(ctx context.Context, lines []string, i int) (string, int) {
+	header := tableCells(lines[i])
+	var alignments []string
+	for _, cell := range tableCells(lines[i+1]) {
+		switch {
+		case strings.HasPrefix(cell, ":") && strings.HasSuffix(cell, ":"):
+			alignments = append(alignments, "center")
+		case strings.HasSuffix(cell, ":"):
+			alignments = append(alignments, "right")
+		default:
+			alignments = append(alignments, "")
+		}
+	}
+
+	row := func(cells []string, tag string) string {
+		var out strings.Builder
+		out.WriteString("<tr>")
+		for column := range header {
+			cell := ""
+			if column < len(cells) {
+				cell = cells[column]
+			}
+			style := ""
+			if column < len(alignments) && alignments[column] != "" {
+				style = fmt.Sprintf(` style="text-align:%s"`, alignments[column])
+			}
+			fmt.Fprintf(&out, "<%s%s>%s</%s>", tag, style, c.inline(ctx, cell), tag)
+		}
+		out.WriteString("</tr>")
+		return out.String()
+	}
+
+	var out strings.Builder
+	out.WriteString("<table><thead>" + row(header, "th") + "</thead><tbody>")
+	for i += 2; i < len(lines) && strings.TrimSpace(lines[i]) != "" && strings.Contains(lines[i], "|"); i++ {
+		out.WriteString(row(tableCells(lines[i]), "td"))
+	}
+	out.WriteString("</tbody></table>")
+	return out.String(), i
+}
+
+// tableCells splits a table row into its cells. Escaped pipes stay in the cell.
+func tableCells(line string) []string {
+	line = strings.TrimSpace(line)
+	line = strings.TrimPrefix(line, "|")
+	if strings.HasSuffix(line, "|") && !strings.HasSuffix(line, `\|`) {
+		line = line[:len(line)-1]
+	}
+
+	var cells []string
+	var cell strings.Builder
+	for i := 0; i < len(line); i++ {
+		switch {
+		case line[i] == '\\' && i+1 < len(line) && line[i+1] == '|':
+			cell.WriteByte('|')
+			i++
+		case line[i] == '|':
+			cells = append(cells, strings.TrimSpace(cell.String()))
+			cell.Reset()
+		default:
+			cell.WriteByte(line[i])
+		}
+	}
+	return append(cells, strings.TrimSpace(cell.String()))
+}
+
+// inline renders a line of Markdown text. Constructs that must not be touched by emphasis, such as
+// code spans and links, are rendered first and swapped for placeholders until the end.
+func (c *MarkdownConverter) inline(ctx context.Context, text string) string {
+	var rendered []string
+	placeholder := func(fragment string) string {
+		rendered = append(rendered, fragment)
+		return fmt.Sprintf("\x00%d\x00", len(rendered)-1)
+	}
+
+	var out strings.Builder
+	last := 0
+	for _, match := range inlineRe.FindAllStringSubmatchIndex(text, -1) {
+		group := func(n int) string {
+			if match[2*n] < 0 {
+				return ""
+			}
+			return text[match[2*n]:match[2*n+1]]
+		}
+
+		var fragment string
+		switch {
+		case group(1) != "" || group(2) != "":
+			fragment = "<code>" + html.EscapeString(strings.TrimSpace(group(1)+group(2))) + "</code>"
+		case group(4) != "":
+			fragment = fmt.Sprintf(`<img src="%s" alt="%s">`, html.EscapeString(group(4)), html.EscapeString(group(3)))
+		case group(6) != "":
+			fragment = fmt.Sprintf(`<a href="%s">%s</a>`, html.EscapeString(group(6)), c.inline(ctx, group(5)))
+		case group(7) != "" || group(8) != "":
+			link := html.EscapeString(group(7) + group(8))
+			fragment = fmt.Sprintf(`<a href="%s">%s</a>`, link, link)
+		case group(9) != "" || group(10) != "":
+			fragment = c.mention(ctx, group(9)+group(10), text[match[0]:match[1]], match[0] > 0 && isWordByte(text[match[0]-1]))
+		case group(11) != "":
+			id, _ := strconv.Atoi(group(11))
+			fragment = fmt.Sprintf(`<a href="%s" data-vss-mention="version:1.0">#%d</a>`, GetWorkItemURL(c.orgURL, id), id)
+		}
+
+		out.WriteString(html.EscapeString(text[last:match[0]]))
+		out.WriteString(placeholder(fragment))
+		last = match[1]
+	}
+	out.WriteString(html.EscapeString(text[last:]))
+
+	result := strongRe.ReplaceAllString(out.String(), "<strong>$1$2</strong>")
+	result = emphasisRe.ReplaceAllString(result, "<em>$1</em>")
+	result = underscoreRe.ReplaceAllString(result, "$1<em>$2</em>$3")
+	result = strikeRe.ReplaceAllString(result, "<del>$1</del>")
+
+	return placeholderRe.ReplaceAllStringFunc(result, func(token string) string {
+		index, err := strconv.Atoi(strings.Trim(token, "\x00"))
+		if err != nil || index >= len(rendered) {
+			return ""
+		}
+		return rendered[index]
+	})
+}
+
+// mention renders a mention of a person, or the original text if the person cannot be resolved
+// unambiguously or the @ is part of a word, as in an email address.
+func (c *MarkdownConverter) mention(ctx context.Context, name, original string, inWord bool) string {
+	if inWord {
+		return html.EscapeString(original)
+	}
+
+	c.mu.Lock()
+	cached, found := c.mentions[strings.ToLower(name)]
+	c.mu.Unlock()
+
+	if !found {
+		id, displayName, ok := c.resolve(ctx, name)
+		if !ok {
+			return html.EscapeString(original)
+		}
+		cached = [2]string{id, displayName}
+		c.mu.Lock()
+		c.mentions[strings.ToLower(name)] = cached
+		c.mu.Unlock()
+	}
+
+	return fmt.Sprintf(`<a href="#" data-vss-mention="version:2.0,%s">@%s</a>`, html.EscapeString(cached[0]), html.EscapeString(cached[1]))
+}
+
+func isWordByte(b byte) bool {
+	return b == '_' || b >= '0' && b <= '9' || b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z'
+}
+
+func leadingSpaces(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " "))
+}
+
+// HTMLToMarkdown converts the HTML of an Azure DevOps rich text field to Markdown, the reverse of
+// MarkdownConverter.ToHTML. Mentions become "@[Display Name]" and work item links "#123".
+func HTMLToMarkdown(content string) string {
+	return htmlmd.ConvertFragment(content, htmlmd.Options{Inline: mentionMarkdown})
+}
+
+// mentionMarkdown renders the mentions of people and work items Azure DevOps stores as links.
+func mentionMarkdown(n *nethtml.Node, inner string) (string, bool) {
+	if n.DataAtom != atom.A {
+		return "", false
+	}
+	mention := htmlmd.Attr(n, "data-vss-mention")
+	switch {
+	case strings.HasPrefix(mention, "version:2.0"):
+		return "@[" + strings.TrimPrefix(strings.TrimSpace(inner), "@") + "]", true
+	case strings.HasPrefix(mention, "version:1.0"):
+		return strings.TrimSpace(inner), true
+	}
+	return "", false
+}
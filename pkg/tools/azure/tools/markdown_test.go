@@ -0,0 +1,79 @@
+package tools
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func testMarkdownConverter() *MarkdownConverter {
+	return &MarkdownConverter{
+		orgURL:   "https://dev.azure.com/org",
+		mentions: make(map[string][2]string),
+		resolve: func(_ context.Context, name string) (string, string, bool) {
+			if name == "ada@example.com" || name == "Ada Lovelace" {
+				return "0f1e2d3c-0000-0000-0000-000000000001", "Ada Lovelace", true
+			}
+			return "", "", false
+		},
+	}
+}
+
+func TestMarkdownToHTML(t *testing.T) {
+	Convey("Given Markdown written for a work item", t, func() {
+		converter := testMarkdownConverter()
+		ctx := context.Background()
+
+		Convey("Headings, paragraphs and emphasis are converted and raw HTML is escaped", func() {
+			So(converter.ToHTML(ctx, "## Goal\n\nMake **login** *fast*\nand ~~slow~~ <b>safe</b>."), ShouldEqual,
+				"<h2>Goal</h2><p>Make <strong>login</strong> <em>fast</em> and <del>slow</del> &lt;b&gt;safe&lt;/b&gt;.</p>")
+		})
+
+		Convey("Code is kept verbatim", func() {
+			So(converter.ToHTML(ctx, "Run `make *all*`:\n\n```go\nif a < b {}\n```"), ShouldEqual,
+				`<p>Run <code>make *all*</code>:</p><pre><code class="language-go">if a &lt; b {}</code></pre>`)
+		})
+
+		Convey("Nested and check lists are converted", func() {
+			So(converter.ToHTML(ctx, "- [ ] Design\n- [x] Build\n  1. API\n  2. UI"), ShouldEqual,
+				"<ul><li>☐ Design</li><li>☑ Build<ol><li>API</li><li>UI</li></ol></li></ul>")
+		})
+
+		Convey("Tables are converted with their alignment", func() {
+			So(converter.ToHTML(ctx, "| Step | Owner |\n| --- | :---: |\n| Deploy | Ops \\| SRE |"), ShouldEqual,
+				`<table><thead><tr><th>Step</th><th style="text-align:center">Owner</th></tr></thead>`+
+					`<tbody><tr><td>Deploy</td><td style="text-align:center">Ops | SRE</td></tr></tbody></table>`)
+		})
+
+		Convey("Mentions and work item references become Azure DevOps links", func() {
+			So(converter.ToHTML(ctx, "@ada@example.com see #42, not @[Nobody] or mail ada@example.com"), ShouldEqual,
+				`<p><a href="#" data-vss-mention="version:2.0,0f1e2d3c-0000-0000-0000-000000000001">@Ada Lovelace</a> see `+
+					`<a href="https://dev.azure.com/org/_workitems/edit/42" data-vss-mention="version:1.0">#42</a>, not @[Nobody] or mail ada@example.com</p>`)
+		})
+
+		Convey("Links keep their URL intact", func() {
+			So(converter.ToHTML(ctx, "See [the *spec*](https://example.com/a_b_c?x=1&y=2) and https://example.com/d_e."), ShouldEqual,
+				`<p>See <a href="https://example.com/a_b_c?x=1&amp;y=2">the <em>spec</em></a> and <a href="https://example.com/d_e">https://example.com/d_e</a>.</p>`)
+		})
+
+		Convey("NUL bytes cannot forge placeholders", func() {
+			So(converter.ToHTML(ctx, "A0* \x000\x0000"), ShouldEqual, "<p>A0* \uFFFD0\uFFFD00</p>")
+			So(converter.ToHTML(ctx, "`a` \x000\x00"), ShouldEqual, "<p><code>a</code> \uFFFD0\uFFFD</p>")
+		})
+	})
+}
+
+func TestHTMLToMarkdown(t *testing.T) {
+	Convey("Given the HTML of a rich text field", t, func() {
+		Convey("Markdown converted to HTML converts back", func() {
+			markdown := "## Goal\n\nMake **login** *fast*\n\n- [ ] Design\n- [x] Build\n  1. API\n  2. UI\n\n| Step | Owner |\n| --- | --- |\n| Deploy | Ops |\n\n```go\nif a < b {}\n```\n\n@[Ada Lovelace] see #42"
+			So(HTMLToMarkdown(testMarkdownConverter().ToHTML(context.Background(), markdown)), ShouldEqual, markdown)
+		})
+
+		Convey("Azure DevOps markup is simplified", func() {
+			So(HTMLToMarkdown(`<div>First&nbsp;line<br>second <span style="color:red">line</span></div><div><a href="https://example.com">docs</a></div>`), ShouldEqual,
+				"First line\nsecond line\n\n[docs](https://example.com)")
+		})
+	})
+}
@@ -0,0 +1,213 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/microsoft/azure-devops-go-api/azuredevops/v7"
+	"github.com/microsoft/azure-devops-go-api/azuredevops/v7/workitemtracking"
+	"github.com/theapemachine/mcp-server-devops-bridge/core"
+)
+
+// recycleBinPageSize is the number of deleted work items requested per call.
+const recycleBinPageSize = 200
+
+// DeletedWorkItemOutput is a work item in the recycle bin.
+type DeletedWorkItemOutput struct {
+	ID          int    `json:"id"`
+	Title       string `json:"title"`
+	Type        string `json:"type"`
+	DeletedBy   string `json:"deleted_by"`
+	DeletedDate string `json:"deleted_date"`
+}
+
+// AzureRecycleBinTool lists the work items in the recycle bin of the project.
+type AzureRecycleBinTool struct {
+	handle mcp.Tool
+	client workitemtracking.Client
+	config AzureDevOpsConfig
+}
+
+// NewAzureRecycleBinTool creates a new tool instance for listing deleted work items.
+func NewAzureRecycleBinTool(conn *azuredevops.Connection, config AzureDevOpsConfig) core.Tool {
+	client, err := workitemtracking.NewClient(context.Background(), conn)
+	if err != nil {
+		return nil
+	}
+
+	tool := &AzureRecycleBinTool{
+		client: client,
+		config: config,
+	}
+
+	tool.handle = mcp.NewTool(
+		"azure_recycle_bin",
+		mcp.WithDescription("List the deleted work items in the recycle bin of the Azure DevOps project, which can be restored with azure_restore_work_items."),
+		mcp.WithString(
+			"ids",
+			mcp.Description("Optional comma-separated list of deleted work item IDs to show; by default the whole recycle bin is listed"),
+		),
+		mcp.WithString("format", mcp.Description("Response format: 'text' (default) or 'json'")),
+	)
+
+	return tool
+}
+
+func (tool *AzureRecycleBinTool) Handle() mcp.Tool {
+	return tool.handle
+}
+
+func (tool *AzureRecycleBinTool) Handler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var ids []int
+	if idsStr, _ := GetStringArg(request, "ids"); idsStr != "" {
+		var err error
+		if ids, err = ParseIDs(idsStr); err != nil {
+			return HandleError(err, "Invalid work item IDs"), nil
+		}
+	} else {
+		references, err := tool.client.GetDeletedWorkItemShallowReferences(ctx, workitemtracking.GetDeletedWorkItemShallowReferencesArgs{
+			Project: &tool.config.Project,
+		})
+		if err != nil {
+			return HandleError(err, "Failed to list the recycle bin"), nil
+		}
+		for _, reference := range *references {
+			if reference.Id != nil {
+				ids = append(ids, *reference.Id)
+			}
+		}
+	}
+
+	if len(ids) == 0 {
+		return mcp.NewToolResultText("The recycle bin is empty."), nil
+	}
+
+	var deletedItems []DeletedWorkItemOutput
+	for start := 0; start < len(ids); start += recycleBinPageSize {
+		page := ids[start:min(start+recycleBinPageSize, len(ids))]
+		deleted, err := tool.client.GetDeletedWorkItems(ctx, workitemtracking.GetDeletedWorkItemsArgs{
+			Ids:     &page,
+			Project: &tool.config.Project,
+		})
+		if err != nil {
+			return HandleError(err, "Failed to get deleted work items"), nil
+		}
+		for _, item := range *deleted {
+			if item.Id == nil {
+				continue
+			}
+			deletedItems = append(deletedItems, DeletedWorkItemOutput{
+				ID:          *item.Id,
+				Title:       SafeString(item.Name),
+				Type:        SafeString(item.Type),
+				DeletedBy:   SafeString(item.DeletedBy),
+				DeletedDate: SafeString(item.DeletedDate),
+			})
+		}
+	}
+
+	format, _ := GetStringArg(request, "format")
+	if strings.ToLower(format) == "json" {
+		jsonData, err := json.MarshalIndent(deletedItems, "", "  ")
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to serialize JSON response: %v", err)), nil
+		}
+		return mcp.NewToolResultText(string(jsonData)), nil
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "## Recycle Bin (%d work items)\n\n", len(deletedItems))
+	for _, item := range deletedItems {
+		fmt.Fprintf(&sb, "- #%d [%s] %s, deleted by %s on %s\n", item.ID, item.Type, item.Title, item.DeletedBy, item.DeletedDate)
+	}
+	return mcp.NewToolResultText(sb.String()), nil
+}
+
+// AzureRestoreWorkItemsTool restores deleted work items from the recycle bin.
+type AzureRestoreWorkItemsTool struct {
+	handle mcp.Tool
+	client workitemtracking.Client
+	config AzureDevOpsConfig
+}
+
+// NewAzureRestoreWorkItemsTool creates a new tool instance for restoring deleted work items.
+func NewAzureRestoreWorkItemsTool(conn *azuredevops.Connection, config AzureDevOpsConfig) core.Tool {
+	client, err := workitemtracking.NewClient(context.Background(), conn)
+	if err != nil {
+		return nil
+	}
+
+	tool := &AzureRestoreWorkItemsTool{
+		client: client,
+		config: config,
+	}
+
+	tool.handle = mcp.NewTool(
+		"azure_restore_work_items",
+		mcp.WithDescription("Restore deleted work items from the recycle bin of the Azure DevOps project."),
+		mcp.WithString(
+			"ids",
+			mcp.Required(),
+			mcp.Description("Comma-separated list of deleted work item IDs to restore"),
+		),
+		mcp.WithString("format", mcp.Description("Response format: 'text' (default) or 'json'")),
+	)
+
+	return tool
+}
+
+func (tool *AzureRestoreWorkItemsTool) Handle() mcp.Tool {
+	return tool.handle
+}
+
+func (tool *AzureRestoreWorkItemsTool) Handler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	idsStr, err := GetStringArg(request, "ids")
+	if err != nil {
+		return mcp.NewToolResultError("Missing required parameter: ids"), nil
+	}
+	ids, err := ParseIDs(idsStr)
+	if err != nil {
+		return HandleError(err, "Invalid work item IDs"), nil
+	}
+	format, _ := GetStringArg(request, "format")
+
+	var results []map[string]any
+	var textResults []string
+
+	for _, id := range ids {
+		restored, err := tool.client.RestoreWorkItem(ctx, workitemtracking.RestoreWorkItemArgs{
+			Id:      &id,
+			Project: &tool.config.Project,
+			Payload: &workitemtracking.WorkItemDeleteUpdate{IsDeleted: BoolPtr(false)},
+		})
+		if err != nil {
+			errMsg := fmt.Sprintf("Failed to restore work item #%d: %v", id, err)
+			results = append(results, map[string]any{"id": id, "error": errMsg})
+			textResults = append(textResults, errMsg)
+			continue
+		}
+
+		message := fmt.Sprintf("Restored %s #%d: %s. URL: %s", SafeString(restored.Type), id, SafeString(restored.Name), GetWorkItemURL(tool.config.OrganizationURL, id))
+		results = append(results, map[string]any{
+			"id":      id,
+			"title":   SafeString(restored.Name),
+			"type":    SafeString(restored.Type),
+			"url":     GetWorkItemURL(tool.config.OrganizationURL, id),
+			"message": message,
+		})
+		textResults = append(textResults, message)
+	}
+
+	if strings.ToLower(format) == "json" {
+		jsonData, err := json.MarshalIndent(results, "", "  ")
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to serialize JSON response: %v", err)), nil
+		}
+		return mcp.NewToolResultText(string(jsonData)), nil
+	}
+
+	return mcp.NewToolResultText(strings.Join(textResults, "\n")), nil
+}
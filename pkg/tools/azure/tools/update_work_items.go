@@ -4,6 +4,8 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"maps"
+	"slices"
 	"strings"
 
 	"github.com/mark3labs/mcp-go/mcp"
@@ -15,14 +17,16 @@ import (
 
 // AzureUpdateWorkItemsTool provides functionality to update multiple work items in Azure DevOps.
 type AzureUpdateWorkItemsTool struct {
-	handle mcp.Tool
-	client workitemtracking.Client
-	config AzureDevOpsConfig
+	handle   mcp.Tool
+	client   workitemtracking.Client
+	config   AzureDevOpsConfig
+	markdown *MarkdownConverter
 }
 
 // WorkItemUpdateDefinition defines the structure for updating a single work item.
 type WorkItemUpdateDefinition struct {
 	ID              int                `json:"id"`
+	Rev             int                `json:"rev,omitempty"`              // Expected current revision, the update fails on a conflict if the item changed since
 	FieldsToUpdate  map[string]any     `json:"fields_to_update"`           // Flexible map for all field types
 	Comment         string             `json:"comment,omitempty"`          // For adding a new comment
 	AddRelations    []RelationLink     `json:"add_relations,omitempty"`    // For adding new relations
@@ -44,24 +48,35 @@ type RelationLinkArgs struct {
 }
 
 // NewAzureUpdateWorkItemsTool creates a new tool instance for updating work items.
-func NewAzureUpdateWorkItemsTool(conn *azuredevops.Connection, config AzureDevOpsConfig) core.Tool {
+func NewAzureUpdateWorkItemsTool(conn *azuredevops.Connection, config AzureDevOpsConfig, markdown *MarkdownConverter) core.Tool {
 	client, err := workitemtracking.NewClient(context.Background(), conn)
 	if err != nil {
 		return nil
 	}
 
 	tool := &AzureUpdateWorkItemsTool{
-		client: client,
-		config: config,
+		client:   client,
+		config:   config,
+		markdown: markdown,
 	}
 
 	tool.handle = mcp.NewTool(
 		"azure_update_work_items",
-		mcp.WithDescription("Update one or more work items in Azure DevOps. Supports updating various fields, adding comments (HTML, not Markdown), and managing relationships."),
+		mcp.WithDescription("Update one or more work items in Azure DevOps. Supports updating various fields, adding comments (HTML or Markdown), and managing relationships."),
 		mcp.WithString(
 			"items_to_update_json",
 			mcp.Required(),
-			mcp.Description("A JSON string representing an array of work items to update. Each item object must have an 'id' (integer) and 'fields_to_update' (map of field names to new values). Optionally, include 'comment' (HTML string, not Markdown) to add a comment, 'add_relations' (array of relation links), or 'remove_relations' (array of relation identifiers)."),
+			mcp.Description("A JSON string representing an array of work items to update. Each item object must have an 'id' (integer) and 'fields_to_update' (map of field names to new values). Optionally, include 'rev' (the revision the change is based on, the update is rejected with the current values if the item changed since), 'comment' (HTML, or Markdown with content_format 'markdown') to add a comment, 'add_relations' (array of relation links), or 'remove_relations' (array of relation identifiers)."),
+		),
+		mcp.WithString(
+			"content_format",
+			mcp.Description(contentFormatDescription+" Applies to the comment and to rich text fields such as System.Description."),
+			mcp.Enum(ContentFormatHTML, ContentFormatMarkdown),
+		),
+		mcp.WithString(
+			"dry_run",
+			mcp.Description("Validate the updates (fields, allowed values, state transitions, relation targets) without saving anything and return the JSON Patch documents that would be sent"),
+			mcp.Enum("true", "false"),
 		),
 		mcp.WithString("format", mcp.Description("Response format: 'text' (default) or 'json'")),
 	)
@@ -78,6 +93,9 @@ func (tool *AzureUpdateWorkItemsTool) Handler(ctx context.Context, request mcp.C
 		return mcp.NewToolResultError("Missing required parameter: items_to_update_json"), nil
 	}
 	format, _ := GetStringArg(request, "format")
+	dryRunStr, _ := GetStringArg(request, "dry_run")
+	dryRun := strings.ToLower(dryRunStr) == "true"
+	contentFormat, _ := GetStringArg(request, "content_format")
 
 	var itemsToUpdate []WorkItemUpdateDefinition
 	if err := json.Unmarshal([]byte(itemsJSON), &itemsToUpdate); err != nil {
@@ -92,89 +110,191 @@ func (tool *AzureUpdateWorkItemsTool) Handler(ctx context.Context, request mcp.C
 	var textResults []string
 
 	for _, itemDef := range itemsToUpdate {
-		if itemDef.ID == 0 {
-			errMsg := fmt.Sprintf("Skipped item due to missing or invalid 'id'. Provided: %+v", itemDef)
-			results = append(results, map[string]any{"id": itemDef.ID, "error": errMsg})
-			textResults = append(textResults, errMsg)
-			continue
+		tool.convertContent(ctx, &itemDef, contentFormat)
+		itemResult, text := tool.updateItem(ctx, itemDef, dryRun)
+		results = append(results, itemResult)
+		textResults = append(textResults, text)
+	}
+
+	if strings.ToLower(format) == "json" {
+		jsonData, err := json.MarshalIndent(results, "", "  ")
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to serialize JSON response: %v", err)), nil
 		}
+		return mcp.NewToolResultText(string(jsonData)), nil
+	}
+	return mcp.NewToolResultText(strings.Join(textResults, "\n---\n")), nil
+}
 
-		var operations []webapi.JsonPatchOperation
+// convertContent converts the comment and rich text fields of an update from Markdown to HTML.
+func (tool *AzureUpdateWorkItemsTool) convertContent(ctx context.Context, itemDef *WorkItemUpdateDefinition, contentFormat string) {
+	itemDef.Comment = tool.markdown.ConvertContent(ctx, itemDef.Comment, contentFormat)
+	tool.markdown.ConvertFields(ctx, itemDef.FieldsToUpdate, contentFormat)
+}
 
-		// Add field updates
-		for field, value := range itemDef.FieldsToUpdate {
-			operations = append(operations, AddOperation(field, value)) // AddOperation should handle various types
-		}
+// updateItem applies one update definition, or only validates it in a dry run, and returns the
+// result for the JSON response together with its text rendering.
+func (tool *AzureUpdateWorkItemsTool) updateItem(ctx context.Context, itemDef WorkItemUpdateDefinition, dryRun bool) (map[string]any, string) {
+	if itemDef.ID == 0 {
+		errMsg := fmt.Sprintf("Skipped item due to missing or invalid 'id'. Provided: %+v", itemDef)
+		return map[string]any{"id": itemDef.ID, "error": errMsg}, errMsg
+	}
 
-		// Add comment if provided
-		if itemDef.Comment != "" {
-			operations = append(operations, AddOperation("System.History", itemDef.Comment))
-		}
+	var operations []webapi.JsonPatchOperation
+	var notes []string
 
-		// Add relations
-		for _, rel := range itemDef.AddRelations {
-			if rel.RelType == "" || rel.TargetURL == "" {
-				textResults = append(textResults, fmt.Sprintf("Skipping add relation for item %d: rel_type and target_url are required.", itemDef.ID))
-				continue
-			}
-			linkValue := map[string]any{
-				"rel": rel.RelType,
-				"url": rel.TargetURL,
-			}
-			if len(rel.LinkAttributes) > 0 {
-				linkValue["attributes"] = rel.LinkAttributes
-			}
-			operations = append(operations, webapi.JsonPatchOperation{
-				Op:    &webapi.OperationValues.Add,
-				Path:  StringPtr("/relations/-"),
-				Value: linkValue,
-			})
-		}
+	// Add field updates, in a stable order so a dry run shows the document that is sent
+	for _, field := range slices.Sorted(maps.Keys(itemDef.FieldsToUpdate)) {
+		operations = append(operations, AddOperation(field, itemDef.FieldsToUpdate[field])) // AddOperation should handle various types
+	}
 
-		// Note: Removing relations by TargetURL and RelType might require fetching the work item first to get the specific relation index or reference.
-		// The Azure DevOps API for PATCH /wit/workitems/{id} with op: "remove", path: "/relations/{index}" or by reference if the API supports it.
-		// For simplicity, if removing specific relations is complex, this part might need a dedicated tool or further refinement.
-		// The current structure assumes we might get a 'relation_ref' that can be directly used. If not, a GET call is needed.
+	// Add comment if provided
+	if itemDef.Comment != "" {
+		operations = append(operations, AddOperation("System.History", itemDef.Comment))
+	}
 
-		if len(operations) == 0 {
-			msg := fmt.Sprintf("No updates specified for work item #%d (no fields, comment, or relations to add/remove).", itemDef.ID)
-			results = append(results, map[string]any{"id": itemDef.ID, "status": "skipped", "message": msg})
-			textResults = append(textResults, msg)
+	// Add relations
+	for _, rel := range itemDef.AddRelations {
+		if rel.RelType == "" || rel.TargetURL == "" {
+			notes = append(notes, fmt.Sprintf("Skipping add relation for item %d: rel_type and target_url are required.", itemDef.ID))
 			continue
 		}
-
-		updateArgs := workitemtracking.UpdateWorkItemArgs{
-			Id:       &itemDef.ID,
-			Project:  &tool.config.Project,
-			Document: &operations,
-			// ValidateOnly: BoolPtr(false), // Optional: set to true to test without saving
-			// Expand: &workitemtracking.WorkItemExpandValues.Relations, // Optional: to get relations back
+		linkValue := map[string]any{
+			"rel": rel.RelType,
+			"url": rel.TargetURL,
+		}
+		if len(rel.LinkAttributes) > 0 {
+			linkValue["attributes"] = rel.LinkAttributes
 		}
+		operations = append(operations, webapi.JsonPatchOperation{
+			Op:    &webapi.OperationValues.Add,
+			Path:  StringPtr("/relations/-"),
+			Value: linkValue,
+		})
+	}
 
-		updatedWorkItem, err := tool.client.UpdateWorkItem(ctx, updateArgs)
-		if err != nil {
-			errMsg := fmt.Sprintf("Failed to update work item #%d: %v", itemDef.ID, err)
-			results = append(results, map[string]any{"id": itemDef.ID, "error": errMsg})
-			textResults = append(textResults, errMsg)
-			continue
+	// Note: Removing relations by TargetURL and RelType might require fetching the work item first to get the specific relation index or reference.
+	// The Azure DevOps API for PATCH /wit/workitems/{id} with op: "remove", path: "/relations/{index}" or by reference if the API supports it.
+	// For simplicity, if removing specific relations is complex, this part might need a dedicated tool or further refinement.
+	// The current structure assumes we might get a 'relation_ref' that can be directly used. If not, a GET call is needed.
+
+	withNotes := func(text string) string {
+		return strings.Join(append(notes, text), "\n")
+	}
+
+	if len(operations) == 0 {
+		msg := fmt.Sprintf("No updates specified for work item #%d (no fields, comment, or relations to add/remove).", itemDef.ID)
+		return map[string]any{"id": itemDef.ID, "status": "skipped", "message": msg}, withNotes(msg)
+	}
+
+	// Guard against overwriting changes made since the caller read the item.
+	if itemDef.Rev > 0 {
+		operations = append([]webapi.JsonPatchOperation{{
+			Op:    &webapi.OperationValues.Test,
+			Path:  StringPtr("/rev"),
+			Value: itemDef.Rev,
+		}}, operations...)
+	}
+
+	updateArgs := workitemtracking.UpdateWorkItemArgs{
+		Id:       &itemDef.ID,
+		Project:  &tool.config.Project,
+		Document: &operations,
+		// Expand: &workitemtracking.WorkItemExpandValues.Relations, // Optional: to get relations back
+	}
+
+	if dryRun {
+		// Azure DevOps validates the fields, allowed values, state transition and relation targets without saving.
+		updateArgs.ValidateOnly = BoolPtr(true)
+		var problems []string
+		var conflict map[string]any
+		if _, err := tool.client.UpdateWorkItem(ctx, updateArgs); err != nil {
+			if conflict = tool.checkConflict(ctx, itemDef); conflict != nil {
+				problems = append(problems, formatConflictText(conflict))
+			} else {
+				problems = append(problems, err.Error())
+			}
 		}
+		preview := dryRunResult(map[string]any{"id": itemDef.ID, "document": operations}, problems)
+		if conflict != nil {
+			preview["conflict"] = conflict
+		}
+		return preview, withNotes(formatPreviewText(fmt.Sprintf("work item #%d", itemDef.ID), preview))
+	}
 
-		itemResult := map[string]any{
-			"id":      *updatedWorkItem.Id,
-			"rev":     *updatedWorkItem.Rev,
-			"url":     GetWorkItemURL(tool.config.OrganizationURL, *updatedWorkItem.Id),
-			"message": fmt.Sprintf("Work item #%d updated successfully to revision %d.", *updatedWorkItem.Id, *updatedWorkItem.Rev),
+	updatedWorkItem, err := tool.client.UpdateWorkItem(ctx, updateArgs)
+	if err != nil {
+		if conflict := tool.checkConflict(ctx, itemDef); conflict != nil {
+			return conflict, withNotes(formatConflictText(conflict))
 		}
-		results = append(results, itemResult)
-		textResults = append(textResults, itemResult["message"].(string))
+		errMsg := fmt.Sprintf("Failed to update work item #%d: %v", itemDef.ID, err)
+		return map[string]any{"id": itemDef.ID, "error": errMsg}, withNotes(errMsg)
 	}
 
-	if strings.ToLower(format) == "json" {
-		jsonData, err := json.MarshalIndent(results, "", "  ")
-		if err != nil {
-			return mcp.NewToolResultError(fmt.Sprintf("Failed to serialize JSON response: %v", err)), nil
+	itemResult := map[string]any{
+		"id":      *updatedWorkItem.Id,
+		"rev":     *updatedWorkItem.Rev,
+		"url":     GetWorkItemURL(tool.config.OrganizationURL, *updatedWorkItem.Id),
+		"message": fmt.Sprintf("Work item #%d updated successfully to revision %d.", *updatedWorkItem.Id, *updatedWorkItem.Rev),
+	}
+	return itemResult, withNotes(itemResult["message"].(string))
+}
+
+// checkConflict looks up the work item after a failed update with an expected revision. It returns
+// the conflict, with the current values of the fields the caller tried to change, if the item has
+// moved past that revision, and nil otherwise.
+func (tool *AzureUpdateWorkItemsTool) checkConflict(ctx context.Context, itemDef WorkItemUpdateDefinition) map[string]any {
+	if itemDef.Rev <= 0 {
+		return nil
+	}
+
+	current, err := tool.client.GetWorkItem(ctx, workitemtracking.GetWorkItemArgs{
+		Id:      &itemDef.ID,
+		Project: &tool.config.Project,
+	})
+	if err != nil || current.Rev == nil || *current.Rev == itemDef.Rev {
+		return nil
+	}
+
+	var fields map[string]any
+	if current.Fields != nil {
+		fields = *current.Fields
+	}
+	currentValues := make(map[string]any, len(itemDef.FieldsToUpdate))
+	for field := range itemDef.FieldsToUpdate {
+		currentValues[field] = historyValue(fields[fieldReferenceName(field)])
+	}
+
+	return map[string]any{
+		"id":             itemDef.ID,
+		"status":         "conflict",
+		"expected_rev":   itemDef.Rev,
+		"current_rev":    *current.Rev,
+		"changed_by":     historyValue(fields["System.ChangedBy"]),
+		"changed_date":   fields["System.ChangedDate"],
+		"current_values": currentValues,
+		"url":            GetWorkItemURL(tool.config.OrganizationURL, itemDef.ID),
+		"message": fmt.Sprintf("Work item #%d was not updated: expected revision %d, but it is at revision %d. Merge the current values and retry with rev %d.",
+			itemDef.ID, itemDef.Rev, *current.Rev, *current.Rev),
+	}
+}
+
+// formatConflictText renders a conflict with the current values of the conflicting fields.
+func formatConflictText(conflict map[string]any) string {
+	var sb strings.Builder
+	sb.WriteString(conflict["message"].(string))
+	fmt.Fprintf(&sb, "\nLast changed by %v on %v.", conflict["changed_by"], conflict["changed_date"])
+
+	currentValues := conflict["current_values"].(map[string]any)
+	if len(currentValues) > 0 {
+		sb.WriteString("\nCurrent values:")
+		for _, field := range slices.Sorted(maps.Keys(currentValues)) {
+			value := currentValues[field]
+			if value == nil {
+				value = "(empty)"
+			}
+			fmt.Fprintf(&sb, "\n- %s: %v", field, value)
 		}
-		return mcp.NewToolResultText(string(jsonData)), nil
 	}
-	return mcp.NewToolResultText(strings.Join(textResults, "\n---\n")), nil
+	return sb.String()
 }
@@ -0,0 +1,265 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/microsoft/azure-devops-go-api/azuredevops/v7/webapi"
+	"github.com/microsoft/azure-devops-go-api/azuredevops/v7/workitemtracking"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// fakeWorkItemClient keeps work items in memory. Calls it does not implement panic through the
+// embedded nil client.
+type fakeWorkItemClient struct {
+	workitemtracking.Client
+	items   map[int]*workitemtracking.WorkItem
+	updates []workitemtracking.UpdateWorkItemArgs
+	creates []workitemtracking.CreateWorkItemArgs
+	// invalid makes every write fail validation with this error.
+	invalid error
+}
+
+func newFakeWorkItemClient() *fakeWorkItemClient {
+	return &fakeWorkItemClient{items: make(map[int]*workitemtracking.WorkItem)}
+}
+
+// add stores a work item at the given revision.
+func (c *fakeWorkItemClient) add(id, rev int, fields map[string]any) {
+	c.items[id] = &workitemtracking.WorkItem{Id: &id, Rev: &rev, Fields: &fields}
+}
+
+func (c *fakeWorkItemClient) GetWorkItem(_ context.Context, args workitemtracking.GetWorkItemArgs) (*workitemtracking.WorkItem, error) {
+	item, exists := c.items[*args.Id]
+	if !exists {
+		return nil, fmt.Errorf("TF401232: Work item %d does not exist", *args.Id)
+	}
+	return item, nil
+}
+
+func (c *fakeWorkItemClient) GetWorkItems(_ context.Context, args workitemtracking.GetWorkItemsArgs) (*[]workitemtracking.WorkItem, error) {
+	var workItems []workitemtracking.WorkItem
+	for _, id := range *args.Ids {
+		item, exists := c.items[id]
+		switch {
+		case exists:
+			workItems = append(workItems, *item)
+		case args.ErrorPolicy != nil && *args.ErrorPolicy == workitemtracking.WorkItemErrorPolicyValues.Omit:
+			workItems = append(workItems, workitemtracking.WorkItem{})
+		default:
+			return nil, fmt.Errorf("TF401232: Work item %d does not exist", id)
+		}
+	}
+	return &workItems, nil
+}
+
+func (c *fakeWorkItemClient) UpdateWorkItem(_ context.Context, args workitemtracking.UpdateWorkItemArgs) (*workitemtracking.WorkItem, error) {
+	c.updates = append(c.updates, args)
+
+	item, exists := c.items[*args.Id]
+	if !exists {
+		return nil, fmt.Errorf("TF401232: Work item %d does not exist", *args.Id)
+	}
+	if c.invalid != nil {
+		return nil, c.invalid
+	}
+
+	updated := *item.Fields
+	for _, op := range *args.Document {
+		if *op.Op == webapi.OperationValues.Test && *op.Path == "/rev" && op.Value != *item.Rev {
+			return nil, errors.New("TF26071: This work item has been changed by someone else since you opened it")
+		}
+	}
+	if args.ValidateOnly != nil && *args.ValidateOnly {
+		return item, nil
+	}
+
+	for _, op := range *args.Document {
+		if field, ok := strings.CutPrefix(*op.Path, "/fields/"); ok && *op.Op == webapi.OperationValues.Add {
+			updated[field] = op.Value
+		}
+	}
+	rev := *item.Rev + 1
+	item.Rev = &rev
+	return item, nil
+}
+
+func (c *fakeWorkItemClient) CreateWorkItem(_ context.Context, args workitemtracking.CreateWorkItemArgs) (*workitemtracking.WorkItem, error) {
+	c.creates = append(c.creates, args)
+	if c.invalid != nil {
+		return nil, c.invalid
+	}
+	if args.ValidateOnly != nil && *args.ValidateOnly {
+		return &workitemtracking.WorkItem{}, nil
+	}
+
+	id, title, itemType := 1000+len(c.items), "", *args.Type
+	for _, op := range *args.Document {
+		if *op.Path == "/fields/System.Title" {
+			title, _ = op.Value.(string)
+		}
+	}
+	c.items[id] = &workitemtracking.WorkItem{Id: &id, Fields: &map[string]any{"System.Title": &title, "System.WorkItemType": &itemType}}
+	return c.items[id], nil
+}
+
+// DeleteWorkItem moves the item to the recycle bin, or destroys it with the empty response
+// body Azure DevOps sends for a destroy, which the SDK fails to decode.
+func (c *fakeWorkItemClient) DeleteWorkItem(_ context.Context, args workitemtracking.DeleteWorkItemArgs) (*workitemtracking.WorkItemDelete, error) {
+	item, exists := c.items[*args.Id]
+	if !exists {
+		return nil, fmt.Errorf("TF401232: Work item %d does not exist", *args.Id)
+	}
+	delete(c.items, *args.Id)
+
+	if args.Destroy != nil && *args.Destroy {
+		var empty workitemtracking.WorkItemDelete
+		return &empty, json.Unmarshal(nil, &empty)
+	}
+
+	fields := *item.Fields
+	title, _ := fields["System.Title"].(string)
+	itemType, _ := fields["System.WorkItemType"].(string)
+	return &workitemtracking.WorkItemDelete{Id: args.Id, Name: &title, Type: &itemType, DeletedBy: StringPtr("Ada Lovelace")}, nil
+}
+
+// callTool runs a tool handler with the given arguments and returns the text of its result.
+func callTool(handler func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error), arguments map[string]any) string {
+	var request mcp.CallToolRequest
+	request.Params.Arguments = arguments
+
+	result, err := handler(context.Background(), request)
+	So(err, ShouldBeNil)
+	So(result.Content, ShouldHaveLength, 1)
+	return result.Content[0].(mcp.TextContent).Text
+}
+
+func TestUpdateWorkItemsDryRun(t *testing.T) {
+	Convey("Given a work item", t, func() {
+		client := newFakeWorkItemClient()
+		client.add(42, 3, map[string]any{"System.Title": "Fix login", "System.State": "New"})
+		tool := &AzureUpdateWorkItemsTool{client: client, config: AzureDevOpsConfig{Project: "Project", OrganizationURL: "https://dev.azure.com/org"}}
+
+		update := func(dryRun string) string {
+			return callTool(tool.Handler, map[string]any{
+				"items_to_update_json": `[{"id": 42, "fields_to_update": {"System.State": "Active", "System.Title": "Fix login page"}, "comment": "Starting"}]`,
+				"dry_run":              dryRun,
+				"format":               "json",
+			})
+		}
+
+		Convey("A dry run validates the update without saving it", func() {
+			var results []map[string]any
+			So(json.Unmarshal([]byte(update("true")), &results), ShouldBeNil)
+
+			So(results[0]["dry_run"], ShouldEqual, true)
+			So(results[0]["valid"], ShouldEqual, true)
+			So(client.updates, ShouldHaveLength, 1)
+			So(*client.updates[0].ValidateOnly, ShouldBeTrue)
+			So(*client.items[42].Rev, ShouldEqual, 3)
+			So((*client.items[42].Fields)["System.State"], ShouldEqual, "New")
+
+			// The fields come in a stable order, followed by the comment.
+			var paths []string
+			for _, op := range results[0]["document"].([]any) {
+				paths = append(paths, op.(map[string]any)["path"].(string))
+			}
+			So(paths, ShouldResemble, []string{"/fields/System.State", "/fields/System.Title", "/fields/System.History"})
+		})
+
+		Convey("A dry run reports why the update would fail", func() {
+			client.invalid = errors.New("TF401320: Rule Error for field State. Error code: InvalidListValue")
+
+			text := callTool(tool.Handler, map[string]any{
+				"items_to_update_json": `[{"id": 42, "fields_to_update": {"System.State": "Doing"}}]`,
+				"dry_run":              "true",
+			})
+
+			So(text, ShouldStartWith, "Dry run: work item #42 would fail, nothing was changed.\n- TF401320")
+			So(text, ShouldContainSubstring, `"path": "/fields/System.State"`)
+		})
+
+		Convey("Without a dry run the update is saved", func() {
+			var results []map[string]any
+			So(json.Unmarshal([]byte(update("false")), &results), ShouldBeNil)
+
+			So(results[0]["rev"], ShouldEqual, 4)
+			So(client.updates[0].ValidateOnly, ShouldBeNil)
+			So((*client.items[42].Fields)["System.State"], ShouldEqual, "Active")
+		})
+	})
+}
+
+func TestUpdateWorkItemsConflict(t *testing.T) {
+	Convey("Given a work item someone changed since it was read at revision 3", t, func() {
+		client := newFakeWorkItemClient()
+		client.add(42, 5, map[string]any{
+			"System.Title":       "Fix login",
+			"System.State":       "Resolved",
+			"System.ChangedBy":   map[string]any{"displayName": "Ada Lovelace", "uniqueName": "ada@example.com"},
+			"System.ChangedDate": "2026-10-01T09:30:00Z",
+		})
+		tool := &AzureUpdateWorkItemsTool{client: client, config: AzureDevOpsConfig{Project: "Project", OrganizationURL: "https://dev.azure.com/org"}}
+
+		update := func(rev int, format, dryRun string) string {
+			return callTool(tool.Handler, map[string]any{
+				"items_to_update_json": fmt.Sprintf(`[{"id": 42, "rev": %d, "fields_to_update": {"State": "Active", "System.Tags": "login"}}]`, rev),
+				"format":               format,
+				"dry_run":              dryRun,
+			})
+		}
+
+		Convey("The update guards the expected revision and reports the current values", func() {
+			var results []map[string]any
+			So(json.Unmarshal([]byte(update(3, "json", "false")), &results), ShouldBeNil)
+
+			first := (*client.updates[0].Document)[0]
+			So(*first.Op, ShouldEqual, webapi.OperationValues.Test)
+			So(*first.Path, ShouldEqual, "/rev")
+
+			So(results[0]["status"], ShouldEqual, "conflict")
+			So(results[0]["expected_rev"], ShouldEqual, 3)
+			So(results[0]["current_rev"], ShouldEqual, 5)
+			So(results[0]["changed_by"], ShouldEqual, "Ada Lovelace <ada@example.com>")
+			So(results[0]["current_values"], ShouldResemble, map[string]any{"State": "Resolved", "System.Tags": nil})
+			So((*client.items[42].Fields)["System.State"], ShouldEqual, "Resolved")
+		})
+
+		Convey("The text result lists the current values to merge", func() {
+			So(update(3, "text", "false"), ShouldEqual, "Work item #42 was not updated: expected revision 3, but it is at revision 5. Merge the current values and retry with rev 5.\n"+
+				"Last changed by Ada Lovelace <ada@example.com> on 2026-10-01T09:30:00Z.\n"+
+				"Current values:\n"+
+				"- State: Resolved\n"+
+				"- System.Tags: (empty)")
+		})
+
+		Convey("A dry run reports the conflict as the reason the update would fail", func() {
+			var results []map[string]any
+			So(json.Unmarshal([]byte(update(3, "json", "true")), &results), ShouldBeNil)
+
+			So(results[0]["valid"], ShouldEqual, false)
+			So(results[0]["conflict"].(map[string]any)["current_rev"], ShouldEqual, 5)
+		})
+
+		Convey("An update based on the current revision succeeds", func() {
+			var results []map[string]any
+			So(json.Unmarshal([]byte(update(5, "json", "false")), &results), ShouldBeNil)
+
+			So(results[0]["rev"], ShouldEqual, 6)
+		})
+
+		Convey("A failure that is not a conflict is reported as an error", func() {
+			client.invalid = errors.New("TF401320: Rule Error for field State")
+
+			var results []map[string]any
+			So(json.Unmarshal([]byte(update(5, "json", "false")), &results), ShouldBeNil)
+
+			So(results[0]["error"], ShouldEqual, "Failed to update work item #42: TF401320: Rule Error for field State")
+		})
+	})
+}
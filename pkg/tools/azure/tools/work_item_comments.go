@@ -16,9 +16,10 @@ import (
 
 // AzureWorkItemCommentsTool provides functionality to manage comments on work items
 type AzureWorkItemCommentsTool struct {
-	handle mcp.Tool
-	client workitemtracking.Client
-	config AzureDevOpsConfig
+	handle   mcp.Tool
+	client   workitemtracking.Client
+	config   AzureDevOpsConfig
+	markdown *MarkdownConverter
 }
 
 // CommentOutput defines the structure for a single comment's output.
@@ -32,15 +33,16 @@ type CommentOutput struct {
 }
 
 // NewAzureWorkItemCommentsTool creates a new tool instance for managing work item comments
-func NewAzureWorkItemCommentsTool(conn *azuredevops.Connection, config AzureDevOpsConfig) core.Tool {
+func NewAzureWorkItemCommentsTool(conn *azuredevops.Connection, config AzureDevOpsConfig, markdown *MarkdownConverter) core.Tool {
 	client, err := workitemtracking.NewClient(context.Background(), conn)
 	if err != nil {
 		return nil
 	}
 
 	tool := &AzureWorkItemCommentsTool{
-		client: client,
-		config: config,
+		client:   client,
+		config:   config,
+		markdown: markdown,
 	}
 
 	tool.handle = mcp.NewTool(
@@ -61,6 +63,11 @@ func NewAzureWorkItemCommentsTool(conn *azuredevops.Connection, config AzureDevO
 			"text",
 			mcp.Description("Text of the comment to add (required for 'add' operation)"),
 		),
+		mcp.WithString(
+			"content_format",
+			mcp.Description("Comment format: 'html' (default) or 'markdown'. For 'add', Markdown text is converted to HTML; for 'get', comments are returned as Markdown"),
+			mcp.Enum(ContentFormatHTML, ContentFormatMarkdown),
+		),
 		mcp.WithString(
 			"format",
 			mcp.Description("Response format: 'text' (default) or 'json'"),
@@ -160,13 +167,14 @@ Example: "text": "This is a comment on the work item."
 	if text == "" {
 		return mcp.NewToolResultError("Comment text cannot be empty"), nil
 	}
+	contentFormat, _ := GetStringArg(request, "content_format")
 
 	// Add comment as a discussion by updating the History field
 	updateArgs := workitemtracking.UpdateWorkItemArgs{
 		Id:      &id,
 		Project: &tool.config.Project,
 		Document: &[]webapi.JsonPatchOperation{
-			AddOperation("System.History", text),
+			AddOperation("System.History", tool.markdown.ConvertContent(ctx, text, contentFormat)),
 		},
 	}
 
@@ -235,6 +243,8 @@ func formatCommentGetResultsToText(workItemID int, comments []CommentOutput, tot
 }
 
 func (tool *AzureWorkItemCommentsTool) handleGetComments(ctx context.Context, request mcp.CallToolRequest, id int, format string) (*mcp.CallToolResult, error) {
+	contentFormat, _ := GetStringArg(request, "content_format")
+
 	// Get page size if provided
 	pageSizeStr, _ := GetStringArg(request, "page_size")
 	pageSize := 10 // Default page size
@@ -293,6 +303,9 @@ func (tool *AzureWorkItemCommentsTool) handleGetComments(ctx context.Context, re
 		}
 		if rawComment.Text != nil { // Ensure text is not nil
 			comment.Text = *rawComment.Text
+			if strings.EqualFold(contentFormat, ContentFormatMarkdown) {
+				comment.Text = HTMLToMarkdown(comment.Text)
+			}
 		} else {
 			comment.Text = "[Comment text not available]"
 		}
@@ -0,0 +1,514 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/microsoft/azure-devops-go-api/azuredevops/v7"
+	"github.com/microsoft/azure-devops-go-api/azuredevops/v7/workitemtracking"
+	"github.com/theapemachine/mcp-server-devops-bridge/core"
+)
+
+// historyPageSize is the number of updates or revisions requested per call, the API maximum.
+const historyPageSize = 200
+
+// bookkeepingFields change on every revision and are left out of the history unless requested.
+var bookkeepingFields = map[string]bool{
+	"System.Rev":            true,
+	"System.ChangedDate":    true,
+	"System.ChangedBy":      true,
+	"System.AuthorizedDate": true,
+	"System.AuthorizedAs":   true,
+	"System.RevisedDate":    true,
+	"System.Watermark":      true,
+	"System.PersonId":       true,
+}
+
+// FieldChangeOutput is a change of a single field in a work item update.
+type FieldChangeOutput struct {
+	Field    string `json:"field"`
+	OldValue any    `json:"old_value,omitempty"`
+	NewValue any    `json:"new_value,omitempty"`
+}
+
+// RelationChangeOutput is a link added to, removed from or updated on a work item.
+type RelationChangeOutput struct {
+	Action   string `json:"action"`
+	Type     string `json:"type"`
+	TargetID int    `json:"target_id,omitempty"`
+	URL      string `json:"url"`
+}
+
+// WorkItemChangeOutput is one update of a work item: who changed what and when.
+type WorkItemChangeOutput struct {
+	Rev         int                    `json:"rev"`
+	ChangedBy   string                 `json:"changed_by"`
+	ChangedDate time.Time              `json:"changed_date"`
+	Fields      []FieldChangeOutput    `json:"fields,omitempty"`
+	Relations   []RelationChangeOutput `json:"relations,omitempty"`
+}
+
+// WorkItemHistoryOutput is the change history of a work item, oldest change first.
+type WorkItemHistoryOutput struct {
+	ID         int                    `json:"id"`
+	URL        string                 `json:"url"`
+	Changes    []WorkItemChangeOutput `json:"changes"`
+	AsOf       *time.Time             `json:"as_of,omitempty"`
+	AsOfRev    int                    `json:"as_of_rev,omitempty"`
+	AsOfFields map[string]any         `json:"as_of_fields,omitempty"`
+}
+
+// historyFilter narrows a work item history down to the changes of interest.
+type historyFilter struct {
+	fields    map[string]bool // Reference names; empty means every field except bookkeeping ones
+	from      *time.Time
+	to        *time.Time
+	changedBy string
+	relations bool
+}
+
+// AzureWorkItemHistoryTool shows the revision history of work items as field-level diffs.
+type AzureWorkItemHistoryTool struct {
+	handle mcp.Tool
+	client workitemtracking.Client
+	config AzureDevOpsConfig
+}
+
+// NewAzureWorkItemHistoryTool creates a new tool instance for work item history.
+func NewAzureWorkItemHistoryTool(conn *azuredevops.Connection, config AzureDevOpsConfig) core.Tool {
+	client, err := workitemtracking.NewClient(context.Background(), conn)
+	if err != nil {
+		return nil
+	}
+
+	tool := &AzureWorkItemHistoryTool{
+		client: client,
+		config: config,
+	}
+
+	tool.handle = mcp.NewTool(
+		"azure_work_item_history",
+		mcp.WithDescription("Get the audit trail of one or more work items: every change in chronological order with the changed fields (old -> new value), links added or removed, who made the change and when. Can also show the fields as they were at a given moment."),
+		mcp.WithString(
+			"ids",
+			mcp.Required(),
+			mcp.Description("Comma-separated list of work item IDs (e.g., '123,456')."),
+		),
+		mcp.WithString(
+			"fields",
+			mcp.Description("Comma-separated fields to include, as reference names (e.g., 'System.State,System.AssignedTo') or short names (Title, State, Priority, Description). Default: every field except bookkeeping fields such as System.Rev and System.ChangedDate."),
+		),
+		mcp.WithString(
+			"from_date",
+			mcp.Description("Only include changes on or after this date (YYYY-MM-DD or RFC 3339)."),
+		),
+		mcp.WithString(
+			"to_date",
+			mcp.Description("Only include changes on or before this date (YYYY-MM-DD, inclusive, or RFC 3339)."),
+		),
+		mcp.WithString(
+			"changed_by",
+			mcp.Description("Only include changes by people whose display name or email contains this text."),
+		),
+		mcp.WithString(
+			"include_relations",
+			mcp.Description("Whether to include links added, removed or updated. Default: true"),
+			mcp.Enum("true", "false"),
+		),
+		mcp.WithString(
+			"as_of",
+			mcp.Description("Also return the field values as they were at this moment (YYYY-MM-DD means the end of that day, or RFC 3339), from the work item's revisions."),
+		),
+		mcp.WithString(
+			"format",
+			mcp.Description("Response format: 'text' (default) or 'json'."),
+			mcp.Enum("text", "json"),
+		),
+	)
+
+	return tool
+}
+
+func (tool *AzureWorkItemHistoryTool) Handle() mcp.Tool {
+	return tool.handle
+}
+
+func (tool *AzureWorkItemHistoryTool) Handler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	idsStr, err := GetStringArg(request, "ids")
+	if err != nil {
+		return mcp.NewToolResultError("Missing \"ids\" parameter. Provide comma-separated work item IDs."), nil
+	}
+
+	ids, err := ParseIDs(idsStr)
+	if err != nil || len(ids) == 0 {
+		return mcp.NewToolResultError(fmt.Sprintf("Invalid or empty IDs provided: '%s'. Error: %v", idsStr, err)), nil
+	}
+
+	filter := historyFilter{relations: true}
+	if includeRelationsStr, _ := GetStringArg(request, "include_relations"); includeRelationsStr == "false" {
+		filter.relations = false
+	}
+	if fieldsStr, _ := GetStringArg(request, "fields"); fieldsStr != "" {
+		filter.fields = make(map[string]bool)
+		for _, field := range strings.Split(fieldsStr, ",") {
+			if field = strings.TrimSpace(field); field != "" {
+				filter.fields[fieldReferenceName(field)] = true
+			}
+		}
+	}
+	filter.changedBy, _ = GetStringArg(request, "changed_by")
+
+	if fromStr, _ := GetStringArg(request, "from_date"); fromStr != "" {
+		from, err := parseHistoryDate(fromStr, false)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		filter.from = &from
+	}
+	if toStr, _ := GetStringArg(request, "to_date"); toStr != "" {
+		to, err := parseHistoryDate(toStr, true)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		filter.to = &to
+	}
+
+	var asOf *time.Time
+	if asOfStr, _ := GetStringArg(request, "as_of"); asOfStr != "" {
+		moment, err := parseHistoryDate(asOfStr, true)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		asOf = &moment
+	}
+
+	histories := make([]WorkItemHistoryOutput, 0, len(ids))
+	for _, id := range ids {
+		updates, err := tool.getUpdates(ctx, id)
+		if err != nil {
+			return HandleError(err, fmt.Sprintf("Failed to get updates of work item %d", id)), nil
+		}
+
+		history := WorkItemHistoryOutput{
+			ID:      id,
+			URL:     GetWorkItemURL(tool.config.OrganizationURL, id),
+			Changes: buildWorkItemChanges(updates, filter),
+		}
+
+		if asOf != nil {
+			revisions, err := fetchRevisions(ctx, tool.client, tool.config.Project, id)
+			if err != nil {
+				return HandleError(err, fmt.Sprintf("Failed to get revisions of work item %d", id)), nil
+			}
+			history.AsOf = asOf
+			history.AsOfRev, history.AsOfFields = fieldsAsOf(revisions, *asOf, filter.fields)
+		}
+
+		histories = append(histories, history)
+	}
+
+	format, _ := GetStringArg(request, "format")
+	if strings.ToLower(format) == "json" {
+		jsonData, err := json.MarshalIndent(histories, "", "  ")
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("failed to serialize JSON: %v", err)), nil
+		}
+		return mcp.NewToolResultText(string(jsonData)), nil
+	}
+
+	return mcp.NewToolResultText(formatWorkItemHistoryToText(histories)), nil
+}
+
+// getUpdates fetches every update of the work item, paging through the results.
+func (tool *AzureWorkItemHistoryTool) getUpdates(ctx context.Context, id int) ([]workitemtracking.WorkItemUpdate, error) {
+	var all []workitemtracking.WorkItemUpdate
+	for skip := 0; ; skip += historyPageSize {
+		top := historyPageSize
+		page, err := tool.client.GetUpdates(ctx, workitemtracking.GetUpdatesArgs{
+			Id:      &id,
+			Project: &tool.config.Project,
+			Top:     &top,
+			Skip:    &skip,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if page == nil {
+			return all, nil
+		}
+		all = append(all, *page...)
+		if len(*page) < historyPageSize {
+			return all, nil
+		}
+	}
+}
+
+// fetchRevisions fetches every revision of the work item, paging through the results.
+func fetchRevisions(ctx context.Context, client workitemtracking.Client, project string, id int) ([]workitemtracking.WorkItem, error) {
+	var all []workitemtracking.WorkItem
+	for skip := 0; ; skip += historyPageSize {
+		top := historyPageSize
+		page, err := client.GetRevisions(ctx, workitemtracking.GetRevisionsArgs{
+			Id:      &id,
+			Project: &project,
+			Top:     &top,
+			Skip:    &skip,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if page == nil {
+			return all, nil
+		}
+		all = append(all, *page...)
+		if len(*page) < historyPageSize {
+			return all, nil
+		}
+	}
+}
+
+// buildWorkItemChanges turns work item updates into field-level diffs, oldest first, keeping only
+// the changes the filter selects. Updates that are left without any change are dropped.
+func buildWorkItemChanges(updates []workitemtracking.WorkItemUpdate, filter historyFilter) []WorkItemChangeOutput {
+	changes := []WorkItemChangeOutput{}
+	for _, update := range updates {
+		change := WorkItemChangeOutput{}
+		if update.Rev != nil {
+			change.Rev = *update.Rev
+		}
+
+		var fields map[string]workitemtracking.WorkItemFieldUpdate
+		if update.Fields != nil {
+			fields = *update.Fields
+		}
+
+		// RevisedDate is when the revision was superseded, so the change date comes from the fields.
+		if changedDate, ok := fields["System.ChangedDate"]; ok {
+			if date, ok := changedDate.NewValue.(string); ok {
+				change.ChangedDate, _ = time.Parse(time.RFC3339Nano, date)
+			}
+		}
+		if changedBy, ok := fields["System.ChangedBy"]; ok {
+			change.ChangedBy = identityName(changedBy.NewValue)
+		}
+		if change.ChangedBy == "" && update.RevisedBy != nil && update.RevisedBy.DisplayName != nil {
+			change.ChangedBy = *update.RevisedBy.DisplayName
+		}
+
+		if filter.from != nil && change.ChangedDate.Before(*filter.from) {
+			continue
+		}
+		if filter.to != nil && change.ChangedDate.After(*filter.to) {
+			continue
+		}
+		if filter.changedBy != "" && !strings.Contains(strings.ToLower(change.ChangedBy), strings.ToLower(filter.changedBy)) {
+			continue
+		}
+
+		for field, value := range fields {
+			if len(filter.fields) > 0 && !filter.fields[field] {
+				continue
+			}
+			if len(filter.fields) == 0 && bookkeepingFields[field] {
+				continue
+			}
+			change.Fields = append(change.Fields, FieldChangeOutput{
+				Field:    field,
+				OldValue: historyValue(value.OldValue),
+				NewValue: historyValue(value.NewValue),
+			})
+		}
+		sort.Slice(change.Fields, func(i, j int) bool { return change.Fields[i].Field < change.Fields[j].Field })
+
+		if filter.relations && update.Relations != nil {
+			change.Relations = append(change.Relations, relationChanges("added", update.Relations.Added)...)
+			change.Relations = append(change.Relations, relationChanges("removed", update.Relations.Removed)...)
+			change.Relations = append(change.Relations, relationChanges("updated", update.Relations.Updated)...)
+		}
+
+		if len(change.Fields) == 0 && len(change.Relations) == 0 {
+			continue
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+func relationChanges(action string, relations *[]workitemtracking.WorkItemRelation) []RelationChangeOutput {
+	if relations == nil {
+		return nil
+	}
+
+	var changes []RelationChangeOutput
+	for _, relation := range *relations {
+		if relation.Rel == nil || relation.Url == nil {
+			continue
+		}
+		change := RelationChangeOutput{Action: action, Type: *relation.Rel, URL: *relation.Url}
+		if targetID, err := ExtractWorkItemIDFromURL(*relation.Url); err == nil {
+			change.TargetID = targetID
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+// fieldsAsOf returns the revision number and field values of the last revision changed at or
+// before the moment, restricted to the given fields when there are any.
+func fieldsAsOf(revisions []workitemtracking.WorkItem, moment time.Time, fields map[string]bool) (int, map[string]any) {
+	var current *workitemtracking.WorkItem
+	for i := range revisions {
+		if revisions[i].Fields == nil {
+			continue
+		}
+		changedDate, _ := (*revisions[i].Fields)["System.ChangedDate"].(string)
+		date, err := time.Parse(time.RFC3339Nano, changedDate)
+		if err != nil || date.After(moment) {
+			continue
+		}
+		current = &revisions[i]
+	}
+	if current == nil {
+		return 0, nil
+	}
+
+	state := make(map[string]any)
+	for field, value := range *current.Fields {
+		if len(fields) > 0 && !fields[field] {
+			continue
+		}
+		state[field] = historyValue(value)
+	}
+
+	rev := 0
+	if current.Rev != nil {
+		rev = *current.Rev
+	}
+	return rev, state
+}
+
+// historyValue simplifies identity fields to their display name; other values are returned as they are.
+func historyValue(value any) any {
+	if name := identityName(value); name != "" {
+		return name
+	}
+	return value
+}
+
+// identityName returns the display name and email of an identity field value, or an empty string
+// if the value is not an identity.
+func identityName(value any) string {
+	identity, ok := value.(map[string]any)
+	if !ok {
+		return ""
+	}
+	name, _ := identity["displayName"].(string)
+	if email, ok := identity["uniqueName"].(string); ok && email != "" && email != name {
+		return fmt.Sprintf("%s <%s>", name, email)
+	}
+	return name
+}
+
+// fieldReferenceName maps short field names such as "State" to their reference names.
+func fieldReferenceName(field string) string {
+	for name, reference := range FieldMap {
+		if strings.EqualFold(name, field) {
+			return reference
+		}
+	}
+	return field
+}
+
+// parseHistoryDate parses a date filter. A plain date means the start of that day, or the end of
+// it when endOfDay is set, so date ranges are inclusive.
+func parseHistoryDate(value string, endOfDay bool) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if moment, err := time.Parse(time.RFC3339, value); err == nil {
+		return moment, nil
+	}
+
+	day, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q: use YYYY-MM-DD or RFC 3339", value)
+	}
+	if endOfDay {
+		return day.Add(24*time.Hour - time.Nanosecond), nil
+	}
+	return day, nil
+}
+
+// formatWorkItemHistoryToText formats work item histories as a readable audit trail.
+func formatWorkItemHistoryToText(histories []WorkItemHistoryOutput) string {
+	var b strings.Builder
+	for _, history := range histories {
+		fmt.Fprintf(&b, "## History of Work Item #%d\n", history.ID)
+		fmt.Fprintf(&b, "URL: %s\n\n", history.URL)
+
+		if len(history.Changes) == 0 {
+			b.WriteString("No changes match the filters.\n\n")
+		}
+
+		for _, change := range history.Changes {
+			fmt.Fprintf(&b, "### Rev %d - %s by %s\n", change.Rev, change.ChangedDate.Format("2006-01-02 15:04"), change.ChangedBy)
+			for _, field := range change.Fields {
+				fmt.Fprintf(&b, "- %s: %s -> %s\n", field.Field, historyText(field.OldValue), historyText(field.NewValue))
+			}
+			for _, relation := range change.Relations {
+				target := relation.URL
+				if relation.TargetID != 0 {
+					target = fmt.Sprintf("#%d", relation.TargetID)
+				}
+				fmt.Fprintf(&b, "- Link %s: %s %s\n", relation.Action, relation.Type, target)
+			}
+			b.WriteString("\n")
+		}
+
+		if history.AsOf != nil {
+			fmt.Fprintf(&b, "### Fields as of %s\n", history.AsOf.Format("2006-01-02 15:04"))
+			if history.AsOfFields == nil {
+				b.WriteString("The work item did not exist yet.\n\n")
+				continue
+			}
+			fmt.Fprintf(&b, "(Rev %d)\n", history.AsOfRev)
+
+			fields := make([]string, 0, len(history.AsOfFields))
+			for field := range history.AsOfFields {
+				fields = append(fields, field)
+			}
+			sort.Strings(fields)
+			for _, field := range fields {
+				fmt.Fprintf(&b, "- %s: %s\n", field, historyText(history.AsOfFields[field]))
+			}
+			b.WriteString("\n")
+		}
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// historyText renders a field value on one line, shortening long values such as descriptions.
+func historyText(value any) string {
+	if value == nil {
+		return "(empty)"
+	}
+
+	text := fmt.Sprint(value)
+	if _, isString := value.(string); !isString {
+		if data, err := json.Marshal(value); err == nil {
+			text = string(data)
+		}
+	}
+
+	text = strings.Join(strings.Fields(text), " ")
+	if len(text) > 200 {
+		text = text[:200] + "..."
+	}
+	if text == "" {
+		return `""`
+	}
+	return text
+}
@@ -0,0 +1,71 @@
+package tools
+
+import (
+	"testing"
+	"time"
+
+	"github.com/microsoft/azure-devops-go-api/azuredevops/v7/workitemtracking"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func historyUpdate(rev int, changedDate string, fields map[string]workitemtracking.WorkItemFieldUpdate) workitemtracking.WorkItemUpdate {
+	fields["System.ChangedDate"] = workitemtracking.WorkItemFieldUpdate{NewValue: changedDate}
+	fields["System.ChangedBy"] = workitemtracking.WorkItemFieldUpdate{NewValue: map[string]any{"displayName": "Ada", "uniqueName": "ada@example.com"}}
+	fields["System.Rev"] = workitemtracking.WorkItemFieldUpdate{OldValue: float64(rev - 1), NewValue: float64(rev)}
+	return workitemtracking.WorkItemUpdate{Rev: &rev, Fields: &fields}
+}
+
+func TestBuildWorkItemChanges(t *testing.T) {
+	Convey("Given the updates of a work item", t, func() {
+		relationType := "System.LinkTypes.Hierarchy-Reverse"
+		relationURL := "https://dev.azure.com/org/project/_apis/wit/workItems/42"
+
+		updates := []workitemtracking.WorkItemUpdate{
+			historyUpdate(1, "2024-03-01T09:00:00Z", map[string]workitemtracking.WorkItemFieldUpdate{
+				"System.Title": {NewValue: "Login page"},
+				"System.State": {NewValue: "New"},
+			}),
+			historyUpdate(2, "2024-03-05T14:30:00Z", map[string]workitemtracking.WorkItemFieldUpdate{
+				"System.State":      {OldValue: "New", NewValue: "Active"},
+				"System.AssignedTo": {NewValue: map[string]any{"displayName": "Grace", "uniqueName": "grace@example.com"}},
+			}),
+			historyUpdate(3, "2024-03-09T08:00:00Z", map[string]workitemtracking.WorkItemFieldUpdate{}),
+		}
+		updates[2].Relations = &workitemtracking.WorkItemRelationUpdates{
+			Added: &[]workitemtracking.WorkItemRelation{{Rel: &relationType, Url: &relationURL}},
+		}
+
+		Convey("Without filters every change is listed, without bookkeeping fields", func() {
+			changes := buildWorkItemChanges(updates, historyFilter{relations: true})
+
+			So(changes, ShouldHaveLength, 3)
+			So(changes[0].ChangedBy, ShouldEqual, "Ada <ada@example.com>")
+			So(changes[0].ChangedDate, ShouldEqual, time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC))
+			So(changes[1].Fields, ShouldResemble, []FieldChangeOutput{
+				{Field: "System.AssignedTo", NewValue: "Grace <grace@example.com>"},
+				{Field: "System.State", OldValue: "New", NewValue: "Active"},
+			})
+			So(changes[2].Relations, ShouldResemble, []RelationChangeOutput{
+				{Action: "added", Type: relationType, TargetID: 42, URL: relationURL},
+			})
+		})
+
+		Convey("A field filter drops updates that did not change the field", func() {
+			changes := buildWorkItemChanges(updates, historyFilter{fields: map[string]bool{"System.State": true}})
+
+			So(changes, ShouldHaveLength, 2)
+			So(changes[1].Fields, ShouldResemble, []FieldChangeOutput{
+				{Field: "System.State", OldValue: "New", NewValue: "Active"},
+			})
+		})
+
+		Convey("A date range keeps only the changes within it", func() {
+			from, _ := parseHistoryDate("2024-03-02", false)
+			to, _ := parseHistoryDate("2024-03-05", true)
+			changes := buildWorkItemChanges(updates, historyFilter{from: &from, to: &to, relations: true})
+
+			So(changes, ShouldHaveLength, 1)
+			So(changes[0].Rev, ShouldEqual, 2)
+		})
+	})
+}
@@ -0,0 +1,404 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/microsoft/azure-devops-go-api/azuredevops/v7"
+	"github.com/microsoft/azure-devops-go-api/azuredevops/v7/workitemtracking"
+	"github.com/theapemachine/mcp-server-devops-bridge/core"
+)
+
+const (
+	defaultTreeDepth = 5
+	treePageSize     = 200 // The most work items GetWorkItems returns in one call
+)
+
+// treeFields are the fields shown for every work item in a tree.
+var treeFields = []string{
+	"System.Id", "System.Title", "System.WorkItemType", "System.State", "System.AssignedTo",
+	"Microsoft.VSTS.Scheduling.RemainingWork", "Microsoft.VSTS.Scheduling.StoryPoints",
+	"Microsoft.VSTS.Scheduling.Effort",
+}
+
+// WorkItemTreeNode is a work item with its children. Rolled-up values include the item itself and
+// all its descendants in the tree.
+type WorkItemTreeNode struct {
+	ID                    int                 `json:"id"`
+	Title                 string              `json:"title"`
+	Type                  string              `json:"type"`
+	State                 string              `json:"state"`
+	AssignedTo            string              `json:"assigned_to,omitempty"`
+	URL                   string              `json:"url"`
+	RemainingWork         float64             `json:"remaining_work,omitempty"`
+	StoryPoints           float64             `json:"story_points,omitempty"`
+	RolledUpRemainingWork float64             `json:"rolled_up_remaining_work"`
+	RolledUpStoryPoints   float64             `json:"rolled_up_story_points"`
+	HasMoreChildren       bool                `json:"has_more_children,omitempty"` // Children exist below the depth limit
+	Children              []*WorkItemTreeNode `json:"children,omitempty"`
+
+	childIDs []int
+}
+
+// AzureWorkItemTreeTool shows work items and their descendants as a tree.
+type AzureWorkItemTreeTool struct {
+	handle mcp.Tool
+	client workitemtracking.Client
+	config AzureDevOpsConfig
+}
+
+// NewAzureWorkItemTreeTool creates a new tool instance for showing work item hierarchies.
+func NewAzureWorkItemTreeTool(conn *azuredevops.Connection, config AzureDevOpsConfig) core.Tool {
+	client, err := workitemtracking.NewClient(context.Background(), conn)
+	if err != nil {
+		return nil
+	}
+
+	tool := &AzureWorkItemTreeTool{
+		client: client,
+		config: config,
+	}
+
+	tool.handle = mcp.NewTool(
+		"azure_work_item_tree",
+		mcp.WithDescription("Show the hierarchy below work items as an indented tree with type, state, assignee and remaining work and story points rolled up from the descendants."),
+		mcp.WithString(
+			"ids",
+			mcp.Description("Comma-separated list of root work item IDs. Either ids or query is required."),
+		),
+		mcp.WithString(
+			"query",
+			mcp.Description("WIQL tree query instead of ids, e.g. SELECT [System.Id] FROM WorkItemLinks WHERE [Source].[System.WorkItemType] = 'Epic' AND [System.Links.LinkType] = 'System.LinkTypes.Hierarchy-Forward' MODE (Recursive). A flat query selects the roots."),
+		),
+		mcp.WithNumber(
+			"max_depth",
+			mcp.Description(fmt.Sprintf("Number of levels below the roots to show (default: %d)", defaultTreeDepth)),
+		),
+		mcp.WithString("format", mcp.Description("Response format: 'text' (default) or 'json'")),
+	)
+
+	return tool
+}
+
+func (tool *AzureWorkItemTreeTool) Handle() mcp.Tool {
+	return tool.handle
+}
+
+func (tool *AzureWorkItemTreeTool) Handler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	maxDepth := defaultTreeDepth
+	if value, err := GetIntArg(request, "max_depth"); err == nil && value >= 0 {
+		maxDepth = value
+	}
+	format, _ := GetStringArg(request, "format")
+
+	var roots []*WorkItemTreeNode
+	var err error
+
+	idsStr, _ := GetStringArg(request, "ids")
+	query, _ := GetStringArg(request, "query")
+
+	switch {
+	case idsStr != "":
+		ids, parseErr := ParseIDs(idsStr)
+		if parseErr != nil {
+			return HandleError(parseErr, "Invalid work item IDs"), nil
+		}
+		roots, err = tool.walk(ctx, ids, maxDepth)
+	case query != "":
+		roots, err = tool.fromQuery(ctx, query, maxDepth)
+	default:
+		return mcp.NewToolResultError("Provide either ids or a WIQL query to select the root work items."), nil
+	}
+	if err != nil {
+		return HandleError(err, "Failed to build the work item tree"), nil
+	}
+	if len(roots) == 0 {
+		return mcp.NewToolResultText("No work items found."), nil
+	}
+
+	for _, root := range roots {
+		rollUp(root)
+	}
+
+	if strings.ToLower(format) == "json" {
+		jsonData, err := json.MarshalIndent(roots, "", "  ")
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to serialize JSON response: %v", err)), nil
+		}
+		return mcp.NewToolResultText(string(jsonData)), nil
+	}
+
+	return mcp.NewToolResultText(formatWorkItemTreeText(roots)), nil
+}
+
+// walk builds the trees below the roots by following Hierarchy-Forward links, level by level, up
+// to maxDepth levels below the roots. Roots that are descendants of other roots are not returned
+// as roots.
+func (tool *AzureWorkItemTreeTool) walk(ctx context.Context, rootIDs []int, maxDepth int) ([]*WorkItemTreeNode, error) {
+	nodes := make(map[int]*WorkItemTreeNode)
+	for _, id := range rootIDs {
+		nodes[id] = nil
+	}
+	level := rootIDs
+
+	for depth := 0; len(level) > 0; depth++ {
+		workItems, err := fetchWorkItems(ctx, tool.client, tool.config.Project, level, true)
+		if err != nil {
+			return nil, err
+		}
+
+		var next []int
+		for _, workItem := range workItems {
+			node := treeNode(workItem, tool.config.OrganizationURL)
+			nodes[node.ID] = node
+			for _, childID := range node.childIDs {
+				if depth >= maxDepth {
+					node.HasMoreChildren = true
+					break
+				}
+				if _, seen := nodes[childID]; seen {
+					continue
+				}
+				nodes[childID] = nil // Claimed, so a child reachable twice appears once
+				next = append(next, childID)
+			}
+		}
+		level = next
+	}
+
+	attached := make(map[int]bool)
+	for _, node := range nodes {
+		if node == nil {
+			continue
+		}
+		for _, childID := range node.childIDs {
+			if child := nodes[childID]; child != nil && child != node && !containsNode(node.Children, child) {
+				node.Children = append(node.Children, child)
+				attached[childID] = true
+			}
+		}
+	}
+
+	// A requested root below another requested root is shown under it only, so that its work is
+	// not rolled up into the totals twice.
+	var roots []*WorkItemTreeNode
+	for _, id := range rootIDs {
+		if node := nodes[id]; node != nil && !attached[id] {
+			roots = append(roots, node)
+		}
+	}
+	return roots, nil
+}
+
+// fromQuery builds the trees returned by a WIQL tree query. The links of the query decide the
+// shape; a flat query only selects the roots, whose descendants are then walked.
+func (tool *AzureWorkItemTreeTool) fromQuery(ctx context.Context, query string, maxDepth int) ([]*WorkItemTreeNode, error) {
+	result, err := tool.client.QueryByWiql(ctx, workitemtracking.QueryByWiqlArgs{
+		Wiql:    &workitemtracking.Wiql{Query: &query},
+		Project: &tool.config.Project,
+		Team:    &tool.config.Team,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if result.WorkItemRelations == nil || len(*result.WorkItemRelations) == 0 {
+		var ids []int
+		if result.WorkItems != nil {
+			for _, ref := range *result.WorkItems {
+				ids = append(ids, *ref.Id)
+			}
+		}
+		return tool.walk(ctx, ids, maxDepth)
+	}
+
+	var rootIDs, ids []int
+	children := make(map[int][]int)
+	for _, link := range *result.WorkItemRelations {
+		if link.Target == nil || link.Target.Id == nil {
+			continue
+		}
+		ids = append(ids, *link.Target.Id)
+		if link.Source == nil || link.Source.Id == nil {
+			rootIDs = append(rootIDs, *link.Target.Id)
+			continue
+		}
+		children[*link.Source.Id] = append(children[*link.Source.Id], *link.Target.Id)
+	}
+
+	workItems, err := fetchWorkItems(ctx, tool.client, tool.config.Project, ids, false)
+	if err != nil {
+		return nil, err
+	}
+	nodes := make(map[int]*WorkItemTreeNode, len(workItems))
+	for _, workItem := range workItems {
+		node := treeNode(workItem, tool.config.OrganizationURL)
+		nodes[node.ID] = node
+	}
+
+	var attach func(node *WorkItemTreeNode, depth int, path map[int]bool)
+	attach = func(node *WorkItemTreeNode, depth int, path map[int]bool) {
+		path[node.ID] = true
+		defer delete(path, node.ID)
+		for _, childID := range children[node.ID] {
+			child := nodes[childID]
+			if child == nil || path[childID] {
+				continue
+			}
+			if depth >= maxDepth {
+				node.HasMoreChildren = true
+				continue
+			}
+			node.Children = append(node.Children, child)
+			attach(child, depth+1, path)
+		}
+	}
+
+	var roots []*WorkItemTreeNode
+	for _, id := range rootIDs {
+		if node := nodes[id]; node != nil {
+			attach(node, 0, make(map[int]bool))
+			roots = append(roots, node)
+		}
+	}
+	return roots, nil
+}
+
+// fetchWorkItems fetches work items in pages, either with their relations or only the tree fields.
+// Work items that do not exist or cannot be read are left out.
+func fetchWorkItems(ctx context.Context, client workitemtracking.Client, project string, ids []int, withRelations bool) ([]workitemtracking.WorkItem, error) {
+	var workItems []workitemtracking.WorkItem
+	for start := 0; start < len(ids); start += treePageSize {
+		page := ids[start:min(start+treePageSize, len(ids))]
+		args := workitemtracking.GetWorkItemsArgs{
+			Ids:         &page,
+			Project:     &project,
+			ErrorPolicy: &workitemtracking.WorkItemErrorPolicyValues.Omit,
+		}
+		if withRelations {
+			args.Expand = &workitemtracking.WorkItemExpandValues.Relations
+		} else {
+			args.Fields = &treeFields
+		}
+
+		result, err := client.GetWorkItems(ctx, args)
+		if err != nil {
+			return nil, err
+		}
+		for _, workItem := range *result {
+			if workItem.Id != nil && workItem.Fields != nil {
+				workItems = append(workItems, workItem)
+			}
+		}
+	}
+	return workItems, nil
+}
+
+// treeNode extracts the fields shown in a tree, and the IDs of the children if relations were fetched.
+func treeNode(workItem workitemtracking.WorkItem, orgURL string) *WorkItemTreeNode {
+	fields := *workItem.Fields
+	node := &WorkItemTreeNode{
+		ID:            *workItem.Id,
+		URL:           GetWorkItemURL(orgURL, *workItem.Id),
+		RemainingWork: numberField(fields, "Microsoft.VSTS.Scheduling.RemainingWork"),
+		StoryPoints:   numberField(fields, "Microsoft.VSTS.Scheduling.StoryPoints"),
+	}
+	node.Title, _ = fields["System.Title"].(string)
+	node.Type, _ = fields["System.WorkItemType"].(string)
+	node.State, _ = fields["System.State"].(string)
+	if assignedTo, ok := fields["System.AssignedTo"].(map[string]any); ok {
+		node.AssignedTo, _ = assignedTo["displayName"].(string)
+	}
+	if node.StoryPoints == 0 {
+		// Scrum processes size backlog items by effort instead of story points.
+		node.StoryPoints = numberField(fields, "Microsoft.VSTS.Scheduling.Effort")
+	}
+
+	if workItem.Relations != nil {
+		for _, relation := range *workItem.Relations {
+			if relation.Rel == nil || *relation.Rel != "System.LinkTypes.Hierarchy-Forward" || relation.Url == nil {
+				continue
+			}
+			if childID, err := ExtractWorkItemIDFromURL(*relation.Url); err == nil {
+				node.childIDs = append(node.childIDs, childID)
+			}
+		}
+	}
+	return node
+}
+
+// numberField reads a numeric field, which the API returns as a float64, or as a string for some
+// custom fields.
+func numberField(fields map[string]any, field string) float64 {
+	switch value := fields[field].(type) {
+	case float64:
+		return value
+	case string:
+		number, _ := strconv.ParseFloat(value, 64)
+		return number
+	}
+	return 0
+}
+
+// rollUp sums the remaining work and story points of a node and its descendants.
+func rollUp(node *WorkItemTreeNode) {
+	node.RolledUpRemainingWork = node.RemainingWork
+	node.RolledUpStoryPoints = node.StoryPoints
+	for _, child := range node.Children {
+		rollUp(child)
+		node.RolledUpRemainingWork += child.RolledUpRemainingWork
+		node.RolledUpStoryPoints += child.RolledUpStoryPoints
+	}
+}
+
+func containsNode(nodes []*WorkItemTreeNode, node *WorkItemTreeNode) bool {
+	for _, n := range nodes {
+		if n == node {
+			return true
+		}
+	}
+	return false
+}
+
+// formatWorkItemTreeText renders the trees as an indented outline.
+func formatWorkItemTreeText(roots []*WorkItemTreeNode) string {
+	var sb strings.Builder
+	sb.WriteString("## Work Item Tree\n\n")
+
+	var write func(node *WorkItemTreeNode, depth int)
+	write = func(node *WorkItemTreeNode, depth int) {
+		fmt.Fprintf(&sb, "%s- #%d [%s] %s (%s", strings.Repeat("  ", depth), node.ID, node.Type, node.Title, node.State)
+		if node.AssignedTo != "" {
+			fmt.Fprintf(&sb, ", %s", node.AssignedTo)
+		}
+		sb.WriteString(")")
+
+		var totals []string
+		if node.RolledUpRemainingWork > 0 {
+			totals = append(totals, fmt.Sprintf("%sh remaining", strconv.FormatFloat(node.RolledUpRemainingWork, 'f', -1, 64)))
+		}
+		if node.RolledUpStoryPoints > 0 {
+			totals = append(totals, fmt.Sprintf("%s points", strconv.FormatFloat(node.RolledUpStoryPoints, 'f', -1, 64)))
+		}
+		if len(totals) > 0 {
+			fmt.Fprintf(&sb, " | %s", strings.Join(totals, ", "))
+		}
+		if node.HasMoreChildren {
+			sb.WriteString(" | more levels below, increase max_depth to see them")
+		}
+		sb.WriteString("\n")
+
+		for _, child := range node.Children {
+			write(child, depth+1)
+		}
+	}
+
+	for _, root := range roots {
+		write(root, 0)
+	}
+	return sb.String()
+}
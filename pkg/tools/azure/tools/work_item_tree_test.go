@@ -0,0 +1,85 @@
+package tools
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/microsoft/azure-devops-go-api/azuredevops/v7/workitemtracking"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestRollUp(t *testing.T) {
+	Convey("Given a tree of work items", t, func() {
+		task := &WorkItemTreeNode{ID: 3, Type: "Task", Title: "Build", State: "Active", RemainingWork: 4}
+		otherTask := &WorkItemTreeNode{ID: 4, Type: "Task", Title: "Test", State: "New", RemainingWork: 2.5}
+		story := &WorkItemTreeNode{ID: 2, Type: "User Story", Title: "Login", State: "Active", AssignedTo: "Ada", StoryPoints: 5, Children: []*WorkItemTreeNode{task, otherTask}}
+		epic := &WorkItemTreeNode{ID: 1, Type: "Epic", Title: "Accounts", State: "New", Children: []*WorkItemTreeNode{story}, HasMoreChildren: true}
+
+		rollUp(epic)
+
+		Convey("Remaining work and story points include all descendants", func() {
+			So(story.RolledUpRemainingWork, ShouldEqual, 6.5)
+			So(epic.RolledUpRemainingWork, ShouldEqual, 6.5)
+			So(epic.RolledUpStoryPoints, ShouldEqual, 5)
+			So(task.RolledUpStoryPoints, ShouldEqual, 0)
+		})
+
+		Convey("The outline is indented by depth", func() {
+			lines := strings.Split(strings.TrimSpace(formatWorkItemTreeText([]*WorkItemTreeNode{epic})), "\n")
+			So(lines[2], ShouldStartWith, "- #1 [Epic] Accounts (New) | 6.5h remaining, 5 points | more levels below")
+			So(lines[3], ShouldEqual, "  - #2 [User Story] Login (Active, Ada) | 6.5h remaining, 5 points")
+			So(lines[4], ShouldEqual, "    - #3 [Task] Build (Active) | 4h remaining")
+		})
+	})
+}
+
+func TestNumberField(t *testing.T) {
+	Convey("Numeric fields are read from numbers and strings", t, func() {
+		fields := map[string]any{"a": 3.5, "b": "2", "c": true}
+		So(numberField(fields, "a"), ShouldEqual, 3.5)
+		So(numberField(fields, "b"), ShouldEqual, 2)
+		So(numberField(fields, "c"), ShouldEqual, 0)
+		So(numberField(fields, "missing"), ShouldEqual, 0)
+	})
+}
+
+func TestWorkItemTreeWalk(t *testing.T) {
+	Convey("Given an epic with a story and its task", t, func() {
+		client := newFakeWorkItemClient()
+		child := func(id int) workitemtracking.WorkItemRelation {
+			return workitemtracking.WorkItemRelation{
+				Rel: StringPtr("System.LinkTypes.Hierarchy-Forward"),
+				Url: StringPtr("https://dev.azure.com/org/_apis/wit/workItems/" + strconv.Itoa(id)),
+			}
+		}
+		client.add(1, 1, map[string]any{"System.Title": "Accounts", "System.WorkItemType": "Epic"})
+		client.add(2, 1, map[string]any{"System.Title": "Login", "System.WorkItemType": "User Story"})
+		client.add(3, 1, map[string]any{"System.Title": "Build", "System.WorkItemType": "Task", "Microsoft.VSTS.Scheduling.RemainingWork": 4.0})
+		client.items[1].Relations = &[]workitemtracking.WorkItemRelation{child(2)}
+		client.items[2].Relations = &[]workitemtracking.WorkItemRelation{child(3)}
+		tool := &AzureWorkItemTreeTool{client: client, config: AzureDevOpsConfig{Project: "Project", OrganizationURL: "https://dev.azure.com/org"}}
+
+		Convey("A requested root below another requested root is only shown under it", func() {
+			roots, err := tool.walk(context.Background(), []int{3, 1}, defaultTreeDepth)
+			So(err, ShouldBeNil)
+
+			So(roots, ShouldHaveLength, 1)
+			So(roots[0].ID, ShouldEqual, 1)
+			So(roots[0].Children[0].Children[0].ID, ShouldEqual, 3)
+
+			rollUp(roots[0])
+			So(roots[0].RolledUpRemainingWork, ShouldEqual, 4)
+		})
+
+		Convey("A root that cannot be read is left out", func() {
+			roots, err := tool.walk(context.Background(), []int{2, 99}, defaultTreeDepth)
+			So(err, ShouldBeNil)
+
+			So(roots, ShouldHaveLength, 1)
+			So(roots[0].ID, ShouldEqual, 2)
+			So(roots[0].Children, ShouldHaveLength, 1)
+		})
+	})
+}
@@ -0,0 +1,480 @@
+// Package htmlmd renders HTML as Markdown. It is shared by the tools that read web pages and the
+// tools that read rich text fields, which customise it through Options.
+package htmlmd
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Checklist items are written as ballot boxes, since rich text editors such as the one of Azure
+// DevOps strip checkbox inputs. List items starting with one become Markdown check list items.
+const (
+	UncheckedBox = "☐"
+	CheckedBox   = "☑"
+)
+
+// Options customise the rendering.
+type Options struct {
+	// Base resolves relative links and image sources. Without it they are kept as written.
+	Base *url.URL
+	// Skip reports whether an element is left out together with its content. Scripts and styles
+	// are always left out.
+	Skip func(n *html.Node) bool
+	// Inline renders an inline element, given its rendered content, in place of the default
+	// rendering when it returns true.
+	Inline func(n *html.Node, inner string) (string, bool)
+}
+
+// inlineElements are rendered as part of the surrounding paragraph.
+var inlineElements = map[atom.Atom]bool{
+	atom.A: true, atom.Abbr: true, atom.B: true, atom.Bdi: true, atom.Bdo: true,
+	atom.Br: true, atom.Cite: true, atom.Code: true, atom.Data: true, atom.Del: true,
+	atom.Dfn: true, atom.Em: true, atom.Font: true, atom.I: true, atom.Img: true,
+	atom.Input: true, atom.Ins: true, atom.Kbd: true, atom.Label: true, atom.Mark: true,
+	atom.Q: true, atom.S: true, atom.Samp: true, atom.Small: true, atom.Span: true,
+	atom.Strike: true, atom.Strong: true, atom.Sub: true, atom.Sup: true, atom.Time: true,
+	atom.U: true, atom.Var: true, atom.Wbr: true,
+}
+
+// Convert renders the children of n as Markdown. Headings, paragraphs, emphasis, code, links,
+// images, block quotes, lists, check lists and tables are preserved.
+func Convert(n *html.Node, options Options) string {
+	w := &writer{options: options}
+	w.walkBlocks(n)
+	w.flush()
+	return strings.Join(w.blocks, "\n\n")
+}
+
+// ConvertFragment parses an HTML fragment, such as the value of a rich text field, and renders it
+// as Markdown. Content that cannot be parsed is returned as is.
+func ConvertFragment(content string, options Options) string {
+	if strings.TrimSpace(content) == "" {
+		return ""
+	}
+
+	root := &html.Node{Type: html.ElementNode, Data: "div", DataAtom: atom.Div}
+	nodes, err := html.ParseFragment(strings.NewReader(content), root)
+	if err != nil {
+		return content
+	}
+	for _, node := range nodes {
+		root.AppendChild(node)
+	}
+	return Convert(root, options)
+}
+
+// writer renders block-level HTML into Markdown blocks. Inline content between blocks is
+// gathered into paragraphs.
+type writer struct {
+	options Options
+	blocks  []string
+	inline  strings.Builder
+}
+
+// walkBlocks renders the children of n, starting a new block for every block-level child.
+func (w *writer) walkBlocks(n *html.Node) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		switch {
+		case c.Type == html.TextNode:
+			w.inline.WriteString(w.inlineText(c))
+		case c.Type != html.ElementNode || w.skip(c):
+			continue
+		case inlineElements[c.DataAtom]:
+			w.inline.WriteString(w.inlineText(c))
+		default:
+			w.flush()
+			w.block(c)
+		}
+	}
+}
+
+// flush turns the gathered inline content into a paragraph.
+func (w *writer) flush() {
+	text := w.inline.String()
+	w.inline.Reset()
+	w.add(normalizeParagraph(text))
+}
+
+func (w *writer) add(block string) {
+	if strings.TrimSpace(block) != "" {
+		w.blocks = append(w.blocks, block)
+	}
+}
+
+func (w *writer) skip(n *html.Node) bool {
+	if n.DataAtom == atom.Script || n.DataAtom == atom.Style {
+		return true
+	}
+	return w.options.Skip != nil && w.options.Skip(n)
+}
+
+// block renders a single block-level element.
+func (w *writer) block(n *html.Node) {
+	switch n.DataAtom {
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		level := int(n.Data[1] - '0')
+		if text := strings.ReplaceAll(w.inlineChildren(n), "\n", " "); text != "" {
+			w.add(strings.Repeat("#", level) + " " + text)
+		}
+	case atom.P, atom.Dt, atom.Summary, atom.Figcaption:
+		w.add(w.inlineChildren(n))
+	case atom.Pre:
+		w.add(codeBlock(n))
+	case atom.Blockquote:
+		w.add(prefixLines(w.subBlocks(n, "\n\n"), "> "))
+	case atom.Ul, atom.Ol:
+		w.add(w.list(n))
+	case atom.Table:
+		w.add(w.table(n))
+	case atom.Hr:
+		w.add("---")
+	default:
+		w.walkBlocks(n)
+		w.flush()
+	}
+}
+
+// subBlocks renders the children of n with a fresh writer and joins the resulting blocks.
+func (w *writer) subBlocks(n *html.Node, separator string) string {
+	sub := &writer{options: w.options}
+	sub.walkBlocks(n)
+	sub.flush()
+	return strings.Join(sub.blocks, separator)
+}
+
+// list renders an ordered or unordered list. Nested lists are indented under their item.
+func (w *writer) list(n *html.Node) string {
+	var items []string
+	number := 1
+	if start, err := strconv.Atoi(Attr(n, "start")); err == nil {
+		number = start
+	}
+
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type != html.ElementNode || c.DataAtom != atom.Li {
+			continue
+		}
+
+		marker := "- "
+		if n.DataAtom == atom.Ol {
+			marker = fmt.Sprintf("%d. ", number)
+			number++
+		}
+
+		content := w.subBlocks(c, "\n")
+		if content == "" {
+			continue
+		}
+		if rest, ok := strings.CutPrefix(content, UncheckedBox); ok {
+			content = "[ ]" + rest
+		} else if rest, ok := strings.CutPrefix(content, CheckedBox); ok {
+			content = "[x]" + rest
+		}
+
+		indent := strings.Repeat(" ", len(marker))
+		lines := strings.Split(content, "\n")
+		for i := 1; i < len(lines); i++ {
+			if lines[i] != "" {
+				lines[i] = indent + lines[i]
+			}
+		}
+		items = append(items, marker+strings.Join(lines, "\n"))
+	}
+	return strings.Join(items, "\n")
+}
+
+// table renders a table as a GitHub-flavored Markdown table. The first row is used as the
+// header, since Markdown tables require one.
+func (w *writer) table(n *html.Node) string {
+	var rows [][]string
+	Walk(n, func(c *html.Node) bool {
+		if c != n && c.DataAtom == atom.Table {
+			return false // Nested tables are flattened into their cell
+		}
+		if c.DataAtom != atom.Tr {
+			return true
+		}
+		var cells []string
+		for cell := c.FirstChild; cell != nil; cell = cell.NextSibling {
+			if cell.DataAtom == atom.Td || cell.DataAtom == atom.Th {
+				text := strings.ReplaceAll(w.inlineChildren(cell), "\n", " ")
+				cells = append(cells, strings.ReplaceAll(text, "|", `\|`))
+			}
+		}
+		if len(cells) > 0 {
+			rows = append(rows, cells)
+		}
+		return false
+	})
+	if len(rows) == 0 {
+		return ""
+	}
+
+	columns := 0
+	for _, row := range rows {
+		columns = max(columns, len(row))
+	}
+
+	var b strings.Builder
+	for i, row := range rows {
+		for len(row) < columns {
+			row = append(row, "")
+		}
+		b.WriteString("| " + strings.Join(row, " | ") + " |\n")
+		if i == 0 {
+			b.WriteString("|" + strings.Repeat(" --- |", columns) + "\n")
+		}
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// inlineChildren renders the children of n as a single paragraph.
+func (w *writer) inlineChildren(n *html.Node) string {
+	var b strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		b.WriteString(w.inlineText(c))
+	}
+	return normalizeParagraph(b.String())
+}
+
+// inlineText renders a node as inline Markdown.
+func (w *writer) inlineText(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return collapseRuns(n.Data)
+	}
+	if n.Type != html.ElementNode || w.skip(n) {
+		return ""
+	}
+
+	var b strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		b.WriteString(w.inlineText(c))
+	}
+	inner := b.String()
+
+	if w.options.Inline != nil {
+		if rendered, ok := w.options.Inline(n, inner); ok {
+			return rendered
+		}
+	}
+
+	switch n.DataAtom {
+	case atom.Br:
+		return "\n"
+	case atom.Input:
+		if Attr(n, "type") != "checkbox" {
+			return ""
+		}
+		if HasAttr(n, "checked") {
+			return CheckedBox + " "
+		}
+		return UncheckedBox + " "
+	case atom.Img:
+		return fmt.Sprintf("![%s](%s)", CollapseSpace(Attr(n, "alt")), w.resolve(Attr(n, "src")))
+	case atom.A:
+		href := w.resolve(Attr(n, "href"))
+		text := strings.TrimSpace(inner)
+		if href == "" || strings.HasPrefix(href, "javascript:") || text == "" {
+			return inner
+		}
+		if text == href {
+			return href
+		}
+		return wrap(inner, "[", "]("+href+")")
+	case atom.Strong, atom.B:
+		return wrap(inner, "**", "**")
+	case atom.Em, atom.I:
+		return wrap(inner, "*", "*")
+	case atom.Code, atom.Kbd, atom.Samp:
+		return wrap(collapseRuns(TextContent(n)), "`", "`")
+	case atom.Del, atom.S, atom.Strike:
+		return wrap(inner, "~~", "~~")
+	}
+
+	if !inlineElements[n.DataAtom] {
+		// Block elements nested in inline content still need separating from their neighbours.
+		return " " + inner + " "
+	}
+	return inner
+}
+
+// resolve makes a link absolute against the base URL. Fragment-only links are dropped.
+func (w *writer) resolve(href string) string {
+	href = strings.TrimSpace(href)
+	if href == "" || strings.HasPrefix(href, "#") {
+		return ""
+	}
+	ref, err := url.Parse(href)
+	if err != nil || w.options.Base == nil {
+		return href
+	}
+	return w.options.Base.ResolveReference(ref).String()
+}
+
+// codeBlock renders a pre element as a fenced code block, taking the language from a
+// "language-*" or "lang-*" class when present.
+func codeBlock(n *html.Node) string {
+	code := strings.Trim(TextContent(n), "\n")
+	if strings.TrimSpace(code) == "" {
+		return ""
+	}
+
+	language := ""
+	classes := Attr(n, "class")
+	if child := FindFirst(n, atom.Code); child != nil {
+		classes += " " + Attr(child, "class")
+	}
+	for _, class := range strings.Fields(classes) {
+		if lang, ok := strings.CutPrefix(class, "language-"); ok {
+			language = lang
+			break
+		}
+		if lang, ok := strings.CutPrefix(class, "lang-"); ok {
+			language = lang
+			break
+		}
+	}
+
+	fence := "```"
+	for strings.Contains(code, fence) {
+		fence += "`"
+	}
+	return fence + language + "\n" + code + "\n" + fence
+}
+
+// wrap surrounds the trimmed text with markers, keeping the surrounding whitespace outside them.
+func wrap(text, open, close string) string {
+	trimmed := strings.TrimSpace(text)
+	if trimmed == "" {
+		return text
+	}
+	lead := text[:strings.Index(text, trimmed)]
+	trail := text[len(lead)+len(trimmed):]
+	return lead + open + trimmed + close + trail
+}
+
+// normalizeParagraph collapses whitespace in every line of a paragraph and drops empty lines.
+func normalizeParagraph(text string) string {
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		if line = CollapseSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// prefixLines prefixes every line of text, used for block quotes.
+func prefixLines(text, prefix string) string {
+	if text == "" {
+		return ""
+	}
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(prefix+line, " ")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// collapseRuns replaces every run of whitespace with a single space, keeping a leading or trailing one.
+func collapseRuns(text string) string {
+	fields := strings.Fields(text)
+	if len(fields) == 0 {
+		if text != "" {
+			return " "
+		}
+		return ""
+	}
+	result := strings.Join(fields, " ")
+	if first := fields[0]; !strings.HasPrefix(text, first) {
+		result = " " + result
+	}
+	if last := fields[len(fields)-1]; !strings.HasSuffix(text, last) {
+		result += " "
+	}
+	return result
+}
+
+// CollapseSpace replaces every run of whitespace with a single space and trims the result.
+func CollapseSpace(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
+
+// TextContent returns the raw text of a node and its descendants, with line breaks as newlines.
+func TextContent(n *html.Node) string {
+	var b strings.Builder
+	Walk(n, func(c *html.Node) bool {
+		switch {
+		case c.Type == html.TextNode:
+			b.WriteString(c.Data)
+		case c.DataAtom == atom.Br:
+			b.WriteString("\n")
+		}
+		return true
+	})
+	return b.String()
+}
+
+// Attr returns the value of an attribute, or an empty string if it is not set.
+func Attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// HasAttr reports whether an attribute is set, with or without a value.
+func HasAttr(n *html.Node, key string) bool {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// Walk visits n and its descendants depth-first. Children are skipped when visit returns false.
+func Walk(n *html.Node, visit func(*html.Node) bool) {
+	if !visit(n) {
+		return
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		Walk(c, visit)
+	}
+}
+
+// FindFirst returns the first element of the given type in n, depth-first, or nil.
+func FindFirst(n *html.Node, a atom.Atom) *html.Node {
+	var found *html.Node
+	Walk(n, func(c *html.Node) bool {
+		if found != nil {
+			return false
+		}
+		if c.Type == html.ElementNode && c.DataAtom == a {
+			found = c
+			return false
+		}
+		return true
+	})
+	return found
+}
+
+// FindAll returns every element of the given type in n, depth-first.
+func FindAll(n *html.Node, a atom.Atom) []*html.Node {
+	var found []*html.Node
+	Walk(n, func(c *html.Node) bool {
+		if c.Type == html.ElementNode && c.DataAtom == a {
+			found = append(found, c)
+		}
+		return true
+	})
+	return found
+}
@@ -0,0 +1,43 @@
+package htmlmd
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+func TestConvertFragment(t *testing.T) {
+	Convey("Given HTML with block and inline content", t, func() {
+		Convey("It should render blocks, check lists and inline markup", func() {
+			So(ConvertFragment(`<h2>Plan</h2><p>Make <b>login</b>&nbsp;<i>fast</i><script>x()</script></p>`+
+				`<ol start="3"><li>One</li><li><input type="checkbox" checked>Two</li></ol>`+
+				`<blockquote>Quoted<br>twice</blockquote><pre class="lang-sh">make all</pre>`, Options{}), ShouldEqual,
+				"## Plan\n\nMake **login** *fast*\n\n3. One\n4. [x] Two\n\n> Quoted\n> twice\n\n```sh\nmake all\n```")
+		})
+
+		Convey("It should resolve links against the base and drop fragment links", func() {
+			base, _ := url.Parse("https://example.com/docs/guide")
+			So(ConvertFragment(`<a href="setup">Setup</a> <a href="#top">top</a> <a href="https://example.com/x">https://example.com/x</a>`, Options{Base: base}), ShouldEqual,
+				"[Setup](https://example.com/docs/setup) top https://example.com/x")
+		})
+
+		Convey("It should let options skip and render elements", func() {
+			options := Options{
+				Skip: func(n *html.Node) bool { return n.DataAtom == atom.Nav },
+				Inline: func(n *html.Node, inner string) (string, bool) {
+					return strings.ToUpper(inner), n.DataAtom == atom.Mark
+				},
+			}
+			So(ConvertFragment(`<nav>Menu</nav><p>A <mark>note</mark></p>`, options), ShouldEqual, "A NOTE")
+		})
+
+		Convey("It should flatten nested tables into their cell", func() {
+			So(ConvertFragment(`<table><tr><td>a|b</td><td><table><tr><td>inner</td></tr></table></td></tr></table>`, Options{}), ShouldEqual,
+				"| a\\|b | inner |\n| --- | --- |")
+		})
+	})
+}